@@ -10,6 +10,7 @@ import (
 	"flag"
 	"log"
 	"math/rand"
+	"os"
 	"sync"
 	"time"
 
@@ -55,6 +56,13 @@ func init() {
 }
 
 func main() {
+	// operator subcommands: inspect/dump/verify/repair
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(cmd(os.Args[2:]))
+		}
+	}
+
 	flag.Parse()
 	var err error
 
@@ -0,0 +1,235 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dc "github.com/GuanceCloud/cliutils/diskcache"
+	"github.com/GuanceCloud/cliutils/point"
+)
+
+// subcommands of the operator tool, all of them work offline against a
+// (copy of) cache directory.
+var subcommands = map[string]func(args []string) int{
+	"inspect": runInspect,
+	"dump":    runDump,
+	"verify":  runVerify,
+	"repair":  runRepair,
+}
+
+// keysFlag accept encryption keys in form of `id:secret`.
+type keysFlag []*dc.EncryptionKey
+
+func (k *keysFlag) String() string {
+	var ids []string
+	for _, x := range *k {
+		ids = append(ids, x.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func (k *keysFlag) Set(s string) error {
+	arr := strings.SplitN(s, ":", 2)
+	if len(arr) != 2 {
+		return fmt.Errorf("invalid key %q, should be id:secret", s)
+	}
+
+	*k = append(*k, &dc.EncryptionKey{ID: arr[0], Key: []byte(arr[1])})
+	return nil
+}
+
+func runInspect(args []string) int {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	dir := fs.String("path", "./diskcache", "cache path")
+	fs.Parse(args) //nolint:errcheck
+
+	ci, err := dc.Inspect(*dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "inspect: %s\n", err)
+		return 1
+	}
+
+	switch {
+	case ci.LockPID > 0:
+		fmt.Printf("lock: pid %d(alive: %v)\n", ci.LockPID, ci.LockAlive)
+	default:
+		fmt.Println("lock: unlocked")
+	}
+
+	for _, pi := range ci.Pos {
+		name := pi.Consumer
+		if name == "" {
+			name = "<default>"
+		}
+
+		fmt.Printf("pos: %s -> %s:%d\n", name, filepath.Base(pi.Name), pi.Seek)
+	}
+
+	var size int64
+	records := 0
+	for _, fi := range ci.DataFiles {
+		size += fi.Size
+		records += fi.Records
+
+		state := "ok"
+		if fi.Err != nil {
+			state = fi.Err.Error()
+		}
+
+		fmt.Printf("%s: %d bytes, %d records, eof: %v, %s\n",
+			filepath.Base(fi.Path), fi.Size, fi.Records, fi.EOF, state)
+	}
+
+	fmt.Printf("total: %d files, %d bytes, %d records\n", len(ci.DataFiles), size, records)
+	return 0
+}
+
+func runDump(args []string) int {
+	var keys keysFlag
+
+	fs := flag.NewFlagSet("dump", flag.ExitOnError)
+	dir := fs.String("path", "./diskcache", "cache path")
+	file := fs.String("file", "", "dump the data file only(base name, such as data.00000000000000000000000000000001)")
+	decode := fs.String("decode", "raw", "decode record as raw/point(protobuf)/lp(line-protocol)")
+	limit := fs.Int("limit", 0, "max records to dump, 0 for no limit")
+	fs.Var(&keys, "key", "decryption key in form of id:secret, can be set multiple times")
+	fs.Parse(args) //nolint:errcheck
+
+	ci, err := dc.Inspect(*dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dump: %s\n", err)
+		return 1
+	}
+
+	errLimit := errors.New("limit reached")
+	n := 0
+
+	for _, fi := range ci.DataFiles {
+		if *file != "" && filepath.Base(fi.Path) != *file {
+			continue
+		}
+
+		if _, err := dc.ScanDataFile(fi.Path, func(rec *dc.Record) error {
+			if *limit > 0 && n >= *limit {
+				return errLimit
+			}
+			n++
+
+			fmt.Printf("%s@%d: %d bytes, checksum: %v, compress: %s, encrypted: %v\n",
+				filepath.Base(fi.Path), rec.Offset, rec.Size, rec.Checksum, rec.Compress, rec.Encrypted)
+
+			data, err := dc.DecodeRecord(rec, keys...)
+			if err != nil {
+				fmt.Printf("\t<%s>\n", err)
+				return nil
+			}
+
+			dumpData(data, *decode)
+			return nil
+		}); err != nil {
+			if errors.Is(err, errLimit) {
+				break
+			}
+
+			fmt.Fprintf(os.Stderr, "dump: %s\n", err)
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func dumpData(data []byte, decode string) {
+	var enc point.Encoding
+
+	switch decode {
+	case "point":
+		enc = point.Protobuf
+	case "lp":
+		enc = point.LineProtocol
+	default:
+		fmt.Printf("\t%q\n", data)
+		return
+	}
+
+	dec := point.GetDecoder(point.WithDecEncoding(enc))
+	defer point.PutDecoder(dec)
+
+	pts, err := dec.Decode(data)
+	if err != nil {
+		fmt.Printf("\t<decode %s: %s>\n", decode, err)
+		return
+	}
+
+	for _, pt := range pts {
+		fmt.Printf("\t%s\n", pt.LineProto())
+	}
+}
+
+func runVerify(args []string) int {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	dir := fs.String("path", "./diskcache", "cache path")
+	fs.Parse(args) //nolint:errcheck
+
+	ci, err := dc.Inspect(*dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "verify: %s\n", err)
+		return 1
+	}
+
+	bad := 0
+	for _, fi := range ci.DataFiles {
+		if fi.Err != nil {
+			bad++
+			fmt.Printf("%s: %s(%d bytes broken)\n", filepath.Base(fi.Path), fi.Err, fi.Size-fi.ValidSize)
+		}
+	}
+
+	if bad > 0 {
+		fmt.Printf("%d of %d data files broken, run `repair` to fix them\n", bad, len(ci.DataFiles))
+		return 1
+	}
+
+	fmt.Printf("all %d data files ok\n", len(ci.DataFiles))
+	return 0
+}
+
+func runRepair(args []string) int {
+	fs := flag.NewFlagSet("repair", flag.ExitOnError)
+	dir := fs.String("path", "./diskcache", "cache path")
+	fs.Parse(args) //nolint:errcheck
+
+	res, err := dc.Repair(*dir)
+	if err != nil {
+		if errors.Is(err, dc.ErrCacheInUse) {
+			fmt.Fprintf(os.Stderr, "repair: %s, stop it or repair on a copy\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "repair: %s\n", err)
+		}
+		return 1
+	}
+
+	for _, fi := range res.Truncated {
+		fmt.Printf("%s: truncated to %d bytes\n", filepath.Base(fi.Path), fi.Size)
+	}
+
+	for _, pi := range res.Pos {
+		if pi.Name == "" {
+			fmt.Printf("%s: removed\n", filepath.Base(pi.File))
+		} else {
+			fmt.Printf("%s: reset to %s:%d\n", filepath.Base(pi.File), filepath.Base(pi.Name), pi.Seek)
+		}
+	}
+
+	fmt.Printf("%d data files truncated, %d pos files fixed\n", len(res.Truncated), len(res.Pos))
+	return 0
+}
@@ -0,0 +1,294 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const archiveDir = "archive"
+
+var (
+	// Data file not found within the cache and archive.
+	ErrFileNotFound = errors.New("data file not found")
+
+	// Offset not on record boundary.
+	ErrInvalidOffset = errors.New("invalid offset")
+)
+
+// loadArchive list archived files. The caller should hold the rwlock.
+func (c *DiskCache) loadArchive() error {
+	dir := filepath.Join(c.path, archiveDir)
+	if err := os.MkdirAll(dir, c.dirPerms); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	c.archiveFiles = c.archiveFiles[:0]
+	c.archiveSize = 0
+
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		fi, err := e.Info()
+		if err != nil {
+			return err
+		}
+
+		c.archiveFiles = append(c.archiveFiles, filepath.Join(dir, e.Name()))
+		c.archiveSize += fi.Size()
+	}
+
+	sort.Strings(c.archiveFiles)
+
+	return c.trimArchive()
+}
+
+// archiveFile move consumed data file fname into archive. The caller should hold the rwlock.
+func (c *DiskCache) archiveFile(fname string, size int64) error {
+	dst := filepath.Join(c.path, archiveDir, filepath.Base(fname))
+	if err := os.Rename(fname, dst); err != nil {
+		return fmt.Errorf("archive %q: %w", fname, err)
+	}
+
+	c.archiveFiles = append(c.archiveFiles, dst)
+	sort.Strings(c.archiveFiles)
+	c.archiveSize += size
+
+	return c.trimArchive()
+}
+
+// trimArchive remove oldest archived files if archive capacity exceeded or
+// they are older than max-age.
+func (c *DiskCache) trimArchive() error {
+	defer c.setArchiveSizeMetric()
+
+	for len(c.archiveFiles) > 0 {
+		fname := c.archiveFiles[0]
+
+		fi, err := os.Stat(fname)
+		if err != nil {
+			return err
+		}
+
+		full := c.archiveCapacity > 0 && c.archiveSize > c.archiveCapacity
+		expired := c.maxAge > 0 && time.Since(fi.ModTime()) >= c.maxAge
+		if !full && !expired {
+			return nil
+		}
+
+		if err := os.Remove(fname); err != nil {
+			return err
+		}
+
+		c.archiveFiles = c.archiveFiles[1:]
+		c.archiveSize -= fi.Size()
+	}
+
+	return nil
+}
+
+// restoreArchive move archived files not older than base back into the cache.
+// The caller should hold the rwlock.
+func (c *DiskCache) restoreArchive(base string) error {
+	for len(c.archiveFiles) > 0 {
+		src := c.archiveFiles[len(c.archiveFiles)-1]
+		if filepath.Base(src) < base {
+			break
+		}
+
+		fi, err := os.Stat(src)
+		if err != nil {
+			return err
+		}
+
+		dst := filepath.Join(c.path, filepath.Base(src))
+		if err := os.Rename(src, dst); err != nil {
+			return fmt.Errorf("restore %q: %w", src, err)
+		}
+
+		c.archiveFiles = c.archiveFiles[:len(c.archiveFiles)-1]
+		c.archiveSize -= fi.Size()
+
+		c.dataFiles = append(c.dataFiles, dst)
+		c.dataFileSizes[dst] = fi.Size()
+
+		if fi.Size() > dataHeaderLen {
+			c.size.Add(fi.Size())
+			sizeVec.WithLabelValues(c.metricPath()).Add(float64(fi.Size()))
+		}
+	}
+
+	sort.Strings(c.dataFiles)
+	c.setDatafilesMetric()
+	c.setArchiveSizeMetric()
+
+	return nil
+}
+
+// Rewind move the read position back to the data file that contains data put
+// at time to, so data after that can be read again. The data file may be
+// archived(see WithArchive()) or still kept for other consumers.
+//
+// The time of data file is based on it's modify time, i.e., time of the
+// newest data within the file, so some data before to may also be read again.
+func (c *DiskCache) Rewind(to time.Time) error {
+	if len(c.lanes) > 0 {
+		for _, l := range c.lanes {
+			if err := l.Rewind(to); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	r, err := c.defaultReader()
+	if err != nil {
+		return err
+	}
+
+	return c.doRewind(r, to)
+}
+
+// SeekTo move the read position to offset of the data file. The offset should
+// be the beginning of a record, file can be the base name of the data file.
+func (c *DiskCache) SeekTo(file string, offset int64) error {
+	if len(c.lanes) > 0 {
+		return fmt.Errorf("SeekTo() not supported with priority lanes or shards, use Rewind()")
+	}
+
+	r, err := c.defaultReader()
+	if err != nil {
+		return err
+	}
+
+	return c.doSeekTo(r, file, offset)
+}
+
+func (c *DiskCache) doRewind(r *reader, to time.Time) error {
+	file, err := func() (string, error) {
+		c.rwlock.Lock()
+		defer c.rwlock.Unlock()
+
+		for _, f := range append(append([]string{}, c.archiveFiles...), c.dataFiles...) {
+			fi, err := os.Stat(f)
+			if err != nil {
+				return "", err
+			}
+
+			if !fi.ModTime().Before(to) {
+				return f, nil
+			}
+		}
+
+		return "", nil
+	}()
+	if err != nil {
+		return err
+	}
+
+	if file == "" { // all data are older than to
+		return nil
+	}
+
+	return c.doSeekTo(r, file, 0)
+}
+
+func (c *DiskCache) doSeekTo(r *reader, file string, offset int64) error {
+	r.rlock.Lock()
+	defer r.rlock.Unlock()
+
+	if err := c.checkPendingLeases(r); err != nil {
+		return err
+	}
+
+	c.rwlock.Lock()
+	defer c.rwlock.Unlock()
+
+	base := filepath.Base(file)
+	if err := c.restoreArchive(base); err != nil {
+		return err
+	}
+
+	fname := filepath.Join(c.path, base)
+	if _, ok := c.dataFileSizes[fname]; !ok {
+		return fmt.Errorf("%w: %q", ErrFileNotFound, file)
+	}
+
+	if offset != 0 {
+		fi, err := ScanDataFile(fname, nil)
+		if err != nil {
+			return err
+		}
+
+		valid := false
+		for _, off := range fi.Offsets {
+			if off == offset {
+				valid = true
+				break
+			}
+		}
+
+		if !valid {
+			return fmt.Errorf("%w: %d", ErrInvalidOffset, offset)
+		}
+	}
+
+	fd, err := os.OpenFile(fname, os.O_RDONLY, c.filePerms)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fd.Seek(offset, io.SeekStart); err != nil {
+		fd.Close() //nolint:errcheck,gosec
+		return err
+	}
+
+	fi, err := fd.Stat()
+	if err != nil {
+		fd.Close() //nolint:errcheck,gosec
+		return err
+	}
+
+	if r.rfd != nil {
+		if err := r.rfd.Close(); err != nil {
+			fd.Close() //nolint:errcheck,gosec
+			return err
+		}
+	}
+
+	r.rfd = fd
+	r.curReadfile = fname
+	r.lastReadfile = ""
+	r.curReadSize = fi.Size()
+
+	if !c.noPos {
+		r.pos.Name = []byte(fname)
+		r.pos.Seek = offset
+		if err := r.pos.dumpFile(); err != nil {
+			return err
+		}
+
+		posUpdatedVec.WithLabelValues("seek", c.metricPath()).Inc()
+	}
+
+	c.updateLag()
+	c.notifyData()
+
+	return nil
+}
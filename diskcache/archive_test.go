@@ -0,0 +1,209 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	T "testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func putRotate(t *T.T, c *DiskCache, data ...string) {
+	t.Helper()
+
+	for _, x := range data {
+		require.NoError(t, c.Put([]byte(x)))
+	}
+	require.NoError(t, c.Rotate())
+}
+
+func TestArchive(t *T.T) {
+	t.Run(`archive-on-consume`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithArchive(0))
+		require.NoError(t, err)
+
+		putRotate(t, c, "data-0", "data-1")
+		fname := c.dataFiles[0]
+
+		assert.Len(t, getAll(t, c), 2)
+		assert.Len(t, c.dataFiles, 0)
+
+		_, err = os.Stat(fname)
+		assert.True(t, os.IsNotExist(err))
+
+		_, err = os.Stat(filepath.Join(p, archiveDir, filepath.Base(fname)))
+		assert.NoError(t, err)
+
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(Metrics()...)
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+
+		m := metrics.GetMetricOnLabels(mfs, "diskcache_archive_size", c.path)
+		require.NotNil(t, m)
+		assert.Equal(t, float64(c.archiveSize), m.GetGauge().GetValue())
+		assert.Equal(t, 0.0, float64(c.Size()))
+
+		require.NoError(t, c.Close())
+
+		// new rotated file should not reuse archived file name
+		c, err = Open(WithPath(p), WithArchive(0))
+		require.NoError(t, err)
+		require.Len(t, c.archiveFiles, 1)
+
+		putRotate(t, c, "data-2")
+		assert.Greater(t, filepath.Base(c.dataFiles[0]), filepath.Base(fname))
+		require.NoError(t, c.Close())
+	})
+
+	t.Run(`archive-capacity`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+
+		recLen := int64(dataHeaderLen + len("data-0"))
+		fileSize := recLen + dataHeaderLen // with EOF
+		c, err := Open(WithPath(p), WithArchive(2*fileSize))
+		require.NoError(t, err)
+
+		for i := 0; i < 4; i++ {
+			putRotate(t, c, fmt.Sprintf("data-%d", i))
+		}
+		assert.Len(t, getAll(t, c), 4)
+
+		assert.Len(t, c.archiveFiles, 2)
+		assert.Equal(t, 2*fileSize, c.archiveSize)
+		require.NoError(t, c.Close())
+	})
+
+	t.Run(`rewind`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithArchive(0))
+		require.NoError(t, err)
+
+		putRotate(t, c, "data-0")
+		old := c.dataFiles[0]
+		require.NoError(t, os.Chtimes(old, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)))
+
+		mark := time.Now().Add(-time.Minute)
+		putRotate(t, c, "data-1")
+		putRotate(t, c, "data-2")
+
+		assert.Len(t, getAll(t, c), 3)
+		assert.Len(t, c.archiveFiles, 3)
+
+		require.NoError(t, c.Rewind(mark))
+		assert.Len(t, c.archiveFiles, 1)
+		assert.Equal(t, 2*int64(dataHeaderLen+len("data-0")+dataHeaderLen), c.Size())
+
+		assert.Equal(t, [][]byte{[]byte("data-1"), []byte("data-2")}, getAll(t, c))
+
+		// rewind to the very beginning
+		require.NoError(t, c.Rewind(time.Time{}))
+		assert.Equal(t, [][]byte{[]byte("data-0"), []byte("data-1"), []byte("data-2")}, getAll(t, c))
+
+		// nothing newer than the future
+		require.NoError(t, c.Rewind(time.Now().Add(time.Hour)))
+		assert.Len(t, getAll(t, c), 0)
+
+		require.NoError(t, c.Close())
+	})
+
+	t.Run(`seek-to`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithArchive(0))
+		require.NoError(t, err)
+
+		putRotate(t, c, "data-0", "data-1", "data-2")
+		fname := filepath.Base(c.dataFiles[0])
+		assert.Len(t, getAll(t, c), 3)
+
+		recLen := int64(dataHeaderLen + len("data-0"))
+		assert.ErrorIs(t, c.SeekTo(fname, recLen+1), ErrInvalidOffset)
+		assert.ErrorIs(t, c.SeekTo("data.99999999999999999999999999999999", 0), ErrFileNotFound)
+
+		require.NoError(t, c.SeekTo(fname, recLen))
+		require.NoError(t, c.Close())
+
+		// position kept after reopen
+		c, err = Open(WithPath(p), WithArchive(0))
+		require.NoError(t, err)
+		assert.Equal(t, [][]byte{[]byte("data-1"), []byte("data-2")}, getAll(t, c))
+
+		// not allowed with pending leases
+		putRotate(t, c, "data-3")
+		_, tk, err := c.Peek(1, 0)
+		require.NoError(t, err)
+		assert.ErrorIs(t, c.SeekTo(fname, 0), ErrPendingLeases)
+		require.NoError(t, c.Commit(tk))
+
+		require.NoError(t, c.Close())
+	})
+
+	t.Run(`seek-to-compressed`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithArchive(0), WithCompression(CompressSnappy), WithChecksum(true))
+		require.NoError(t, err)
+
+		putRotate(t, c, "data-0", "data-1")
+		fname := filepath.Base(c.dataFiles[0])
+		assert.Len(t, getAll(t, c), 2)
+
+		require.NoError(t, c.SeekTo(fname, 0))
+		assert.Equal(t, [][]byte{[]byte("data-0"), []byte("data-1")}, getAll(t, c))
+
+		require.NoError(t, c.Close())
+	})
+
+	t.Run(`consumers`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithArchive(0), WithConsumers("cloud", "local"))
+		require.NoError(t, err)
+
+		putRotate(t, c, "data-0", "data-1")
+
+		cloud := mustConsumer(t, c, "cloud")
+		local := mustConsumer(t, c, "local")
+
+		for i := 0; i < 2; i++ {
+			require.NoError(t, cloud.Get(nil))
+			require.NoError(t, local.Get(nil))
+		}
+		assert.ErrorIs(t, cloud.Get(nil), ErrNoData)
+		assert.ErrorIs(t, local.Get(nil), ErrNoData)
+		assert.Len(t, c.archiveFiles, 1)
+
+		// replay on cloud only
+		require.NoError(t, cloud.Rewind(time.Time{}))
+
+		var got []string
+		for {
+			if err := cloud.Get(func(x []byte) error {
+				got = append(got, string(x))
+				return nil
+			}); err != nil {
+				require.ErrorIs(t, err, ErrNoData)
+				break
+			}
+		}
+		assert.Equal(t, []string{"data-0", "data-1"}, got)
+		assert.ErrorIs(t, local.Get(nil), ErrNoData)
+
+		require.NoError(t, c.Close())
+	})
+}
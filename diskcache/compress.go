@@ -0,0 +1,201 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// CompressAlgo is the compression algorithm used on Put() data.
+type CompressAlgo uint8
+
+const (
+	NoCompress CompressAlgo = iota
+	CompressZstd
+	CompressLZ4
+	CompressSnappy
+
+	maxCompressAlgo = CompressSnappy
+)
+
+// Bits within extended record flags that remember the compression algorithm.
+const (
+	flagCodecShift = 1
+	flagCodecMask  = uint8(0x3 << flagCodecShift)
+
+	// compressed payload prefixed with 4 bytes raw data length.
+	rawLenPrefix = 4
+)
+
+var (
+	// Invalid compressed data.
+	ErrBadCompressedData = errors.New("bad compressed data")
+
+	zstdOnce sync.Once
+	zstdEnc  *zstd.Encoder
+	zstdDec  *zstd.Decoder
+	zstdErr  error
+)
+
+func (a CompressAlgo) String() string {
+	switch a {
+	case NoCompress:
+		return "none"
+	case CompressZstd:
+		return "zstd"
+	case CompressLZ4:
+		return "lz4"
+	case CompressSnappy:
+		return "snappy"
+	default:
+		return fmt.Sprintf("unknown(%d)", a)
+	}
+}
+
+// ParseCompressAlgo get compression algorithm from it's name.
+func ParseCompressAlgo(s string) (CompressAlgo, error) {
+	switch strings.ToLower(s) {
+	case "", "none":
+		return NoCompress, nil
+	case "zstd":
+		return CompressZstd, nil
+	case "lz4":
+		return CompressLZ4, nil
+	case "snappy":
+		return CompressSnappy, nil
+	default:
+		return NoCompress, fmt.Errorf("unknown compression algorithm %q", s)
+	}
+}
+
+// setupZstd create the shared zstd encoder/decoder on first use.
+func setupZstd() error {
+	zstdOnce.Do(func() {
+		// EncodeAll/DecodeAll are safe for concurrent use, so we share them among caches.
+		var err error
+		if zstdEnc, err = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1)); err != nil {
+			zstdErr = fmt.Errorf("zstd.NewWriter: %w", err)
+			return
+		}
+
+		if zstdDec, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1)); err != nil {
+			zstdErr = fmt.Errorf("zstd.NewReader: %w", err)
+		}
+	})
+
+	return zstdErr
+}
+
+func codecOf(flags uint8) CompressAlgo {
+	return CompressAlgo((flags & flagCodecMask) >> flagCodecShift)
+}
+
+// compress data with algorithm a. If data is not compressible,
+// nil returned and we should write the raw data.
+func (a CompressAlgo) compress(data []byte) ([]byte, error) {
+	if a == NoCompress || len(data) == 0 {
+		return nil, nil
+	}
+
+	var out []byte
+
+	switch a {
+	case CompressZstd:
+		if err := setupZstd(); err != nil {
+			return nil, err
+		}
+		out = zstdEnc.EncodeAll(data, make([]byte, rawLenPrefix, rawLenPrefix+len(data)/2))
+
+	case CompressLZ4:
+		out = make([]byte, rawLenPrefix+lz4.CompressBlockBound(len(data)))
+		n, err := lz4.CompressBlock(data, out[rawLenPrefix:], nil)
+		if err != nil || n == 0 { // not compressible
+			return nil, nil
+		}
+		out = out[:rawLenPrefix+n]
+
+	case CompressSnappy:
+		out = make([]byte, rawLenPrefix+s2.MaxEncodedLen(len(data)))
+		enc := s2.EncodeSnappy(out[rawLenPrefix:], data)
+		out = out[:rawLenPrefix+len(enc)]
+
+	case NoCompress:
+		return nil, nil
+	}
+
+	if len(out) >= len(data) { // compression not worth
+		return nil, nil
+	}
+
+	binary.LittleEndian.PutUint32(out, uint32(len(data)))
+	return out, nil
+}
+
+// rawLen get raw data length of the compressed payload.
+func rawLen(payload []byte) (int, error) {
+	if len(payload) < rawLenPrefix {
+		return 0, ErrBadCompressedData
+	}
+
+	return int(binary.LittleEndian.Uint32(payload)), nil
+}
+
+// decompress payload into dst, dst should be large enough to hold the raw data.
+func (a CompressAlgo) decompress(payload, dst []byte) error {
+	n, err := rawLen(payload)
+	if err != nil {
+		return err
+	}
+
+	if len(dst) < n {
+		return ErrTooSmallReadBuf
+	}
+
+	src, dst := payload[rawLenPrefix:], dst[:n]
+
+	var out []byte
+
+	switch a {
+	case CompressZstd:
+		if err = setupZstd(); err != nil {
+			return err
+		}
+		out, err = zstdDec.DecodeAll(src, dst[:0])
+	case CompressLZ4:
+		var x int
+		x, err = lz4.UncompressBlock(src, dst)
+		out = dst[:x]
+	case CompressSnappy:
+		out, err = s2.Decode(dst, src)
+	case NoCompress:
+		return nil
+	default:
+		return ErrBadCompressedData
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBadCompressedData, err.Error())
+	}
+
+	if len(out) != n {
+		return ErrBadCompressedData
+	}
+
+	// decoder may allocate new buffer
+	if len(out) > 0 && &out[0] != &dst[0] {
+		copy(dst, out)
+	}
+
+	return nil
+}
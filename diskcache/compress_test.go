@@ -0,0 +1,161 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	T "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompression(t *T.T) {
+	compressible := []byte(strings.Repeat("measurement,tag1=value1,tag2=value2 f1=1i,f2=2.0 1700000000000000000\n", 100))
+
+	for _, algo := range []CompressAlgo{CompressZstd, CompressLZ4, CompressSnappy} {
+		t.Run(algo.String(), func(t *T.T) {
+			ResetMetrics()
+			p := t.TempDir()
+			c, err := Open(WithPath(p), WithCompression(algo))
+			require.NoError(t, err)
+
+			require.NoError(t, c.Put(compressible))
+			require.NoError(t, c.Put([]byte("x"))) // too small to compress
+			require.NoError(t, c.StreamPut(bytes.NewReader(compressible), len(compressible)))
+			require.NoError(t, c.Rotate())
+
+			// size counted on on-disk bytes
+			assert.Less(t, c.Size(), int64(len(compressible)))
+
+			res := getAll(t, c)
+			require.Len(t, res, 3)
+			assert.Equal(t, compressible, res[0])
+			assert.Equal(t, []byte("x"), res[1])
+			assert.Equal(t, compressible, res[2])
+
+			assert.NoError(t, c.Close())
+		})
+	}
+
+	t.Run(`mixed-algo`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+
+		var expect [][]byte
+		for i, algo := range []CompressAlgo{NoCompress, CompressZstd, CompressLZ4, CompressSnappy} {
+			c, err := Open(WithPath(p), WithCompression(algo), WithChecksum(i%2 == 0))
+			require.NoError(t, err)
+
+			data := append([]byte(fmt.Sprintf("%d:", i)), compressible...)
+			require.NoError(t, c.Put(data))
+			expect = append(expect, data)
+
+			require.NoError(t, c.Close())
+		}
+
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+		require.NoError(t, c.Rotate())
+
+		assert.Equal(t, expect, getAll(t, c))
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`buf-get`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithCompression(CompressZstd))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put(compressible))
+		require.NoError(t, c.Put(compressible))
+		require.NoError(t, c.Rotate())
+
+		// read buffer should hold the decompressed data
+		assert.ErrorIs(t, c.BufGet(make([]byte, len(compressible)-1), nil), ErrTooSmallReadBuf)
+
+		buf := make([]byte, len(compressible))
+		assert.NoError(t, c.BufGet(buf, func(x []byte) error {
+			assert.Equal(t, compressible, x)
+			return nil
+		}))
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`bad-compressed-data`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithCompression(CompressSnappy))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put(compressible))
+		require.NoError(t, c.Put([]byte("next")))
+		require.NoError(t, c.Rotate())
+
+		// break the compressed payload, without checksum, it's detected on decompress
+		raw, err := os.ReadFile(c.dataFiles[0])
+		require.NoError(t, err)
+		raw[dataHeaderLen+recordFlagsLen+rawLenPrefix+2] ^= 0xff
+		require.NoError(t, os.WriteFile(c.dataFiles[0], raw, 0o600))
+
+		res := getAll(t, c)
+		require.Len(t, res, 1)
+		assert.Equal(t, []byte("next"), res[0])
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`bad-raw-len`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithCompression(CompressZstd), WithMaxDataSize(1<<20))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put(compressible))
+		require.NoError(t, c.Put([]byte("next")))
+		require.NoError(t, c.Rotate())
+
+		// a huge raw length should not be allocated on Get()
+		raw, err := os.ReadFile(c.dataFiles[0])
+		require.NoError(t, err)
+		copy(raw[dataHeaderLen+recordFlagsLen:], []byte{0xff, 0xff, 0xff, 0xff})
+		require.NoError(t, os.WriteFile(c.dataFiles[0], raw, 0o600))
+
+		res := getAll(t, c)
+		require.Len(t, res, 1)
+		assert.Equal(t, []byte("next"), res[0])
+
+		assert.NoError(t, c.Close())
+	})
+}
+
+func BenchmarkCompression(b *T.B) {
+	data := []byte(strings.Repeat("measurement,tag1=value1,tag2=value2 f1=1i,f2=2.0 1700000000000000000\n", 1000))
+
+	for _, algo := range []CompressAlgo{NoCompress, CompressZstd, CompressLZ4, CompressSnappy} {
+		b.Run(algo.String(), func(b *T.B) {
+			p := b.TempDir()
+			c, err := Open(WithPath(p), WithNoSync(true), WithCompression(algo))
+			require.NoError(b, err)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				c.Put(data)
+			}
+
+			b.StopTimer()
+			assert.NoError(b, c.Close())
+			ResetMetrics()
+		})
+	}
+}
@@ -0,0 +1,290 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// Consumer not registered by WithConsumers().
+	ErrUnknownConsumer = errors.New("unknown consumer")
+
+	// Cache opened with named consumers, Get() on DiskCache not allowed.
+	ErrConsumerRequired = errors.New("named consumer required")
+
+	consumerNameRe = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+)
+
+// reader is a read cursor on the cache.
+type reader struct {
+	name string // consumer name, empty for the default reader
+
+	rfd *os.File // current read fd
+
+	curReadfile, // current reading file
+	lastReadfile string // last file that read to EOF
+	curReadSize int64 // current reading file's size
+
+	pos   *pos        // current read fd position info
+	rlock *sync.Mutex // exclude concurrent Get on the reader
+
+	recordHeader, // header and crc of extended record
+	encodedBuf []byte // reused buffer to read compressed/encrypted data during Get()
+
+	lagBytes atomic.Int64 // bytes of files after current reading file
+
+	dropped atomic.Bool // current reading file dropped, see dropOldestFile()
+
+	leases   []*lease // uncommitted Peek() batches in read order
+	leaseSeq uint64
+}
+
+func newReader(name string) *reader {
+	return &reader{
+		name:         name,
+		rlock:        &sync.Mutex{},
+		recordHeader: make([]byte, dataHeaderLen+recordFlagsLen+recordCRCLen),
+		pos: &pos{
+			Seek: 0,
+			Name: nil,
+		},
+	}
+}
+
+// passed test if the reader has read through the data file f.
+func (r *reader) passed(f string) bool {
+	if r.curReadfile != "" {
+		return r.curReadfile > f
+	}
+
+	return r.lastReadfile >= f
+}
+
+// posFile get .pos filename of the reader.
+func (r *reader) posFile(path string) string {
+	if r.name == "" {
+		return filepath.Join(path, ".pos")
+	}
+
+	return filepath.Join(path, ".pos."+r.name)
+}
+
+func (r *reader) close() error {
+	if r.rfd != nil {
+		if err := r.rfd.Close(); err != nil {
+			return err
+		}
+		r.rfd = nil
+	}
+
+	if r.pos != nil {
+		if err := r.pos.close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Consumer is a named consumer on the cache. Each consumer has it's own
+// read position(persisted in file .pos.<name>), and all consumers read
+// the same data. A data file removed only after all consumers read through it.
+//
+// A Consumer is safe for concurrent use by multiple goroutines.
+type Consumer struct {
+	c *DiskCache
+	r *reader
+}
+
+// Consumer get the consumer by name, the name should be registered by WithConsumers().
+func (c *DiskCache) Consumer(name string) (*Consumer, error) {
+	for _, r := range c.readers {
+		if r.name != "" && r.name == name {
+			return &Consumer{c: c, r: r}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownConsumer, name)
+}
+
+// Consumers return names of all named consumers.
+func (c *DiskCache) Consumers() (names []string) {
+	for _, r := range c.readers {
+		if r.name != "" {
+			names = append(names, r.name)
+		}
+	}
+
+	return
+}
+
+// Name return the consumer name.
+func (cs *Consumer) Name() string {
+	return cs.r.name
+}
+
+// Get fetch new data from disk cache for the consumer, then passing to fn.
+func (cs *Consumer) Get(fn Fn) error {
+	return cs.c.doGet(cs.r, nil, fn, nil)
+}
+
+// BufGet fetch new data from disk cache for the consumer, and read into buf.
+func (cs *Consumer) BufGet(buf []byte, fn Fn) error {
+	return cs.c.doGet(cs.r, buf, fn, nil)
+}
+
+// BufCallbackGet fetch new data from disk cache for the consumer, and read into
+// buffer that returned by bfn.
+func (cs *Consumer) BufCallbackGet(bfn BufFunc, fn Fn) error {
+	return cs.c.doGet(cs.r, nil, fn, bfn)
+}
+
+// GetContext fetch new data from disk cache for the consumer, and wait new
+// data if there is no data, see DiskCache.GetContext().
+func (cs *Consumer) GetContext(ctx context.Context, fn Fn) error {
+	return cs.c.waitGet(ctx, func() error { return cs.Get(fn) })
+}
+
+// Rewind move the consumer's read position back to time to, see DiskCache.Rewind().
+func (cs *Consumer) Rewind(to time.Time) error {
+	return cs.c.doRewind(cs.r, to)
+}
+
+// SeekTo move the consumer's read position to offset of the data file, see DiskCache.SeekTo().
+func (cs *Consumer) SeekTo(file string, offset int64) error {
+	return cs.c.doSeekTo(cs.r, file, offset)
+}
+
+// GetBatch fetch a batch of new data from disk cache for the consumer, see DiskCache.GetBatch().
+func (cs *Consumer) GetBatch(maxRecords, maxBytes int, fn BatchFn) error {
+	return cs.c.doGetBatch(cs.r, maxRecords, maxBytes, fn)
+}
+
+// Peek fetch a batch of new data for the consumer without committing, see DiskCache.Peek().
+func (cs *Consumer) Peek(maxRecords, maxBytes int) ([][]byte, LeaseToken, error) {
+	return cs.c.doPeek(cs.r, maxRecords, maxBytes)
+}
+
+// Commit the data peeked by the consumer.
+func (cs *Consumer) Commit(token LeaseToken) error {
+	return cs.c.doCommit(cs.r, token)
+}
+
+// Rollback the data peeked by the consumer.
+func (cs *Consumer) Rollback(token LeaseToken) error {
+	return cs.c.doRollback(cs.r, token)
+}
+
+// Lag return bytes that not consumed by the consumer.
+func (cs *Consumer) Lag() int64 {
+	cs.r.rlock.Lock()
+	defer cs.r.rlock.Unlock()
+
+	return cs.c.readerLag(cs.r)
+}
+
+// defaultReader get the default reader used by Get() on DiskCache.
+func (c *DiskCache) defaultReader() (*reader, error) {
+	if len(c.readers) > 0 && c.readers[0] != c.reader {
+		return nil, ErrConsumerRequired
+	}
+
+	return c.reader, nil
+}
+
+// removeConsumedFiles remove data files that all readers read through.
+// The caller should hold the rwlock.
+func (c *DiskCache) removeConsumedFiles() error {
+	for len(c.dataFiles) > 0 {
+		fname := c.dataFiles[0]
+
+		for _, r := range c.readers {
+			if !r.passed(fname) {
+				return nil
+			}
+		}
+
+		if fi, err := os.Stat(fname); err == nil { // file exist
+			if fi.Size() > dataHeaderLen {
+				c.size.Add(-fi.Size())
+				sizeVec.WithLabelValues(c.metricPath()).Sub(float64(fi.Size()))
+			}
+
+			getBytesVec.WithLabelValues(c.metricPath()).Observe(float64(fi.Size()))
+
+			if c.archive {
+				if err := c.archiveFile(fname, fi.Size()); err != nil {
+					return err
+				}
+			} else if err := os.Remove(fname); err != nil {
+				return fmt.Errorf("removeConsumedFiles: %q: %w", fname, err)
+			}
+		}
+
+		delete(c.dataFileSizes, fname)
+		c.dataFiles = c.dataFiles[1:]
+		removeVec.WithLabelValues(c.metricPath()).Inc()
+	}
+
+	return nil
+}
+
+// nextFileOf get next data file to read for r.
+func (c *DiskCache) nextFileOf(r *reader) string {
+	for _, f := range c.dataFiles {
+		if f > r.lastReadfile {
+			return f
+		}
+	}
+
+	return ""
+}
+
+// updateLag refresh bytes of unread files for all named readers.
+// The caller should hold the rwlock.
+func (c *DiskCache) updateLag() {
+	for _, r := range c.readers {
+		if r.name == "" {
+			continue
+		}
+
+		var lag int64
+		for _, f := range c.dataFiles {
+			if !r.passed(f) && f != r.curReadfile {
+				lag += c.dataFileSizes[f]
+			}
+		}
+
+		r.lagBytes.Store(lag)
+	}
+}
+
+// readerLag get bytes not consumed by r. The caller should hold r's rlock.
+func (c *DiskCache) readerLag(r *reader) int64 {
+	lag := r.lagBytes.Load()
+
+	if r.rfd != nil {
+		if off, err := r.rfd.Seek(0, io.SeekCurrent); err == nil {
+			lag += r.curReadSize - off
+		}
+	}
+
+	if r.name != "" {
+		consumerLagVec.WithLabelValues(c.metricPath(), r.name).Set(float64(lag))
+	}
+
+	return lag
+}
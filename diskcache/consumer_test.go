@@ -0,0 +1,185 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	T "testing"
+
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func consumeAll(t *T.T, cs *Consumer) (res [][]byte) {
+	t.Helper()
+
+	for {
+		if err := cs.Get(func(x []byte) error {
+			res = append(res, append([]byte{}, x...))
+			return nil
+		}); err != nil {
+			require.ErrorIs(t, err, ErrNoData)
+			return
+		}
+	}
+}
+
+func TestConsumers(t *T.T) {
+	t.Run(`fan-out`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithConsumers("cloud", "kafka"))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"cloud", "kafka"}, c.Consumers())
+
+		var expect [][]byte
+		for i := 0; i < 10; i++ {
+			data := []byte(fmt.Sprintf("data-%d", i))
+			require.NoError(t, c.Put(data))
+			expect = append(expect, data)
+		}
+		require.NoError(t, c.Rotate())
+
+		cloud, err := c.Consumer("cloud")
+		require.NoError(t, err)
+		kafka, err := c.Consumer("kafka")
+		require.NoError(t, err)
+
+		assert.Equal(t, expect, consumeAll(t, cloud))
+
+		// kafka not consumed yet, the data file should be kept
+		require.Len(t, c.dataFiles, 1)
+		_, err = os.Stat(c.dataFiles[0])
+		assert.NoError(t, err)
+
+		assert.Equal(t, expect, consumeAll(t, kafka))
+
+		// all consumers passed the file
+		assert.Len(t, c.dataFiles, 0)
+		assert.Equal(t, int64(0), c.Size())
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`default-get-not-allowed`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithConsumers("cloud"))
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, c.Get(nil), ErrConsumerRequired)
+
+		_, err = c.Consumer("not-exist")
+		assert.ErrorIs(t, err, ErrUnknownConsumer)
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`invalid-name`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		_, err := Open(WithPath(p), WithConsumers("a/b"))
+		assert.Error(t, err)
+
+		// the path not locked on invalid options
+		c, err := Open(WithPath(p), WithConsumers("a"))
+		require.NoError(t, err)
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`persisted-pos`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithConsumers("cloud", "kafka"))
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, c.Put([]byte(fmt.Sprintf("data-%d", i))))
+		}
+		require.NoError(t, c.Rotate())
+
+		cloud, err := c.Consumer("cloud")
+		require.NoError(t, err)
+		require.NoError(t, cloud.Get(nil))                           // data-0
+		assert.Len(t, consumeAll(t, mustConsumer(t, c, "kafka")), 3) // kafka read through the file
+		require.NoError(t, c.Close())
+
+		_, err = os.Stat(filepath.Join(p, ".pos.cloud"))
+		assert.NoError(t, err)
+		_, err = os.Stat(filepath.Join(p, ".pos.kafka"))
+		assert.NoError(t, err)
+
+		c, err = Open(WithPath(p), WithConsumers("cloud", "kafka"))
+		require.NoError(t, err)
+
+		// kafka should not read the file again
+		assert.Len(t, consumeAll(t, mustConsumer(t, c, "kafka")), 0)
+
+		// cloud continue from the last position
+		assert.Equal(t, [][]byte{[]byte("data-1"), []byte("data-2")}, consumeAll(t, mustConsumer(t, c, "cloud")))
+		assert.Len(t, c.dataFiles, 0)
+
+		// new data after reopen readable for both
+		require.NoError(t, c.Put([]byte("new")))
+		require.NoError(t, c.Rotate())
+		assert.Equal(t, [][]byte{[]byte("new")}, consumeAll(t, mustConsumer(t, c, "kafka")))
+		assert.Equal(t, [][]byte{[]byte("new")}, consumeAll(t, mustConsumer(t, c, "cloud")))
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`lag`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithConsumers("cloud", "kafka"))
+		require.NoError(t, err)
+
+		for i := 0; i < 4; i++ {
+			require.NoError(t, c.Put([]byte("0123456789")))
+		}
+		require.NoError(t, c.Rotate())
+
+		cloud := mustConsumer(t, c, "cloud")
+		kafka := mustConsumer(t, c, "kafka")
+
+		total := c.Size()
+		assert.Equal(t, total, kafka.Lag())
+
+		require.NoError(t, cloud.Get(nil))
+		require.NoError(t, cloud.Get(nil))
+		consumed := int64(2 * (dataHeaderLen + 10))
+		assert.Equal(t, total-consumed, cloud.Lag())
+
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(Metrics()...)
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+
+		assert.Equal(t, float64(total-consumed),
+			metrics.GetMetricOnLabels(mfs, "diskcache_consumer_lag", "cloud", c.path).GetGauge().GetValue(),
+			"got metrics\n%s", metrics.MetricFamily2Text(mfs))
+
+		consumeAll(t, cloud)
+		consumeAll(t, kafka)
+		assert.Equal(t, int64(0), cloud.Lag())
+		assert.Equal(t, int64(0), kafka.Lag())
+
+		assert.NoError(t, c.Close())
+	})
+}
+
+func mustConsumer(t *T.T, c *DiskCache, name string) *Consumer {
+	t.Helper()
+
+	cs, err := c.Consumer(name)
+	require.NoError(t, err)
+	return cs
+}
@@ -14,6 +14,13 @@
 //  5. Auto-rotate on batch size.
 //  6. Drop in FIFO policy when max capacity reached.
 //  7. We can configure various specifics in environments without to modify options source code.
+//  8. Optional per-record checksum, on corrupted record, only the broken record dropped.
+//  9. Optional per-record compression(zstd/lz4/snappy).
+//  10. Optional per-record AES-GCM encryption with key rotation.
+//  11. Multiple named consumers, each with it's own read position.
+//  12. Blocking GetContext() that wait for new data.
+//  13. Priority lanes and shards as sub-caches sharing the same capacity.
+//  14. Optional in-memory buffer that spill to disk only when it's full.
 package diskcache
 
 import (
@@ -49,6 +56,9 @@ var (
 	// Diskcache full, no data can be write now.
 	ErrCacheFull = errors.New("cache full")
 
+	// Diskcache closed during GetContext().
+	ErrCacheClosed = errors.New("cache closed")
+
 	ErrInvalidStreamSize = errors.New("invalid stream size")
 
 	// Invalid cache filename.
@@ -65,14 +75,37 @@ var (
 type DiskCache struct {
 	path string
 
-	dataFiles []string
+	dataFiles     []string
+	dataFileSizes map[string]int64
+
+	// current writing file.
+	curWriteFile string
+
+	// current write fd
+	wfd *os.File
+
+	// the default reader used by Get() on DiskCache.
+	*reader
+
+	// readers that data files removed only after all of them read through,
+	// if named consumers set, the default reader not included.
+	readers       []*reader
+	consumerNames []string
 
-	// current writing/reading file.
-	curWriteFile,
-	curReadfile string
+	// priority lanes(or shards), lanes[0] is the lowest priority.
+	laneCount  int
+	shardCount int
+	lanes      []*DiskCache
+	parent     *DiskCache // parent cache of the lane
+	lane       int        // priority of the lane
 
-	// current write/read fd
-	wfd, rfd *os.File
+	// round-robin Put()/Get() on shards
+	putSeq,
+	getSeq atomic.Uint64
+
+	// gauges reported by the shard, see setDatafilesMetric()
+	reportedDatafiles,
+	reportedArchiveSize int64
 
 	// If current write file go nothing put for a
 	// long time(wakeup), we rotate it manually.
@@ -81,23 +114,67 @@ type DiskCache struct {
 	// how long to wakeup a sleeping write-file
 	wakeup time.Duration
 
-	wlock, // write-lock: used to exclude concurrent Put to the header file.
-	rlock *sync.Mutex // read-lock: used to exclude concurrent Get on the tail file.
+	// when the write file opened, and how long it should be kept before
+	// rotated for GetContext() waiting
+	wfdOpened  time.Time
+	waitRotate time.Duration
+
+	// how long the records returned by Peek() can be kept uncommitted
+	leaseTimeout time.Duration
+
+	// keep consumed data files in archive
+	archive         bool
+	archiveCapacity int64
+	archiveFiles    []string
+	archiveSize     int64
+
+	// data files older than maxAge are dropped
+	maxAge      time.Duration
+	lastDropped string // the newest dropped data file
+	sweeperExit chan struct{}
+	sweeperDone sync.WaitGroup
+
+	// group-commit: concurrent Put() share a single fsync
+	groupCommit bool
+	gcMaxDelay  time.Duration
+	gcMaxBytes  int64
+	gcMu        sync.Mutex     // protect gcBatch
+	gcSyncMu    sync.Mutex     // serialize fsync of batches
+	gcInflight  sync.WaitGroup // fsync running without wlock
+	gcBatch     *syncBatch
+
+	// in-memory buffer in front of data files
+	memCapacity int64
+	memMu       sync.Mutex
+	memData     [][]byte
+	memSize     int64
+
+	// closed on new data available, for GetContext() waiting
+	dataMu sync.Mutex
+	dataCh chan struct{}
+	closed atomic.Bool
+
+	wlock  *sync.Mutex // write-lock: used to exclude concurrent Put to the header file.
 	rwlock *sync.Mutex // used to exclude switch/rotate/drop/Close on current disk cache instance.
 
 	flock *flock // disabled multi-Open on same path
-	pos   *pos   // current read fd position info
 
 	// specs of current diskcache
 	size          atomic.Int64 // current byte size
 	curBatchSize, // current writing file's size
-	curReadSize, // current reading file's size
 	batchSize, // current batch size(static)
 	capacity int64 // capacity of the diskcache
 	maxDataSize int32 // max data size of single Put()
 
 	batchHeader []byte
 
+	compress CompressAlgo // compression algorithm on Put()
+
+	encKey  *aeadKey            // key to encrypt Put() data
+	decKeys map[string]*aeadKey // keys to decrypt Get() data, indexed by key ID
+
+	optErr error // the first error on applying options, fail the Open()
+
 	// File permission, default 0750/0640
 	dirPerms,
 	filePerms os.FileMode
@@ -108,6 +185,7 @@ type DiskCache struct {
 	noPos, // no position
 	filoDrop, // first-in-last-out drop, meas we chooes to drop the new-coming data first
 	noDrop, // disable drop on cache full
+	checksum, // write record with CRC32C checksum
 	noLock bool // no file lock
 
 	// labels used to export prometheus flags
@@ -6,16 +6,33 @@
 package diskcache
 
 import (
+	"errors"
 	"os"
+	"time"
 )
 
 const (
-	reasonExceedCapacity     = "exceed-max-capacity"
-	reasonBadDataFile        = "bad-data-file"
-	reasonTooSmallReadBuffer = "too-small-read-buffer"
+	reasonExceedCapacity       = "exceed-max-capacity"
+	reasonBadDataFile          = "bad-data-file"
+	reasonTooSmallReadBuffer   = "too-small-read-buffer"
+	reasonBadChecksum          = "bad-checksum"
+	reasonBadCompressedData    = "bad-compressed-data"
+	reasonBadEncryptedData     = "bad-encrypted-data"
+	reasonUnknownEncryptionKey = "unknown-encryption-key"
+	reasonExpired              = "expired"
+
+	maxSweepInterval = time.Minute
 )
 
 func (c *DiskCache) dropBatch() error {
+	if c.isShard() {
+		return c.parent.dropLargestShard()
+	}
+
+	if c.parent != nil { // drop the lowest lane first
+		return c.parent.dropLowestLane()
+	}
+
 	c.rwlock.Lock()
 	defer c.rwlock.Unlock()
 
@@ -24,27 +41,151 @@ func (c *DiskCache) dropBatch() error {
 	}
 
 	// FILO drop: accept new data, and drop old data.
-	fname := c.dataFiles[0]
+	return c.dropOldestFile(reasonExceedCapacity)
+}
+
+// dropExpired drop data files that older than max-age.
+func (c *DiskCache) dropExpired() error {
+	c.rwlock.Lock()
+	defer c.rwlock.Unlock()
+
+	return c.dropExpiredFiles()
+}
 
-	if c.rfd != nil && c.curReadfile == fname {
-		if err := c.rfd.Close(); err != nil {
+// dropExpiredFiles drop data files that older than max-age. The age of data
+// file is based on it's modify time, i.e., time of the newest data within the file.
+// The caller should hold the rwlock.
+func (c *DiskCache) dropExpiredFiles() error {
+	if c.maxAge <= 0 {
+		return nil
+	}
+
+	if c.archive {
+		if err := c.trimArchive(); err != nil {
 			return err
 		}
+	}
 
-		c.rfd = nil
+	for len(c.dataFiles) > 0 {
+		fi, err := os.Stat(c.dataFiles[0])
+		if err != nil || time.Since(fi.ModTime()) < c.maxAge {
+			return nil
+		}
+
+		if err := c.dropOldestFile(reasonExpired); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// startSweeper drop expired data files in background.
+func (c *DiskCache) startSweeper() {
+	interval := c.maxAge / 2
+	if interval > maxSweepInterval {
+		interval = maxSweepInterval
+	}
+
+	c.sweeperExit = make(chan struct{})
+	c.sweeperDone.Add(1)
+
+	go func() {
+		defer c.sweeperDone.Done()
+
+		tick := time.NewTicker(interval)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-tick.C:
+				// error ignored, we'll retry on next tick or on Get().
+				_ = c.dropExpired()
+
+			case <-c.sweeperExit:
+				return
+			}
+		}
+	}()
+}
+
+// stopSweeper stop the background sweeper, it should not be called with rwlock held.
+func (c *DiskCache) stopSweeper() {
+	if c.sweeperExit != nil {
+		close(c.sweeperExit)
+		c.sweeperDone.Wait()
+		c.sweeperExit = nil
+	}
+}
+
+// dropOldestFile drop the first data file. The caller should hold the rwlock.
+func (c *DiskCache) dropOldestFile(reason string) error {
+	fname := c.dataFiles[0]
+
+	// readers on the dropped file are protected by their rlock, we can't
+	// close the file here. Mark them and they skip to next file(and remove
+	// the file) on their next read, see skipDroppedFile().
+	opened := false
+	for _, r := range c.readers {
+		if r.rfd != nil && r.curReadfile == fname {
+			r.dropped.Store(true)
+			opened = true
+		}
 	}
 
 	if fi, err := os.Stat(fname); err == nil {
-		if err := os.Remove(fname); err != nil {
-			return err
+		if !opened {
+			if err := os.Remove(fname); err != nil {
+				return err
+			}
 		}
 
+		c.lastDropped = fname
 		c.size.Add(-fi.Size())
 		c.dataFiles = c.dataFiles[1:]
+		delete(c.dataFileSizes, fname)
+		c.updateLag()
+
+		droppedDataVec.WithLabelValues(c.metricPath(), reason).Observe(float64(fi.Size()))
+		c.setDatafilesMetric()
+		sizeVec.WithLabelValues(c.metricPath()).Sub(float64(fi.Size()))
+	}
+
+	return nil
+}
+
+// skipDroppedFile close r's current read file if it has been dropped, and
+// remove the file if no other readers reading it. The caller should hold r.rlock.
+func (c *DiskCache) skipDroppedFile(r *reader) error {
+	if !r.dropped.Load() {
+		return nil
+	}
+
+	c.rwlock.Lock()
+	defer c.rwlock.Unlock()
+
+	fname := r.curReadfile
+	if r.rfd != nil {
+		if err := r.rfd.Close(); err != nil {
+			return err
+		}
+
+		r.rfd = nil
+	}
+
+	r.curReadfile = ""
+	r.lastReadfile = fname
+	r.leases = nil // the leased data dropped
+	r.dropped.Store(false)
+
+	for _, x := range c.readers {
+		if x.rfd != nil && x.curReadfile == fname {
+			return nil // removed by the last reader
+		}
+	}
 
-		droppedDataVec.WithLabelValues(c.path, reasonExceedCapacity).Observe(float64(fi.Size()))
-		datafilesVec.WithLabelValues(c.path).Set(float64(len(c.dataFiles)))
-		sizeVec.WithLabelValues(c.path).Sub(float64(fi.Size()))
+	if err := os.Remove(fname); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
 	}
 
 	return nil
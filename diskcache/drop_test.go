@@ -8,6 +8,8 @@ package diskcache
 import (
 	"bytes"
 	"errors"
+	"os"
+	"path/filepath"
 	"sync"
 	T "testing"
 	"time"
@@ -129,3 +131,170 @@ func TestDropDuringGet(t *T.T) {
 		ResetMetrics()
 	})
 }
+
+func TestMaxAge(t *T.T) {
+	t.Run(`drop-on-get`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithMaxAge(time.Hour))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put([]byte("old-data")))
+		require.NoError(t, c.Rotate())
+		require.NoError(t, c.Put([]byte("new-data")))
+		require.NoError(t, c.Rotate())
+		require.Len(t, c.dataFiles, 2)
+
+		// make the first file expired
+		old := time.Now().Add(-2 * time.Hour)
+		require.NoError(t, os.Chtimes(c.dataFiles[0], old, old))
+
+		assert.Equal(t, [][]byte{[]byte("new-data")}, getAll(t, c))
+
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(Metrics()...)
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+
+		m := metrics.GetMetricOnLabels(mfs, "diskcache_dropped_data", c.path, reasonExpired)
+		require.NotNil(t, m, "got metrics\n%s", metrics.MetricFamily2Text(mfs))
+		assert.Equal(t, uint64(1), m.GetSummary().GetSampleCount())
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`drop-on-open`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put([]byte("old-data")))
+		require.NoError(t, c.Rotate())
+		fname := c.dataFiles[0]
+		require.NoError(t, c.Close())
+
+		old := time.Now().Add(-2 * time.Hour)
+		require.NoError(t, os.Chtimes(fname, old, old))
+
+		c, err = Open(WithPath(p), WithMaxAge(time.Hour))
+		require.NoError(t, err)
+
+		assert.Len(t, c.dataFiles, 0)
+		assert.Equal(t, int64(0), c.Size())
+
+		_, err = os.Stat(fname)
+		assert.True(t, os.IsNotExist(err))
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`sweeper`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithMaxAge(100*time.Millisecond), WithConsumers("cloud"))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put([]byte("data")))
+		require.NoError(t, c.Rotate())
+
+		// peeked but not committed, the data still dropped on expire
+		_, tk, err := mustConsumer(t, c, "cloud").Peek(1, 0)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			c.rwlock.Lock()
+			defer c.rwlock.Unlock()
+			return len(c.dataFiles) == 0
+		}, time.Second, 10*time.Millisecond)
+
+		assert.ErrorIs(t, mustConsumer(t, c, "cloud").Commit(tk), ErrInvalidLease)
+		assert.Equal(t, int64(0), c.Size())
+
+		// the dropped file removed by the reader
+		fs, err := filepath.Glob(filepath.Join(p, "data.*"))
+		require.NoError(t, err)
+		assert.Len(t, fs, 0)
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`drop-reading-file`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithMaxAge(time.Hour))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put([]byte("a1")))
+		require.NoError(t, c.Put([]byte("a2")))
+		require.NoError(t, c.Rotate())
+		require.NoError(t, c.Put([]byte("b1")))
+		require.NoError(t, c.Rotate())
+
+		fname := c.dataFiles[0]
+		require.NoError(t, c.Get(func(data []byte) error {
+			assert.Equal(t, []byte("a1"), data)
+			return nil
+		}))
+
+		old := time.Now().Add(-2 * time.Hour)
+		require.NoError(t, os.Chtimes(fname, old, old))
+
+		// the reader skip the rest of dropped file
+		assert.Equal(t, [][]byte{[]byte("b1")}, getAll(t, c))
+
+		_, err = os.Stat(fname)
+		assert.True(t, os.IsNotExist(err))
+
+		// new file not overlapped with the dropped file
+		require.NoError(t, c.Put([]byte("c1")))
+		require.NoError(t, c.Rotate())
+		assert.Equal(t, [][]byte{[]byte("c1")}, getAll(t, c))
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`sweeper-during-get`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		for i := 0; i < 100; i++ {
+			require.NoError(t, c.Put([]byte("data-1")))
+			require.NoError(t, c.Put([]byte("data-2")))
+			require.NoError(t, c.Rotate())
+		}
+		require.NoError(t, c.Close())
+
+		c, err = Open(WithPath(p), WithMaxAge(100*time.Millisecond), WithConsumers("c1", "c2"))
+		require.NoError(t, err)
+
+		// readers read slowly, and the sweeper drop files under reading
+		var wg sync.WaitGroup
+		for _, name := range []string{"c1", "c2"} {
+			cs := mustConsumer(t, c, name)
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					if err := cs.Get(func([]byte) error {
+						time.Sleep(time.Millisecond)
+						return nil
+					}); errors.Is(err, ErrNoData) {
+						return
+					}
+				}
+			}()
+		}
+
+		wg.Wait()
+		assert.Equal(t, int64(0), c.Size())
+		assert.NoError(t, c.Close())
+
+		fs, err := filepath.Glob(filepath.Join(p, "data.*"))
+		require.NoError(t, err)
+		assert.Len(t, fs, 0)
+	})
+}
@@ -0,0 +1,157 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// Bit within extended record flags that mark the payload encrypted.
+const (
+	flagEncrypted = uint8(1 << 3)
+
+	maxKeyIDLen = 255
+
+	// key_id label of decryptErrorVec for key IDs not configured.
+	unknownKeyID = "unknown"
+)
+
+var (
+	// Data encrypted with an unknown key.
+	ErrBadEncryptionKey = errors.New("bad encryption key")
+
+	// Encrypted data broken(can't be authenticated with it's key).
+	ErrBadEncryptedData = errors.New("bad encrypted data")
+
+	hkdfInfo = []byte("diskcache AES-256-GCM")
+)
+
+// EncryptionKey is the key used to encrypt/decrypt cached data.
+//
+// The ID tagged within each encrypted data, so we can rotate to a new key
+// and still read data encrypted by old keys(see WithDecryptionKeys()).
+type EncryptionKey struct {
+	ID  string
+	Key []byte
+}
+
+type aeadKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// newAEADKey build AES-256-GCM cipher on k, the cipher key derived from
+// k.Key with HKDF-SHA256.
+func newAEADKey(k *EncryptionKey) (*aeadKey, error) {
+	if len(k.ID) > maxKeyIDLen {
+		return nil, fmt.Errorf("key ID too long(%d > %d)", len(k.ID), maxKeyIDLen)
+	}
+
+	if len(k.Key) == 0 {
+		return nil, fmt.Errorf("empty key of key ID %q", k.ID)
+	}
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, k.Key, nil, hkdfInfo), key); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &aeadKey{id: k.ID, aead: aead}, nil
+}
+
+// sealedLen get the length of sealed payload of n bytes data.
+func (k *aeadKey) sealedLen(n int) int {
+	return 1 + len(k.id) + k.aead.NonceSize() + n + k.aead.Overhead()
+}
+
+// seal encrypt data as | len(id)(1B) | id | nonce | ciphertext |,
+// ad(the record header) is authenticated but not encrypted.
+func (k *aeadKey) seal(data, ad []byte) ([]byte, error) {
+	hdrLen := 1 + len(k.id)
+	nonceSize := k.aead.NonceSize()
+
+	out := make([]byte, hdrLen+nonceSize, hdrLen+nonceSize+len(data)+k.aead.Overhead())
+	out[0] = byte(len(k.id))
+	copy(out[1:], k.id)
+
+	nonce := out[hdrLen : hdrLen+nonceSize]
+	if _, err := io.ReadFull(crand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return k.aead.Seal(out, nonce, data, ad), nil
+}
+
+// payloadKeyID get key ID of the encrypted payload.
+func payloadKeyID(payload []byte) (string, error) {
+	if len(payload) == 0 || len(payload) < 1+int(payload[0]) {
+		return "", fmt.Errorf("%w: invalid key ID", ErrBadEncryptedData)
+	}
+
+	return string(payload[1 : 1+int(payload[0])]), nil
+}
+
+// decrypt the payload in place, returns the plaintext. ad is the record
+// header that authenticated on seal().
+//
+// If the key ID of payload not configured, ErrBadEncryptionKey returned.
+// Other errors are ErrBadEncryptedData, the data is broken.
+func (c *DiskCache) decrypt(payload, ad []byte) ([]byte, error) {
+	id, err := payloadKeyID(payload)
+	if err != nil {
+		decryptErrorVec.WithLabelValues(c.metricPath(), unknownKeyID).Inc()
+		return nil, err
+	}
+
+	k, ok := c.decKeys[id]
+	if !ok {
+		// do not label with the key ID: it's read from disk and may be garbage.
+		decryptErrorVec.WithLabelValues(c.metricPath(), unknownKeyID).Inc()
+		return nil, fmt.Errorf("%w: key %q not found", ErrBadEncryptionKey, id)
+	}
+
+	ciphertext := payload[1+len(id):]
+	nonceSize := k.aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		decryptErrorVec.WithLabelValues(c.metricPath(), id).Inc()
+		return nil, fmt.Errorf("%w: invalid encrypted payload", ErrBadEncryptedData)
+	}
+
+	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
+	plaintext, err := k.aead.Open(ciphertext[:0], nonce, ciphertext, ad)
+	if err != nil {
+		decryptErrorVec.WithLabelValues(c.metricPath(), id).Inc()
+		return nil, fmt.Errorf("%w: key %q: %s", ErrBadEncryptedData, id, err.Error())
+	}
+
+	return plaintext, nil
+}
+
+func (c *DiskCache) addDecryptionKey(k *aeadKey) {
+	if c.decKeys == nil {
+		c.decKeys = map[string]*aeadKey{}
+	}
+
+	c.decKeys[k.id] = k
+}
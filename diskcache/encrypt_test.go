@@ -0,0 +1,204 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	T "testing"
+
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryption(t *T.T) {
+	k1 := &EncryptionKey{ID: "k1", Key: []byte("secret-1")}
+	k2 := &EncryptionKey{ID: "k2", Key: []byte("secret-2")}
+
+	t.Run(`basic`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithEncryption(k1))
+		require.NoError(t, err)
+
+		plain := []byte("sensitive-telemetry-data")
+		require.NoError(t, c.Put(plain))
+		require.NoError(t, c.StreamPut(bytes.NewReader(plain), len(plain)))
+		require.NoError(t, c.Rotate())
+
+		// no plaintext on disk
+		raw, err := os.ReadFile(c.dataFiles[0])
+		require.NoError(t, err)
+		assert.False(t, bytes.Contains(raw, plain))
+
+		assert.Equal(t, [][]byte{plain, plain}, getAll(t, c))
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`with-compression-and-checksum`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithEncryption(k1), WithCompression(CompressZstd), WithChecksum(true))
+		require.NoError(t, err)
+
+		plain := []byte(strings.Repeat("compressible-data,", 100))
+		require.NoError(t, c.Put(plain))
+		require.NoError(t, c.Rotate())
+
+		assert.Less(t, c.Size(), int64(len(plain))) // compressed before encryption
+
+		buf := make([]byte, len(plain))
+		assert.NoError(t, c.BufGet(buf, func(x []byte) error {
+			assert.Equal(t, plain, x)
+			return nil
+		}))
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`key-rotation`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithEncryption(k1))
+		require.NoError(t, err)
+		require.NoError(t, c.Put([]byte("by-k1")))
+		require.NoError(t, c.Close())
+
+		c, err = Open(WithPath(p), WithEncryption(k2), WithDecryptionKeys(k1))
+		require.NoError(t, err)
+		require.NoError(t, c.Put([]byte("by-k2")))
+		require.NoError(t, c.Rotate())
+
+		res := getAll(t, c)
+		require.Len(t, res, 2)
+		assert.Equal(t, "by-k1", string(res[0]))
+		assert.Equal(t, "by-k2", string(res[1]))
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`unknown-key`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithEncryption(k1))
+		require.NoError(t, err)
+		require.NoError(t, c.Put([]byte("by-k1")))
+		require.NoError(t, c.Rotate())
+		require.NoError(t, c.Close())
+
+		c, err = Open(WithPath(p), WithEncryption(k2))
+		require.NoError(t, err)
+		require.NoError(t, c.Put([]byte("by-k2")))
+		require.NoError(t, c.Rotate())
+
+		// data of unknown key dropped, and the reader not blocked on it
+		assert.Equal(t, [][]byte{[]byte("by-k2")}, getAll(t, c))
+
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(Metrics()...)
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+
+		assert.Equal(t, float64(1),
+			metrics.GetMetricOnLabels(mfs, "diskcache_decrypt_error_total", unknownKeyID, c.path).GetCounter().GetValue(),
+			"got metrics\n%s", metrics.MetricFamily2Text(mfs))
+
+		m := metrics.GetMetricOnLabels(mfs, "diskcache_dropped_data", c.path, reasonUnknownEncryptionKey)
+		require.NotNil(t, m, "got metrics\n%s", metrics.MetricFamily2Text(mfs))
+		assert.Equal(t, uint64(1), m.GetSummary().GetSampleCount())
+
+		require.NoError(t, c.Close())
+	})
+
+	t.Run(`tampered-header`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithEncryption(k1), WithCompression(CompressZstd))
+		require.NoError(t, err)
+		require.NoError(t, c.Put([]byte(strings.Repeat("compressible,", 100))))
+		require.NoError(t, c.Put([]byte("next")))
+		require.NoError(t, c.Rotate())
+
+		// clear the codec flag of the 1st record, the header is authenticated
+		// on decrypt, so the record dropped instead of returning garbage.
+		raw, err := os.ReadFile(c.dataFiles[0])
+		require.NoError(t, err)
+		raw[dataHeaderLen] &^= flagCodecMask
+		require.NoError(t, os.WriteFile(c.dataFiles[0], raw, 0o600))
+
+		assert.Equal(t, [][]byte{[]byte("next")}, getAll(t, c))
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`broken-data`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithEncryption(k1))
+		require.NoError(t, err)
+		require.NoError(t, c.Put([]byte("broken")))
+		require.NoError(t, c.Put([]byte("next")))
+		require.NoError(t, c.Rotate())
+
+		// break the ciphertext of the 1st record, without checksum, it's
+		// detected on decrypt and the record dropped.
+		raw, err := os.ReadFile(c.dataFiles[0])
+		require.NoError(t, err)
+		raw[dataHeaderLen+recordFlagsLen+1+len(k1.ID)+20] ^= 0xff
+		require.NoError(t, os.WriteFile(c.dataFiles[0], raw, 0o600))
+
+		assert.Equal(t, [][]byte{[]byte("next")}, getAll(t, c))
+
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(Metrics()...)
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+
+		assert.Equal(t, float64(1),
+			metrics.GetMetricOnLabels(mfs, "diskcache_decrypt_error_total", "k1", c.path).GetCounter().GetValue(),
+			"got metrics\n%s", metrics.MetricFamily2Text(mfs))
+
+		m := metrics.GetMetricOnLabels(mfs, "diskcache_dropped_data", c.path, reasonBadEncryptedData)
+		require.NotNil(t, m, "got metrics\n%s", metrics.MetricFamily2Text(mfs))
+		assert.Equal(t, uint64(1), m.GetSummary().GetSampleCount())
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`invalid-key`, func(t *T.T) {
+		p := t.TempDir()
+
+		_, err := Open(WithPath(p), WithEncryption(&EncryptionKey{ID: strings.Repeat("x", 256), Key: []byte("secret")}))
+		assert.Error(t, err)
+
+		_, err = Open(WithPath(p), WithDecryptionKeys(k1, &EncryptionKey{ID: "k3"}))
+		assert.Error(t, err)
+
+		// the path not locked on invalid options
+		c, err := Open(WithPath(p), WithEncryption(k1))
+		require.NoError(t, err)
+		assert.NotNil(t, c.encKey)
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`no-key`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithEncryption(k1))
+		require.NoError(t, err)
+		require.NoError(t, c.Put([]byte("by-k1")))
+		require.NoError(t, c.Rotate())
+		require.NoError(t, c.Close())
+
+		c, err = Open(WithPath(p))
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, c.Get(nil), ErrNoData)
+		assert.NoError(t, c.Close())
+	})
+}
@@ -8,6 +8,7 @@ package diskcache
 import (
 	"os"
 	"strconv"
+	"time"
 )
 
 func (c *DiskCache) syncEnv() {
@@ -33,6 +34,12 @@ func (c *DiskCache) syncEnv() {
 		c.noSync = true
 	}
 
+	if v, ok := os.LookupEnv("ENV_DISKCACHE_GROUP_COMMIT"); ok && v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			WithGroupCommit(d, 0)(c)
+		}
+	}
+
 	if v, ok := os.LookupEnv("ENV_DISKCACHE_NO_POS"); ok && v != "" {
 		c.noPos = true
 	}
@@ -44,4 +51,20 @@ func (c *DiskCache) syncEnv() {
 	if v, ok := os.LookupEnv("ENV_DISKCACHE_NO_FALLBACK_ON_ERROR"); ok && v != "" {
 		c.noFallbackOnError = true
 	}
+
+	if v, ok := os.LookupEnv("ENV_DISKCACHE_CHECKSUM"); ok && v != "" {
+		c.checksum = true
+	}
+
+	if v, ok := os.LookupEnv("ENV_DISKCACHE_MAX_AGE"); ok && v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			c.maxAge = d
+		}
+	}
+
+	if v, ok := os.LookupEnv("ENV_DISKCACHE_COMPRESSION"); ok && v != "" {
+		if algo, err := ParseCompressAlgo(v); err == nil {
+			c.compress = algo
+		}
+	}
 }
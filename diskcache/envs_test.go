@@ -8,6 +8,7 @@ package diskcache
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -46,6 +47,18 @@ func TestSyncEnv(t *testing.T) {
 			}(),
 		},
 
+		{
+			name: "env-max-age",
+			envs: map[string]string{
+				"ENV_DISKCACHE_MAX_AGE": "12h",
+			},
+			expect: func() *DiskCache {
+				c := defaultInstance()
+				c.maxAge = 12 * time.Hour
+				return c
+			}(),
+		},
+
 		{
 			name: "env-all",
 			envs: map[string]string{
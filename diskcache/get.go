@@ -7,7 +7,9 @@ package diskcache
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"time"
 )
@@ -15,162 +17,607 @@ import (
 // Fn is the handler to eat cache from diskcache.
 type Fn func([]byte) error
 
-func (c *DiskCache) switchNextFile() error {
-	if c.curReadfile != "" {
-		if err := c.removeCurrentReadingFile(); err != nil {
+func (c *DiskCache) switchNextFile(r *reader) error {
+	if r.curReadfile != "" {
+		if err := c.removeCurrentReadingFile(r); err != nil {
 			return fmt.Errorf("removeCurrentReadingFile: %w", err)
 		}
 	}
 
 	// reopen next file to read
-	return c.doSwitchNextFile()
+	return c.doSwitchNextFile(r)
 }
 
-func (c *DiskCache) skipBadFile() error {
+func (c *DiskCache) skipBadFile(r *reader) error {
 	defer func() {
-		droppedDataVec.WithLabelValues(c.path, reasonBadDataFile).Observe(float64(c.curReadSize))
+		droppedDataVec.WithLabelValues(c.metricPath(), reasonBadDataFile).Observe(float64(r.curReadSize))
 	}()
 
-	return c.switchNextFile()
+	return c.switchNextFile(r)
 }
 
 // Get fetch new data from disk cache, then passing to fn
 //
 // Get is safe to call concurrently with other operations and will
 // block until all other operations finish.
+//
+// If the cache opened with named consumers, Get() fail with ErrConsumerRequired,
+// use Consumer(name).Get() instead.
 func (c *DiskCache) Get(fn Fn) error {
-	return c.doGet(nil, fn, nil)
+	if len(c.lanes) > 0 {
+		return c.drainLanes(func(l *DiskCache) error { return l.Get(fn) })
+	}
+
+	r, err := c.defaultReader()
+	if err != nil {
+		return err
+	}
+
+	if c.memCapacity > 0 {
+		return c.memGet(r, func() error { return c.doGet(r, nil, fn, nil) }, 1, 0, singleFn(fn))
+	}
+
+	return c.doGet(r, nil, fn, nil)
+}
+
+// singleFn wrap fn as a BatchFn on batch of single record.
+func singleFn(fn Fn) BatchFn {
+	if fn == nil {
+		return nil
+	}
+
+	return func(batch [][]byte) error { return fn(batch[0]) }
 }
 
 type BufFunc func() []byte
 
+// BatchFn is the handler to eat a batch of cache from diskcache.
+type BatchFn func([][]byte) error
+
+// errBatchBreak means the next record not available for current batch.
+var errBatchBreak = errors.New("batch break")
+
 // BufCallbackGet fetch new data from disk cache, and read into buffer that returned by bfn.
 // If there is nothing to read, the bfn will not be called.
 func (c *DiskCache) BufCallbackGet(bfn BufFunc, fn Fn) error {
-	return c.doGet(nil, fn, bfn)
+	if len(c.lanes) > 0 {
+		return c.drainLanes(func(l *DiskCache) error { return l.BufCallbackGet(bfn, fn) })
+	}
+
+	r, err := c.defaultReader()
+	if err != nil {
+		return err
+	}
+
+	if c.memCapacity > 0 {
+		return c.memGet(r, func() error { return c.doGet(r, nil, fn, bfn) }, 1, 0, singleFn(fn))
+	}
+
+	return c.doGet(r, nil, fn, bfn)
 }
 
 // BufGet fetch new data from disk cache, and read into buf.
 func (c *DiskCache) BufGet(buf []byte, fn Fn) error {
-	return c.doGet(buf, fn, nil)
+	if len(c.lanes) > 0 {
+		return c.drainLanes(func(l *DiskCache) error { return l.BufGet(buf, fn) })
+	}
+
+	r, err := c.defaultReader()
+	if err != nil {
+		return err
+	}
+
+	if c.memCapacity > 0 {
+		return c.memGet(r, func() error { return c.doGet(r, buf, fn, nil) }, 1, 0, singleFn(fn))
+	}
+
+	return c.doGet(r, buf, fn, nil)
+}
+
+// GetBatch fetch at most maxRecords records(or about maxBytes bytes) from disk
+// cache within a single call, then passing them to fn. Zero or negative
+// maxRecords/maxBytes means no limit on it, but a batch never cross data files,
+// and at least one record returned if there is any data.
+//
+// The read position committed once for the whole batch. If fn failed, the
+// whole batch will be read again on next Get(unless WithNoFallbackOnError set).
+func (c *DiskCache) GetBatch(maxRecords, maxBytes int, fn BatchFn) error {
+	if len(c.lanes) > 0 { // the batch always within a single lane
+		return c.drainLanes(func(l *DiskCache) error { return l.GetBatch(maxRecords, maxBytes, fn) })
+	}
+
+	r, err := c.defaultReader()
+	if err != nil {
+		return err
+	}
+
+	if c.memCapacity > 0 {
+		return c.memGet(r, func() error { return c.doGetBatch(r, maxRecords, maxBytes, fn) }, maxRecords, maxBytes, fn)
+	}
+
+	return c.doGetBatch(r, maxRecords, maxBytes, fn)
+}
+
+func (c *DiskCache) doGetBatch(r *reader, maxRecords, maxBytes int, fn BatchFn) error {
+	r.rlock.Lock()
+	defer r.rlock.Unlock()
+
+	if err := c.checkPendingLeases(r); err != nil {
+		return err
+	}
+
+	limit, err := c.skipCommitted(r)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+
+	batch, total, err := c.readBatch(r, maxRecords, maxBytes, limit, false)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		getLatencyVec.WithLabelValues(c.metricPath()).Observe(time.Since(start).Seconds())
+	}()
+
+	if fn != nil {
+		if err = fn(batch); err != nil && !c.noFallbackOnError {
+			// seek back the whole batch
+			if _, serr := r.rfd.Seek(-int64(total), io.SeekCurrent); serr != nil {
+				return fmt.Errorf("r.rfd.Seek(%d) on FallbackOnError: %w", -int64(total), serr)
+			}
+
+			seekBackVec.WithLabelValues(c.metricPath()).Inc()
+			return err // do not update .pos
+		}
+	}
+
+	if derr := c.commitPos(r, total); derr != nil {
+		return derr
+	}
+
+	return err
 }
 
-func (c *DiskCache) doGet(buf []byte, fn Fn, bfn BufFunc) error {
+// readBatch read records of r for a batch, it returns records and the on-disk
+// bytes of them. If limit > 0, the batch stops within limit on-disk bytes. If
+// keepFile set, it will not switch to next file even if current file read to EOF.
+func (c *DiskCache) readBatch(r *reader, maxRecords, maxBytes, limit int, keepFile bool) ([][]byte, int, error) {
 	var (
-		n, nbytes int
-		err       error
+		data []byte
+		n    int
+		err  error
+	)
+
+	if keepFile {
+		if data, n, err = c.readRecord(r, nil, nil, true); errors.Is(err, errBatchBreak) {
+			err = ErrNoData
+		}
+	} else {
+		data, n, err = c.readNext(r, nil, nil)
+	}
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var (
+		batch  = [][]byte{data}
+		nbytes = len(data)
+		total  = n
 	)
 
-	c.rlock.Lock()
-	defer c.rlock.Unlock()
+	for maxRecords <= 0 || len(batch) < maxRecords {
+		if maxBytes > 0 && nbytes >= maxBytes {
+			break
+		}
+
+		if limit > 0 && total >= limit {
+			break
+		}
+
+		if data, n, err = c.readRecord(r, nil, nil, true); err != nil {
+			if errors.Is(err, errBatchBreak) {
+				break
+			}
+
+			return nil, 0, err
+		}
+
+		if maxBytes > 0 && nbytes+len(data) > maxBytes { // leave it to next batch
+			if _, err := r.rfd.Seek(-int64(n), io.SeekCurrent); err != nil {
+				return nil, 0, fmt.Errorf("r.rfd.Seek(%d): %w", -int64(n), err)
+			}
+			break
+		}
+
+		batch = append(batch, data)
+		nbytes += len(data)
+		total += n
+	}
+
+	return batch, total, nil
+}
+
+func (c *DiskCache) doGet(r *reader, buf []byte, fn Fn, bfn BufFunc) error {
+	r.rlock.Lock()
+	defer r.rlock.Unlock()
+
+	if err := c.checkPendingLeases(r); err != nil {
+		return err
+	}
+
+	if _, err := c.skipCommitted(r); err != nil {
+		return err
+	}
 
 	start := time.Now()
 
+	data, n, err := c.readNext(r, buf, bfn)
+	if err != nil {
+		return err
+	}
+
 	defer func() {
-		if uint32(nbytes) != EOFHint {
-			// get on EOF not counted as a real Get
-			getLatencyVec.WithLabelValues(c.path).Observe(time.Since(start).Seconds())
-		}
+		getLatencyVec.WithLabelValues(c.metricPath()).Observe(time.Since(start).Seconds())
 	}()
 
+	if fn != nil {
+		if err = fn(data); err != nil && !c.noFallbackOnError {
+			// seek back
+			if _, serr := r.rfd.Seek(-int64(n), io.SeekCurrent); serr != nil {
+				return fmt.Errorf("r.rfd.Seek(%d) on FallbackOnError: %w", -int64(n), serr)
+			}
+
+			seekBackVec.WithLabelValues(c.metricPath()).Inc()
+			return err // do not update .pos
+		}
+	}
+
+	if derr := c.commitPos(r, n); derr != nil {
+		return derr
+	}
+
+	return err
+}
+
+// readNext rotate the sleeping write file and open the first file to read
+// if needed, then read next record of r.
+func (c *DiskCache) readNext(r *reader, buf []byte, bfn BufFunc) ([]byte, int, error) {
 	// wakeup sleeping write file, rotate it for succession reading!
-	if time.Since(c.wfdLastWrite) > c.wakeup && c.curBatchSize > 0 {
-		wakeupVec.WithLabelValues(c.path).Inc()
+	if err := func() error {
+		c.wlock.Lock()
+		defer c.wlock.Unlock()
 
-		if err = func() error {
-			c.wlock.Lock()
-			defer c.wlock.Unlock()
+		if time.Since(c.wfdLastWrite) > c.wakeup && c.curBatchSize > 0 {
+			wakeupVec.WithLabelValues(c.metricPath()).Inc()
 			return c.rotate()
-		}(); err != nil {
-			return err
 		}
+		return nil
+	}(); err != nil {
+		return nil, 0, err
+	}
+
+	if c.maxAge > 0 {
+		if err := c.dropExpired(); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if err := c.skipDroppedFile(r); err != nil {
+		return nil, 0, err
 	}
 
-	if c.rfd == nil { // no file reading, reading on the first file
-		if err = c.switchNextFile(); err != nil {
+	if r.rfd == nil { // no file reading, reading on the first file
+		if err := c.switchNextFile(r); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return c.readRecord(r, buf, bfn, false)
+}
+
+// commitPos move r's .pos forward n bytes.
+func (c *DiskCache) commitPos(r *reader, n int) error {
+	if !c.noPos && n > 0 {
+		r.pos.Seek += int64(n)
+		if err := r.pos.dumpFile(); err != nil {
 			return err
 		}
+
+		posUpdatedVec.WithLabelValues("get", c.metricPath()).Inc()
+	}
+
+	if r.name != "" {
+		c.readerLag(r)
+	}
+
+	return nil
+}
+
+// readRecord read next record of r into readbuf(buf, or returned by bfn, or
+// allocated if both nil), it returns the data and the on-disk bytes of the record.
+//
+// Within a batch(inBatch set), readRecord never switch to next file or skip
+// broken data, it seek back to the beginning of the record and returns
+// errBatchBreak, so the whole batch can be seeked back within current file.
+func (c *DiskCache) readRecord(r *reader, buf []byte, bfn BufFunc, inBatch bool) ([]byte, int, error) {
+	var (
+		n, nbytes, size,
+		hdrLen, tailLen int
+		hdr     uint32
+		flags   uint8
+		codec   CompressAlgo
+		payload []byte // decoded payload of compressed/encrypted record
+
+		encrypted bool
+		recStart  int64
+		err       error
+	)
+
+	// breakBatch seek back to beginning of current record.
+	breakBatch := func() ([]byte, int, error) {
+		if _, err := r.rfd.Seek(recStart, io.SeekStart); err != nil {
+			return nil, 0, fmt.Errorf("r.rfd.Seek(%d) on batch break: %w", recStart, err)
+		}
+
+		return nil, 0, errBatchBreak
 	}
 
 retry:
-	if c.rfd == nil {
-		return ErrNoData
+	if r.rfd == nil {
+		return nil, 0, ErrNoData
 	}
 
-	if n, err = c.rfd.Read(c.batchHeader); err != nil || n != dataHeaderLen {
+	if recStart, err = r.rfd.Seek(0, io.SeekCurrent); err != nil {
+		return nil, 0, fmt.Errorf("rfd.Seek: %w", err)
+	}
+
+	if n, err = r.rfd.Read(r.recordHeader[:dataHeaderLen]); err != nil || n != dataHeaderLen {
+		if inBatch {
+			return breakBatch()
+		}
+
 		// On bad datafile, just ignore and delete the file.
-		if err = c.skipBadFile(); err != nil {
-			return err
+		if err = c.skipBadFile(r); err != nil {
+			return nil, 0, err
 		}
 
 		goto retry // read next new file to save another Get() calling.
 	}
 
 	// how many bytes of current data?
-	nbytes = int(binary.LittleEndian.Uint32(c.batchHeader))
+	hdr = binary.LittleEndian.Uint32(r.recordHeader)
+	nbytes = int(hdr)
 
 	if uint32(nbytes) == EOFHint { // EOF
-		if err := c.switchNextFile(); err != nil {
-			return fmt.Errorf("switchNextFile: %w", err)
+		if inBatch {
+			return breakBatch()
+		}
+
+		if err := c.switchNextFile(r); err != nil {
+			return nil, 0, fmt.Errorf("switchNextFile: %w", err)
 		}
 
 		goto retry // read next new file to save another Get() calling.
 	}
 
+	hdrLen, tailLen, flags, payload = dataHeaderLen, 0, 0, nil
+
+	if hdr&extRecordFlag != 0 { // extended record
+		nbytes = int(hdr &^ extRecordFlag)
+		hdrLen += recordFlagsLen
+		tailLen = recordCRCLen
+
+		if n, err = r.rfd.Read(r.recordHeader[dataHeaderLen:hdrLen]); err != nil || n != recordFlagsLen {
+			if inBatch {
+				return breakBatch()
+			}
+
+			if err = c.resync(r, recStart); err != nil {
+				return nil, 0, err
+			}
+
+			goto retry
+		}
+
+		flags = r.recordHeader[dataHeaderLen]
+
+		if recStart+int64(hdrLen+nbytes+tailLen) > r.curReadSize { // broken len
+			if inBatch {
+				return breakBatch()
+			}
+
+			if err = c.resync(r, recStart); err != nil {
+				return nil, 0, err
+			}
+
+			goto retry
+		}
+	}
+
+	size, codec, encrypted = nbytes, codecOf(flags), flags&flagEncrypted != 0
+
+	if codec != NoCompress || encrypted {
+		// read encoded payload into internal buffer, we'll decode it into readbuf.
+		if cap(r.encodedBuf) < nbytes {
+			r.encodedBuf = make([]byte, nbytes)
+		}
+
+		if _, err = io.ReadFull(r.rfd, r.encodedBuf[:nbytes]); err == nil {
+			_, err = io.ReadFull(r.rfd, r.recordHeader[hdrLen:hdrLen+tailLen])
+		}
+
+		if err != nil || !r.checkRecord(hdrLen, flags, r.encodedBuf[:nbytes]) {
+			if inBatch {
+				return breakBatch()
+			}
+
+			if err = c.resync(r, recStart); err != nil {
+				return nil, 0, err
+			}
+
+			goto retry
+		}
+
+		payload = r.encodedBuf[:nbytes]
+
+		if encrypted {
+			if payload, err = c.decrypt(payload, r.recordHeader[:hdrLen]); err != nil {
+				if inBatch {
+					return breakBatch()
+				}
+
+				// broken data or the key not found, drop it, or the reader blocked on it forever.
+				reason := reasonBadEncryptedData
+				if errors.Is(err, ErrBadEncryptionKey) {
+					reason = reasonUnknownEncryptionKey
+				}
+
+				if err = c.skipBadRecord(r, reason, nbytes, hdrLen+nbytes+tailLen); err != nil {
+					return nil, 0, err
+				}
+
+				goto retry
+			}
+		}
+
+		size = len(payload)
+		if codec != NoCompress {
+			// the raw length is untrusted without checksum, check it before allocating readbuf.
+			if size, err = rawLen(payload); err == nil && size > c.maxRecordDataSize() {
+				err = ErrBadCompressedData
+			}
+
+			if err != nil {
+				if inBatch {
+					return breakBatch()
+				}
+
+				if err = c.skipBadRecord(r, reasonBadCompressedData, nbytes, hdrLen+nbytes+tailLen); err != nil {
+					return nil, 0, err
+				}
+
+				goto retry
+			}
+		}
+	}
+
 	var readbuf []byte
 
 	switch {
 	case buf == nil && bfn == nil: // malloc memory locally
-		readbuf = make([]byte, nbytes)
+		readbuf = make([]byte, size)
 	case buf == nil && bfn != nil:
 		readbuf = bfn()
 	default:
 		readbuf = buf
 	}
 
-	if len(readbuf) < nbytes {
+	if len(readbuf) < size {
+		if inBatch {
+			return breakBatch()
+		}
+
 		// seek to next read position
-		if _, err := c.rfd.Seek(int64(nbytes), io.SeekCurrent); err != nil {
-			return fmt.Errorf("rfd.Seek(%d): %w", nbytes, err)
+		if payload == nil {
+			if _, err := r.rfd.Seek(int64(nbytes+tailLen), io.SeekCurrent); err != nil {
+				return nil, 0, fmt.Errorf("rfd.Seek(%d): %w", nbytes+tailLen, err)
+			}
 		}
 
-		droppedDataVec.WithLabelValues(c.path, reasonTooSmallReadBuffer).Observe(float64(nbytes))
-		return ErrTooSmallReadBuf
+		droppedDataVec.WithLabelValues(c.metricPath(), reasonTooSmallReadBuffer).Observe(float64(size))
+		return nil, 0, ErrTooSmallReadBuf
 	}
 
-	if n, err := c.rfd.Read(readbuf[:nbytes]); err != nil {
-		return fmt.Errorf("rfd.Read(%d buf): %w", len(readbuf[:nbytes]), err)
-	} else if n != nbytes {
-		return ErrUnexpectedReadSize
-	}
+	switch {
+	case codec != NoCompress:
+		if err = codec.decompress(payload, readbuf); err != nil {
+			if inBatch {
+				return breakBatch()
+			}
 
-	if fn == nil {
-		goto __updatePos
-	}
+			if err = c.skipBadRecord(r, reasonBadCompressedData, nbytes, hdrLen+nbytes+tailLen); err != nil {
+				return nil, 0, err
+			}
 
-	if err = fn(readbuf[:nbytes]); err != nil {
-		// seek back
-		if !c.noFallbackOnError {
-			if _, serr := c.rfd.Seek(-int64(dataHeaderLen+nbytes), io.SeekCurrent); serr != nil {
-				return fmt.Errorf("c.rfd.Seek(%d) on FallbackOnError: %w", -int64(dataHeaderLen+nbytes), serr)
+			goto retry
+		}
+
+	case encrypted:
+		copy(readbuf, payload)
+
+	case tailLen > 0: // check extended record
+		if _, err = io.ReadFull(r.rfd, readbuf[:nbytes]); err == nil {
+			_, err = io.ReadFull(r.rfd, r.recordHeader[hdrLen:hdrLen+tailLen])
+		}
+
+		if err != nil || !r.checkRecord(hdrLen, flags, readbuf[:nbytes]) {
+			if inBatch {
+				return breakBatch()
+			}
+
+			if err = c.resync(r, recStart); err != nil {
+				return nil, 0, err
+			}
+
+			goto retry
+		}
+
+	default:
+		if n, err := r.rfd.Read(readbuf[:nbytes]); err != nil {
+			if inBatch {
+				return breakBatch()
+			}
+
+			return nil, 0, fmt.Errorf("rfd.Read(%d buf): %w", len(readbuf[:nbytes]), err)
+		} else if n != nbytes {
+			if inBatch {
+				return breakBatch()
 			}
 
-			seekBackVec.WithLabelValues(c.path).Inc()
-			goto __end // do not update .pos
+			return nil, 0, ErrUnexpectedReadSize
 		}
 	}
 
-__updatePos:
-	// update seek position
-	if !c.noPos && nbytes > 0 {
-		c.pos.Seek += int64(dataHeaderLen + nbytes)
-		if derr := c.pos.dumpFile(); derr != nil {
-			return derr
+	return readbuf[:size], hdrLen + nbytes + tailLen, nil
+}
+
+// maxRecordDataSize get the max raw data size of a single record.
+func (c *DiskCache) maxRecordDataSize() int {
+	if c.maxDataSize > 0 {
+		return int(c.maxDataSize)
+	}
+
+	return maxExtRecordSize
+}
+
+// skipBadRecord drop the record that already read(n on-disk bytes with
+// nbytes payload). Without checksum, the broken data can't be detected until
+// decompress or decrypt, so we skip it here.
+func (c *DiskCache) skipBadRecord(r *reader, reason string, nbytes, n int) error {
+	droppedDataVec.WithLabelValues(c.metricPath(), reason).Observe(float64(nbytes))
+
+	if !c.noPos {
+		r.pos.Seek += int64(n)
+		if err := r.pos.dumpFile(); err != nil {
+			return err
 		}
+	}
 
-		posUpdatedVec.WithLabelValues("get", c.path).Inc()
+	return nil
+}
+
+// checkRecord verify checksum of the extended record that header
+// and crc already read into r.recordHeader.
+func (r *reader) checkRecord(hdrLen int, flags uint8, payload []byte) bool {
+	if flags&flagChecksum == 0 {
+		return true
 	}
 
-__end:
-	return err
+	crc := crc32.Update(0, castagnoli, r.recordHeader[:hdrLen])
+	crc = crc32.Update(crc, castagnoli, payload)
+	return crc == binary.LittleEndian.Uint32(r.recordHeader[hdrLen:])
 }
@@ -6,7 +6,9 @@
 package diskcache
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"os"
 	T "testing"
 	"time"
@@ -518,3 +520,308 @@ func TestPutGet(t *T.T) {
 		})
 	})
 }
+
+func TestGetBatch(t *T.T) {
+	put := func(t *T.T, c *DiskCache, n int) (expect [][]byte) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			data := []byte(fmt.Sprintf("data-%02d", i))
+			require.NoError(t, c.Put(data))
+			expect = append(expect, data)
+		}
+		return
+	}
+
+	t.Run(`max-records`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		expect := put(t, c, 10)
+		require.NoError(t, c.Rotate())
+
+		var got [][]byte
+		for i := 0; i < 3; i++ {
+			require.NoError(t, c.GetBatch(4, 0, func(batch [][]byte) error {
+				assert.LessOrEqual(t, len(batch), 4)
+				got = append(got, batch...)
+				return nil
+			}))
+		}
+
+		assert.Equal(t, expect, got)
+		assert.ErrorIs(t, c.GetBatch(4, 0, nil), ErrNoData)
+
+		// pos updated once for each batch
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(Metrics()...)
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+
+		assert.Equal(t, float64(3),
+			metrics.GetMetricOnLabels(mfs, "diskcache_pos_updated_total", "get", c.path).GetCounter().GetValue(),
+			"got metrics\n%s", metrics.MetricFamily2Text(mfs))
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`max-bytes`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		put(t, c, 10)
+		require.NoError(t, c.Rotate())
+
+		require.NoError(t, c.GetBatch(0, 20, func(batch [][]byte) error {
+			assert.Len(t, batch, 2) // 7 bytes each
+			return nil
+		}))
+
+		// at least one record within the batch
+		require.NoError(t, c.GetBatch(0, 1, func(batch [][]byte) error {
+			assert.Equal(t, [][]byte{[]byte("data-02")}, batch)
+			return nil
+		}))
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`not-cross-files`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		expect := put(t, c, 3)
+		require.NoError(t, c.Rotate())
+		expect = append(expect, put(t, c, 3)...)
+		require.NoError(t, c.Rotate())
+
+		var got [][]byte
+		for i := 0; i < 2; i++ {
+			require.NoError(t, c.GetBatch(0, 0, func(batch [][]byte) error {
+				assert.Len(t, batch, 3)
+				got = append(got, batch...)
+				return nil
+			}))
+		}
+
+		assert.Equal(t, expect, got)
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`fallback-on-error`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithChecksum(true))
+		require.NoError(t, err)
+
+		expect := put(t, c, 5)
+		require.NoError(t, c.Rotate())
+
+		assert.Error(t, c.GetBatch(3, 0, func(batch [][]byte) error {
+			return errors.New("send failed")
+		}))
+
+		// the whole batch read again
+		require.NoError(t, c.GetBatch(3, 0, func(batch [][]byte) error {
+			assert.Equal(t, expect[:3], batch)
+			return nil
+		}))
+
+		assert.Equal(t, expect[3:], getAll(t, c))
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`fallback-after-reopen`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		expect := put(t, c, 5)
+		require.NoError(t, c.Rotate())
+
+		require.NoError(t, c.GetBatch(2, 0, nil))
+		assert.Error(t, c.GetBatch(2, 0, func(batch [][]byte) error {
+			return errors.New("send failed")
+		}))
+		require.NoError(t, c.Close())
+
+		// .pos committed on the first batch only
+		c, err = Open(WithPath(p))
+		require.NoError(t, err)
+		assert.Equal(t, expect[2:], getAll(t, c))
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`stop-before-broken-record`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithChecksum(true))
+		require.NoError(t, err)
+
+		expect := put(t, c, 3)
+		require.NoError(t, c.Rotate())
+
+		// break the 2nd record
+		raw, err := os.ReadFile(c.dataFiles[0])
+		require.NoError(t, err)
+		recLen := dataHeaderLen + recordFlagsLen + len(expect[0]) + recordCRCLen
+		raw[recLen+dataHeaderLen+recordFlagsLen] ^= 0xff
+		require.NoError(t, os.WriteFile(c.dataFiles[0], raw, 0o600))
+
+		require.NoError(t, c.GetBatch(0, 0, func(batch [][]byte) error {
+			assert.Equal(t, expect[:1], batch)
+			return nil
+		}))
+
+		// the broken record skipped on next batch
+		require.NoError(t, c.GetBatch(0, 0, func(batch [][]byte) error {
+			assert.Equal(t, expect[2:], batch)
+			return nil
+		}))
+
+		assert.NoError(t, c.Close())
+	})
+}
+
+func TestGetContext(t *T.T) {
+	t.Run(`wait-put`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		got := make(chan []byte)
+		go func() {
+			assert.NoError(t, c.GetContext(context.Background(), func(x []byte) error {
+				got <- x
+				return nil
+			}))
+		}()
+
+		time.Sleep(100 * time.Millisecond) // wait GetContext() blocked
+		start := time.Now()
+		require.NoError(t, c.Put([]byte("hello")))
+
+		select {
+		case x := <-got:
+			assert.Equal(t, []byte("hello"), x)
+			assert.Less(t, time.Since(start), c.wakeup) // no wait on wakeup
+		case <-time.After(10 * time.Second):
+			assert.Fail(t, "GetContext() not returned on Put()")
+		}
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`ctx-done`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		assert.ErrorIs(t, c.GetContext(ctx, nil), context.DeadlineExceeded)
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`close`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		done := make(chan error)
+		go func() { done <- c.GetContext(context.Background(), nil) }()
+
+		time.Sleep(100 * time.Millisecond)
+		assert.NoError(t, c.Close())
+
+		select {
+		case err := <-done:
+			assert.ErrorIs(t, err, ErrCacheClosed)
+		case <-time.After(10 * time.Second):
+			assert.Fail(t, "GetContext() not returned on Close()")
+		}
+	})
+
+	t.Run(`no-tiny-files-on-slow-put`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithWaitRotate(50*time.Millisecond))
+		require.NoError(t, err)
+
+		const n = 20
+		done := make(chan int)
+		go func() {
+			got := 0
+			for got < n {
+				assert.NoError(t, c.GetContext(context.Background(), nil))
+				got++
+			}
+			done <- got
+		}()
+
+		for i := 0; i < n; i++ {
+			require.NoError(t, c.Put([]byte("hello")))
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		select {
+		case got := <-done:
+			assert.Equal(t, n, got)
+		case <-time.After(10 * time.Second):
+			assert.Fail(t, "GetContext() not returned on Put()")
+		}
+
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(Metrics()...)
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+
+		// not rotated on each Put()
+		m := metrics.GetMetricOnLabels(mfs, "diskcache_rotate_total", c.path)
+		require.NotNil(t, m, "got metrics\n%s", metrics.MetricFamily2Text(mfs))
+		assert.Less(t, m.GetCounter().GetValue(), float64(n/2))
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`consumers-and-lanes`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithConsumers("cloud"))
+		require.NoError(t, err)
+
+		done := make(chan error)
+		go func() { done <- mustConsumer(t, c, "cloud").GetContext(context.Background(), nil) }()
+
+		time.Sleep(100 * time.Millisecond)
+		require.NoError(t, c.Put([]byte("hello")))
+		assert.NoError(t, <-done)
+		assert.NoError(t, c.Close())
+
+		p = t.TempDir()
+		c, err = Open(WithPath(p), WithPriorityLanes(2))
+		require.NoError(t, err)
+
+		go func() {
+			done <- c.GetContext(context.Background(), func(x []byte) error {
+				assert.Equal(t, []byte("critical"), x)
+				return nil
+			})
+		}()
+
+		time.Sleep(100 * time.Millisecond)
+		require.NoError(t, c.PutPriority(1, []byte("critical")))
+		assert.NoError(t, <-done)
+		assert.NoError(t, c.Close())
+	})
+}
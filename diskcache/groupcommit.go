@@ -0,0 +1,130 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"os"
+	"time"
+)
+
+const defaultGroupCommitBytes = 1 << 20
+
+// syncBatch is a group of Put() that share a single fsync.
+type syncBatch struct {
+	done chan struct{} // closed after the fsync
+	full chan struct{} // closed if max bytes reached
+	err  error         // fsync error
+
+	puts  int
+	bytes int64
+}
+
+// joinSyncBatch add n bytes just written to the pending sync batch, the
+// caller that create the batch is the leader, it's responsible for the fsync.
+// The caller should hold the wlock.
+func (c *DiskCache) joinSyncBatch(n int64) (b *syncBatch, leader bool) {
+	c.gcMu.Lock()
+	defer c.gcMu.Unlock()
+
+	if c.gcBatch == nil {
+		c.gcBatch = &syncBatch{
+			done: make(chan struct{}),
+			full: make(chan struct{}),
+		}
+		leader = true
+	}
+
+	b = c.gcBatch
+	b.puts++
+	b.bytes += n
+
+	if b.bytes >= c.gcMaxBytes && b.bytes-n < c.gcMaxBytes {
+		close(b.full)
+	}
+
+	return b, leader
+}
+
+// waitSyncBatch wait until data within b are durable. The leader wait for
+// max delay(or max bytes) and the previous fsync to collect more Put(), then
+// fsync for all of them. Put() during the fsync go to the next batch.
+func (c *DiskCache) waitSyncBatch(b *syncBatch, leader bool) error {
+	if leader {
+		if c.gcMaxDelay > 0 {
+			timer := time.NewTimer(c.gcMaxDelay)
+			select {
+			case <-timer.C:
+			case <-b.full:
+			case <-b.done: // flushed on rotate
+			}
+			timer.Stop()
+		}
+
+		c.gcSyncMu.Lock()
+		c.syncBatchAsync(b)
+		c.gcSyncMu.Unlock()
+	}
+
+	<-b.done
+	return b.err
+}
+
+// syncBatchAsync detach batch b and fsync without the wlock, so following
+// Put() not blocked by the fsync.
+func (c *DiskCache) syncBatchAsync(b *syncBatch) {
+	fd := func() *os.File {
+		c.wlock.Lock()
+		defer c.wlock.Unlock()
+		c.rwlock.Lock()
+		defer c.rwlock.Unlock()
+
+		c.gcMu.Lock()
+		defer c.gcMu.Unlock()
+
+		if c.gcBatch != b { // already flushed
+			return nil
+		}
+
+		c.gcBatch = nil
+		c.gcInflight.Add(1) // the fd should not closed during fsync
+		return c.wfd
+	}()
+
+	if fd == nil {
+		return
+	}
+
+	b.err = fd.Sync()
+	c.gcInflight.Done()
+	c.doneSyncBatch(b)
+}
+
+// flushSyncBatch fsync the write file for any pending batch, it's called
+// before write file closed. The caller should hold the rwlock.
+func (c *DiskCache) flushSyncBatch() error {
+	c.gcInflight.Wait()
+
+	c.gcMu.Lock()
+	defer c.gcMu.Unlock()
+
+	b := c.gcBatch
+	if b == nil {
+		return nil
+	}
+
+	c.gcBatch = nil
+	if c.wfd != nil {
+		b.err = c.wfd.Sync()
+	}
+
+	c.doneSyncBatch(b)
+	return b.err
+}
+
+func (c *DiskCache) doneSyncBatch(b *syncBatch) {
+	groupCommitVec.WithLabelValues(c.metricPath()).Observe(float64(b.puts))
+	close(b.done)
+}
@@ -0,0 +1,464 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Problems found by Inspect().
+var (
+	ErrBadRecordHeader = errors.New("bad record header")
+	ErrBadChecksum     = errors.New("bad record checksum")
+	ErrTruncatedRecord = errors.New("truncated record")
+	ErrDataAfterEOF    = errors.New("data after EOF")
+)
+
+// ErrCacheInUse returned by Repair() on cache locked by an alive process.
+var ErrCacheInUse = errors.New("cache in use")
+
+// Record is a record within data file.
+type Record struct {
+	Offset int64 // offset of the record within the data file
+	Size   int   // on-disk bytes of the record
+
+	Checksum  bool
+	Compress  CompressAlgo
+	Encrypted bool
+
+	Payload []byte // on-disk payload(may be compressed or encrypted)
+
+	header []byte // on-disk header of extended record, authenticated on encryption
+}
+
+// DataFileInfo is the inspection result of a data file.
+type DataFileInfo struct {
+	Path    string
+	Size    int64
+	Records int
+	EOF     bool // EOF mark found
+
+	// Size of leading valid records(and EOF mark), bytes after that are broken.
+	ValidSize int64
+
+	// Offsets of all valid records.
+	Offsets []int64
+
+	// The first problem found within the file, nil if file is ok.
+	Err error
+}
+
+// PosInfo is the read position of the default reader or a named consumer.
+type PosInfo struct {
+	File     string // the .pos file
+	Consumer string // empty for the default reader
+
+	Name string // the data file reading
+	Seek int64  // offset within the data file, -1 means the file read to EOF
+}
+
+// CacheInfo is the inspection result of a cache directory.
+type CacheInfo struct {
+	Path string
+
+	// Rotated data files and current writing file(the last one, if exist).
+	DataFiles []*DataFileInfo
+	Pos       []*PosInfo
+
+	LockPID   int // -1 or 0 if not locked
+	LockAlive bool
+}
+
+// Inspect list data files, records and positions within cache directory path.
+//
+// Inspect(and Repair()) work offline on the directory, the cache should not
+// opened by any process, or work on a copy of the cache directory.
+func Inspect(path string) (*CacheInfo, error) {
+	ci := &CacheInfo{Path: path}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	hasWriteFile := false
+
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		switch name := e.Name(); {
+		case name == ".lock":
+			if x, err := os.ReadFile(filepath.Join(path, name)); err == nil && len(x) > 0 {
+				if pid, err := strconv.Atoi(string(x)); err == nil {
+					ci.LockPID = pid
+					ci.LockAlive = pid > 0 && pidAlive(pid)
+				}
+			}
+
+		case name == ".pos" || strings.HasPrefix(name, ".pos."):
+			pi, err := loadPosInfo(filepath.Join(path, name))
+			if err != nil {
+				return nil, err
+			}
+
+			if pi != nil {
+				ci.Pos = append(ci.Pos, pi)
+			}
+
+		case name == "data":
+			hasWriteFile = true
+
+		case strings.HasPrefix(name, "data."):
+			files = append(files, filepath.Join(path, name))
+		}
+	}
+
+	sort.Strings(files)
+	if hasWriteFile {
+		files = append(files, filepath.Join(path, "data"))
+	}
+
+	for _, f := range files {
+		fi, err := ScanDataFile(f, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		ci.DataFiles = append(ci.DataFiles, fi)
+	}
+
+	return ci, nil
+}
+
+// ScanDataFile scan all records within data file fname, and fn called on each
+// valid record. The scan stopped on the first broken record, or fn failed.
+func ScanDataFile(fname string, fn func(*Record) error) (*DataFileInfo, error) {
+	b, err := os.ReadFile(filepath.Clean(fname))
+	if err != nil {
+		return nil, err
+	}
+
+	fi := &DataFileInfo{Path: fname, Size: int64(len(b))}
+
+	off := 0
+	for off < len(b) {
+		rec, err := recordAt(b[off:])
+		if err != nil {
+			fi.Err = fmt.Errorf("%w at offset %d", err, off)
+			break
+		}
+
+		if rec == nil { // EOF mark
+			fi.EOF = true
+			off += dataHeaderLen
+
+			if off != len(b) {
+				fi.Err = fmt.Errorf("%w at offset %d", ErrDataAfterEOF, off)
+			}
+			break
+		}
+
+		rec.Offset = int64(off)
+		fi.Records++
+		fi.Offsets = append(fi.Offsets, rec.Offset)
+
+		if fn != nil {
+			if err := fn(rec); err != nil {
+				return nil, err
+			}
+		}
+
+		off += rec.Size
+	}
+
+	fi.ValidSize = int64(off)
+	return fi, nil
+}
+
+// recordAt parse the record at the beginning of b, returns nil record on EOF mark.
+func recordAt(b []byte) (*Record, error) {
+	if len(b) < dataHeaderLen {
+		return nil, ErrTruncatedRecord
+	}
+
+	hdr := binary.LittleEndian.Uint32(b)
+	if hdr == EOFHint {
+		return nil, nil
+	}
+
+	if hdr&extRecordFlag == 0 { // legacy record
+		n := dataHeaderLen + int(hdr)
+		if n > len(b) {
+			return nil, ErrTruncatedRecord
+		}
+
+		return &Record{Size: n, Payload: b[dataHeaderLen:n]}, nil
+	}
+
+	nbytes := int(hdr &^ extRecordFlag)
+	if nbytes >= maxExtRecordSize {
+		return nil, ErrBadRecordHeader
+	}
+
+	hdrLen := dataHeaderLen + recordFlagsLen
+	if hdrLen+nbytes+recordCRCLen > len(b) {
+		return nil, ErrTruncatedRecord
+	}
+
+	flags := b[dataHeaderLen]
+	if codecOf(flags) > maxCompressAlgo {
+		return nil, ErrBadRecordHeader
+	}
+
+	if flags&flagChecksum != 0 {
+		n := hdrLen + nbytes
+		if binary.LittleEndian.Uint32(b[n:]) != crc32.Checksum(b[:n], castagnoli) {
+			return nil, ErrBadChecksum
+		}
+	}
+
+	return &Record{
+		Size:      hdrLen + nbytes + recordCRCLen,
+		Checksum:  flags&flagChecksum != 0,
+		Compress:  codecOf(flags),
+		Encrypted: flags&flagEncrypted != 0,
+		Payload:   b[hdrLen : hdrLen+nbytes],
+		header:    b[:hdrLen],
+	}, nil
+}
+
+// DecodeRecord get the raw data of the record, keys are used to decrypt
+// the encrypted record.
+func DecodeRecord(rec *Record, keys ...*EncryptionKey) ([]byte, error) {
+	payload := rec.Payload
+
+	if rec.Encrypted {
+		c := defaultInstance()
+		WithDecryptionKeys(keys...)(c)
+		if c.optErr != nil {
+			return nil, c.optErr
+		}
+
+		var err error
+		if payload, err = c.decrypt(append([]byte(nil), payload...), rec.header); err != nil {
+			return nil, err
+		}
+	}
+
+	if rec.Compress == NoCompress {
+		return payload, nil
+	}
+
+	n, err := rawLen(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, n)
+	if err := rec.Compress.decompress(payload, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func loadPosInfo(fname string) (*PosInfo, error) {
+	p, err := posFromFile(fname)
+	if err != nil {
+		return nil, fmt.Errorf("posFromFile(%q): %w", fname, err)
+	}
+
+	if p == nil {
+		return nil, nil
+	}
+
+	pi := &PosInfo{
+		File: fname,
+		Name: string(p.Name),
+		Seek: p.Seek,
+	}
+
+	if base := filepath.Base(fname); base != ".pos" {
+		pi.Consumer = strings.TrimPrefix(base, ".pos.")
+	}
+
+	return pi, nil
+}
+
+// RepairResult is the result of Repair().
+type RepairResult struct {
+	Truncated []*DataFileInfo // data files truncated
+	Pos       []*PosInfo      // .pos files fixed(Seek -1 and empty Name means removed)
+}
+
+// Repair truncate data files at the last valid record, and fix .pos files
+// that point to the truncated bytes. The rotated data file get an EOF mark
+// after truncated.
+//
+// Data file within .pos resolved by it's base name under path, so we can
+// repair a copy of the cache directory. Sub-caches of priority lanes and
+// shards(directories lane.* and shard.*) are repaired too.
+//
+// If the cache locked by an alive process, ErrCacheInUse returned and nothing
+// changed, stop the process or repair on a copy of the cache directory.
+func Repair(path string) (*RepairResult, error) {
+	ci, err := Inspect(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if ci.LockAlive {
+		return nil, fmt.Errorf("%w: locked by alive pid %d", ErrCacheInUse, ci.LockPID)
+	}
+
+	res := &RepairResult{}
+	if err := repairCache(ci, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func repairCache(ci *CacheInfo, res *RepairResult) error {
+	files := map[string]*DataFileInfo{}
+
+	for _, fi := range ci.DataFiles {
+		files[filepath.Base(fi.Path)] = fi
+
+		if fi.Err == nil {
+			continue
+		}
+
+		if err := truncateDataFile(fi); err != nil {
+			return err
+		}
+
+		res.Truncated = append(res.Truncated, fi)
+	}
+
+	for _, pi := range ci.Pos {
+		if pi.Seek < 0 { // file read to EOF
+			continue
+		}
+
+		fi, ok := files[filepath.Base(pi.Name)]
+		if !ok { // data file not exist, remove the .pos
+			if err := os.Remove(pi.File); err != nil {
+				return err
+			}
+
+			res.Pos = append(res.Pos, &PosInfo{File: pi.File, Consumer: pi.Consumer, Seek: -1})
+			continue
+		}
+
+		// move to the nearest record boundary before the seek position
+		end := fi.ValidSize // end of the last valid record
+		if fi.EOF {
+			end -= dataHeaderLen
+		}
+
+		seek := int64(0)
+		for _, off := range fi.Offsets {
+			if off > pi.Seek {
+				break
+			}
+			seek = off
+		}
+
+		if pi.Seek >= end {
+			seek = end
+		}
+
+		if seek == pi.Seek {
+			continue
+		}
+
+		p := &pos{Seek: seek, Name: []byte(pi.Name), fname: pi.File}
+		if err := p.dumpFile(); err != nil {
+			return err
+		}
+
+		if err := p.close(); err != nil {
+			return err
+		}
+
+		res.Pos = append(res.Pos, &PosInfo{File: pi.File, Consumer: pi.Consumer, Name: pi.Name, Seek: seek})
+	}
+
+	// sub-caches of lanes and shards
+	entries, err := os.ReadDir(ci.Path)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if !e.IsDir() || !(strings.HasPrefix(e.Name(), "lane.") || strings.HasPrefix(e.Name(), "shard.")) {
+			continue
+		}
+
+		sub, err := Inspect(filepath.Join(ci.Path, e.Name()))
+		if err != nil {
+			return err
+		}
+
+		if err := repairCache(sub, res); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func truncateDataFile(fi *DataFileInfo) error {
+	size := fi.ValidSize
+	if fi.EOF { // remove bytes after EOF mark
+		if err := os.Truncate(fi.Path, size); err != nil {
+			return err
+		}
+
+		fi.Size = size
+		return nil
+	}
+
+	if err := os.Truncate(fi.Path, size); err != nil {
+		return err
+	}
+
+	if filepath.Base(fi.Path) != "data" { // rotated file should end with EOF mark
+		f, err := os.OpenFile(fi.Path, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return err
+		}
+
+		eof := make([]byte, dataHeaderLen)
+		binary.LittleEndian.PutUint32(eof, EOFHint)
+		if _, err := f.Write(eof); err != nil {
+			f.Close() //nolint:errcheck,gosec
+			return err
+		}
+
+		if err := f.Close(); err != nil {
+			return err
+		}
+
+		size += dataHeaderLen
+		fi.EOF = true
+	}
+
+	fi.Size, fi.ValidSize = size, size
+	return nil
+}
@@ -0,0 +1,267 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	T "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *T.T) {
+	t.Run(`basic`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithConsumers("cloud"))
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, c.Put([]byte(fmt.Sprintf("data-%d", i))))
+		}
+		require.NoError(t, c.Rotate())
+		require.NoError(t, c.Put([]byte("writing")))
+		require.NoError(t, mustConsumer(t, c, "cloud").Get(nil))
+
+		ci, err := Inspect(p)
+		require.NoError(t, err)
+
+		assert.Greater(t, ci.LockPID, 0)
+		assert.True(t, ci.LockAlive)
+
+		require.Len(t, ci.DataFiles, 2)
+		assert.Equal(t, 3, ci.DataFiles[0].Records)
+		assert.True(t, ci.DataFiles[0].EOF)
+		assert.NoError(t, ci.DataFiles[0].Err)
+		assert.Equal(t, "data", filepath.Base(ci.DataFiles[1].Path))
+		assert.Equal(t, 1, ci.DataFiles[1].Records)
+		assert.False(t, ci.DataFiles[1].EOF)
+
+		require.Len(t, ci.Pos, 1)
+		assert.Equal(t, "cloud", ci.Pos[0].Consumer)
+		assert.Equal(t, ci.DataFiles[0].Path, ci.Pos[0].Name)
+		assert.Equal(t, int64(dataHeaderLen+len("data-0")), ci.Pos[0].Seek)
+
+		require.NoError(t, c.Close())
+
+		ci, err = Inspect(p)
+		require.NoError(t, err)
+		assert.Equal(t, -1, ci.LockPID)
+	})
+
+	t.Run(`decode`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		k := &EncryptionKey{ID: "k1", Key: []byte("secret")}
+		c, err := Open(WithPath(p), WithCompression(CompressZstd), WithEncryption(k), WithChecksum(true))
+		require.NoError(t, err)
+
+		data := []byte(strings.Repeat("compressible,", 100))
+		require.NoError(t, c.Put(data))
+		require.NoError(t, c.Rotate())
+		fname := c.dataFiles[0]
+		require.NoError(t, c.Close())
+
+		var got []byte
+		fi, err := ScanDataFile(fname, func(rec *Record) error {
+			assert.True(t, rec.Checksum)
+			assert.True(t, rec.Encrypted)
+			assert.Equal(t, CompressZstd, rec.Compress)
+
+			_, err := DecodeRecord(rec)
+			assert.ErrorIs(t, err, ErrBadEncryptionKey)
+
+			got, err = DecodeRecord(rec, k)
+			return err
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, fi.Records)
+		assert.Equal(t, data, got)
+	})
+
+	t.Run(`compressed`, func(t *T.T) {
+		for _, algo := range []CompressAlgo{CompressZstd, CompressLZ4, CompressSnappy} {
+			ResetMetrics()
+			p := t.TempDir()
+			c, err := Open(WithPath(p), WithCompression(algo), WithChecksum(true))
+			require.NoError(t, err)
+
+			data := []byte(strings.Repeat("compressible,", 100))
+			for i := 0; i < 3; i++ {
+				require.NoError(t, c.Put(data))
+			}
+			require.NoError(t, c.Rotate())
+			fname := c.dataFiles[0]
+			require.NoError(t, c.Close())
+
+			fi, err := ScanDataFile(fname, func(rec *Record) error {
+				assert.Equal(t, algo, rec.Compress)
+
+				got, err := DecodeRecord(rec)
+				assert.Equal(t, data, got)
+				return err
+			})
+			require.NoError(t, err)
+			assert.NoError(t, fi.Err, "algo %d", algo)
+			assert.Equal(t, 3, fi.Records)
+			assert.True(t, fi.EOF)
+
+			// healthy file not truncated
+			res, err := Repair(p)
+			require.NoError(t, err)
+			assert.Len(t, res.Truncated, 0, "algo %d", algo)
+
+			x, err := os.Stat(fname)
+			require.NoError(t, err)
+			assert.Equal(t, fi.ValidSize, x.Size())
+		}
+	})
+
+	t.Run(`verify`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithChecksum(true))
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, c.Put([]byte(fmt.Sprintf("data-%d", i))))
+		}
+		require.NoError(t, c.Rotate())
+		fname := c.dataFiles[0]
+		require.NoError(t, c.Close())
+
+		raw, err := os.ReadFile(fname)
+		require.NoError(t, err)
+		recLen := dataHeaderLen + recordFlagsLen + len("data-0") + recordCRCLen
+
+		// bad checksum on the 2nd record
+		bad := append([]byte(nil), raw...)
+		bad[recLen+dataHeaderLen+recordFlagsLen] ^= 0xff
+		require.NoError(t, os.WriteFile(fname, bad, 0o600))
+
+		fi, err := ScanDataFile(fname, nil)
+		require.NoError(t, err)
+		assert.ErrorIs(t, fi.Err, ErrBadChecksum)
+		assert.Equal(t, 1, fi.Records)
+		assert.Equal(t, int64(recLen), fi.ValidSize)
+
+		// truncated
+		require.NoError(t, os.WriteFile(fname, raw[:len(raw)-7], 0o600))
+		fi, err = ScanDataFile(fname, nil)
+		require.NoError(t, err)
+		assert.ErrorIs(t, fi.Err, ErrTruncatedRecord)
+		assert.Equal(t, 2, fi.Records)
+		assert.False(t, fi.EOF)
+
+		// garbage after EOF
+		require.NoError(t, os.WriteFile(fname, append(raw, "garbage"...), 0o600))
+		fi, err = ScanDataFile(fname, nil)
+		require.NoError(t, err)
+		assert.ErrorIs(t, fi.Err, ErrDataAfterEOF)
+		assert.Equal(t, 3, fi.Records)
+		assert.Equal(t, int64(len(raw)), fi.ValidSize)
+	})
+
+	t.Run(`repair`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithChecksum(true))
+		require.NoError(t, err)
+
+		for i := 0; i < 4; i++ {
+			require.NoError(t, c.Put([]byte(fmt.Sprintf("data-%d", i))))
+		}
+		require.NoError(t, c.Rotate())
+		fname := c.dataFiles[0]
+
+		recLen := dataHeaderLen + recordFlagsLen + len("data-0") + recordCRCLen
+
+		// read 3 records
+		for i := 0; i < 3; i++ {
+			require.NoError(t, c.Get(nil))
+		}
+		require.NoError(t, c.Close())
+
+		// torn write within the 3rd record
+		raw, err := os.ReadFile(fname)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(fname, raw[:2*recLen+5], 0o600))
+
+		// repair on a copy of the cache
+		cp := t.TempDir()
+		for _, f := range []string{filepath.Base(fname), ".pos", ".lock"} {
+			x, err := os.ReadFile(filepath.Join(p, f))
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(filepath.Join(cp, f), x, 0o600))
+		}
+
+		res, err := Repair(cp)
+		require.NoError(t, err)
+
+		require.Len(t, res.Truncated, 1)
+		assert.True(t, res.Truncated[0].EOF)
+		assert.Equal(t, int64(2*recLen+dataHeaderLen), res.Truncated[0].Size)
+
+		require.Len(t, res.Pos, 1)
+		assert.Equal(t, int64(2*recLen), res.Pos[0].Seek)
+
+		ci, err := Inspect(cp)
+		require.NoError(t, err)
+		require.Len(t, ci.DataFiles, 1)
+		assert.NoError(t, ci.DataFiles[0].Err)
+		assert.Equal(t, 2, ci.DataFiles[0].Records)
+
+		// nothing to repair
+		res, err = Repair(cp)
+		require.NoError(t, err)
+		assert.Len(t, res.Truncated, 0)
+		assert.Len(t, res.Pos, 0)
+	})
+
+	t.Run(`repair-in-use`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		_, err = Repair(p)
+		assert.ErrorIs(t, err, ErrCacheInUse)
+
+		require.NoError(t, c.Close())
+	})
+
+	t.Run(`repair-lanes`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithPriorityLanes(2), WithChecksum(true))
+		require.NoError(t, err)
+
+		require.NoError(t, c.PutPriority(1, []byte("data-0")))
+		require.NoError(t, c.PutPriority(1, []byte("data-1")))
+		require.NoError(t, c.Close())
+
+		// torn write within the 2nd record of the lane
+		ci, err := Inspect(filepath.Join(p, "lane.1"))
+		require.NoError(t, err)
+		require.Len(t, ci.DataFiles, 1)
+
+		fname := ci.DataFiles[0].Path
+		recLen := dataHeaderLen + recordFlagsLen + len("data-0") + recordCRCLen
+		raw, err := os.ReadFile(fname)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(fname, raw[:recLen+5], 0o600))
+
+		res, err := Repair(p)
+		require.NoError(t, err)
+		require.Len(t, res.Truncated, 1)
+		assert.Equal(t, fname, res.Truncated[0].Path)
+		assert.Equal(t, int64(recLen), res.Truncated[0].Size)
+	})
+}
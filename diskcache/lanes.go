@@ -0,0 +1,138 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+const (
+	// Lease token of lanes: the highest 8 bits are the lane.
+	laneTokenShift = 56
+	maxLanes       = 1 << (64 - laneTokenShift)
+)
+
+// Priority out of range of lanes.
+var ErrInvalidPriority = errors.New("invalid priority")
+
+// openLanes open n lanes(or shards) under directory c.path/<kind>.<i>.
+func (c *DiskCache) openLanes(kind string, n int) error {
+	for i := 0; i < n; i++ {
+		l := defaultInstance()
+
+		l.path = filepath.Join(c.path, fmt.Sprintf("%s.%d", kind, i))
+		l.parent = c
+		l.lane = i
+		l.noLock = true // the parent directory locked
+
+		l.batchSize = c.batchSize
+		l.maxDataSize = c.maxDataSize
+		l.wakeup = c.wakeup
+		l.leaseTimeout = c.leaseTimeout
+		l.maxAge = c.maxAge
+		l.groupCommit, l.gcMaxDelay, l.gcMaxBytes = c.groupCommit, c.gcMaxDelay, c.gcMaxBytes
+		l.archive, l.archiveCapacity = c.archive, c.archiveCapacity
+		l.dirPerms, l.filePerms = c.dirPerms, c.filePerms
+
+		l.compress, l.encKey, l.decKeys = c.compress, c.encKey, c.decKeys
+
+		l.noSync = c.noSync
+		l.noFallbackOnError = c.noFallbackOnError
+		l.noPos = c.noPos
+		l.filoDrop = c.filoDrop
+		l.noDrop = c.noDrop
+		l.checksum = c.checksum
+
+		if err := l.doOpen(); err != nil {
+			return fmt.Errorf("open %s %d: %w", kind, i, err)
+		}
+
+		c.lanes = append(c.lanes, l)
+	}
+
+	return nil
+}
+
+// PutPriority write data into lane of priority prio(0 is the lowest).
+// Without WithPriorityLanes(), the priority ignored.
+func (c *DiskCache) PutPriority(prio int, data []byte) error {
+	if c.laneCount <= 1 {
+		return c.Put(data)
+	}
+
+	if prio < 0 || prio >= len(c.lanes) {
+		return fmt.Errorf("%w: %d", ErrInvalidPriority, prio)
+	}
+
+	return c.lanes[prio].Put(data)
+}
+
+// drainLanes call fn on lanes from the highest priority until fn not
+// returns ErrNoData.
+func (c *DiskCache) drainLanes(fn func(l *DiskCache) error) error {
+	if c.shardCount > 1 {
+		return c.drainShards(fn)
+	}
+
+	for i := len(c.lanes) - 1; i >= 0; i-- {
+		if err := fn(c.lanes[i]); !errors.Is(err, ErrNoData) {
+			return err
+		}
+	}
+
+	return ErrNoData
+}
+
+// lanesFull test if all lanes reached the capacity after put n bytes.
+func (c *DiskCache) lanesFull(n int64) bool {
+	if c.capacity <= 0 {
+		return false
+	}
+
+	size := n
+	for _, l := range c.lanes {
+		size += l.size.Load()
+	}
+
+	return size > c.capacity
+}
+
+// dropLowestLane drop the oldest data file of the lowest priority lane that
+// has any data file.
+func (c *DiskCache) dropLowestLane() error {
+	for _, l := range c.lanes {
+		if err := func() error {
+			l.rwlock.Lock()
+			defer l.rwlock.Unlock()
+
+			if len(l.dataFiles) == 0 {
+				return ErrNoData
+			}
+
+			return l.dropOldestFile(reasonExceedCapacity)
+		}(); !errors.Is(err, ErrNoData) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func laneToken(lane int, token LeaseToken) LeaseToken {
+	return LeaseToken(lane)<<laneTokenShift | token
+}
+
+// laneOfToken get the lane and the lane's lease token.
+func (c *DiskCache) laneOfToken(token LeaseToken) (*DiskCache, LeaseToken, error) {
+	lane := int(token >> laneTokenShift)
+	if lane >= len(c.lanes) {
+		return nil, 0, fmt.Errorf("%w: %d", ErrInvalidLease, token)
+	}
+
+	return c.lanes[lane], token & (1<<laneTokenShift - 1), nil
+}
@@ -0,0 +1,178 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"bytes"
+	"fmt"
+	T "testing"
+
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriorityLanes(t *T.T) {
+	t.Run(`get-higher-first`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithPriorityLanes(3))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put([]byte("bulk-1")))
+		require.NoError(t, c.PutPriority(1, []byte("normal-1")))
+		require.NoError(t, c.PutPriority(2, []byte("critical-1")))
+		require.NoError(t, c.PutPriority(0, []byte("bulk-2")))
+		require.NoError(t, c.PutPriority(2, []byte("critical-2")))
+		require.NoError(t, c.Rotate())
+
+		assert.ErrorIs(t, c.PutPriority(3, []byte("x")), ErrInvalidPriority)
+
+		var got []string
+		for _, x := range getAll(t, c) {
+			got = append(got, string(x))
+		}
+
+		assert.Equal(t, []string{"critical-1", "critical-2", "normal-1", "bulk-1", "bulk-2"}, got)
+		assert.Equal(t, int64(0), c.Size())
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`reopen`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithPriorityLanes(2))
+		require.NoError(t, err)
+
+		require.NoError(t, c.PutPriority(0, []byte("bulk")))
+		require.NoError(t, c.PutPriority(1, []byte("critical")))
+		require.NoError(t, c.Close())
+
+		c, err = Open(WithPath(p), WithPriorityLanes(2))
+		require.NoError(t, err)
+		require.NoError(t, c.Rotate())
+
+		assert.Equal(t, [][]byte{[]byte("critical"), []byte("bulk")}, getAll(t, c))
+		assert.NoError(t, c.Close())
+
+		// lanes ignored by cache without lanes
+		c, err = Open(WithPath(p))
+		require.NoError(t, err)
+		assert.Len(t, c.dataFiles, 0)
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`drop-lower-first`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+
+		data := bytes.Repeat([]byte("x"), 1000)
+		c, err := Open(WithPath(p),
+			WithPriorityLanes(2),
+			WithBatchSize(4*1024),
+			WithCapacity(32*1024))
+		require.NoError(t, err)
+
+		for i := 0; i < 16; i++ {
+			require.NoError(t, c.PutPriority(1, data))
+		}
+
+		// bulk data fill the cache
+		for i := 0; i < 64; i++ {
+			require.NoError(t, c.PutPriority(0, data))
+		}
+
+		require.NoError(t, c.Rotate())
+		assert.Less(t, c.RawSize(), int64(64*len(data))) // bulk data dropped
+
+		// all critical data kept
+		n := 0
+		for {
+			if err := c.lanes[1].Get(nil); err != nil {
+				require.ErrorIs(t, err, ErrNoData)
+				break
+			}
+			n++
+		}
+		assert.Equal(t, 16, n)
+
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(Metrics()...)
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+
+		// only bulk lane dropped
+		assert.NotNil(t, metrics.GetMetricOnLabels(mfs, "diskcache_dropped_data", c.lanes[0].path, reasonExceedCapacity))
+		assert.Nil(t, metrics.GetMetricOnLabels(mfs, "diskcache_dropped_data", c.lanes[1].path, reasonExceedCapacity))
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`peek-commit`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithPriorityLanes(2))
+		require.NoError(t, err)
+
+		require.NoError(t, c.PutPriority(0, []byte("bulk")))
+		require.NoError(t, c.PutPriority(1, []byte("critical")))
+		require.NoError(t, c.Rotate())
+
+		b1, t1, err := c.Peek(0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, [][]byte{[]byte("critical")}, b1)
+
+		b0, t0, err := c.Peek(0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, [][]byte{[]byte("bulk")}, b0)
+
+		require.NoError(t, c.Rollback(t0))
+		require.NoError(t, c.Commit(t1))
+
+		assert.ErrorIs(t, c.Commit(laneToken(5, t1)), ErrInvalidLease)
+
+		assert.Equal(t, [][]byte{[]byte("bulk")}, getAll(t, c))
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`with-consumers`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		_, err := Open(WithPath(p), WithPriorityLanes(2), WithConsumers("cloud"))
+		assert.Error(t, err)
+
+		// the path not locked on invalid options
+		c, err := Open(WithPath(p), WithPriorityLanes(2))
+		require.NoError(t, err)
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`batch-within-lane`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithPriorityLanes(2))
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, c.PutPriority(i%2, []byte(fmt.Sprintf("data-%d", i))))
+		}
+		require.NoError(t, c.Rotate())
+
+		require.NoError(t, c.GetBatch(0, 0, func(batch [][]byte) error {
+			assert.Equal(t, [][]byte{[]byte("data-1")}, batch)
+			return nil
+		}))
+
+		require.NoError(t, c.GetBatch(0, 0, func(batch [][]byte) error {
+			assert.Equal(t, [][]byte{[]byte("data-0"), []byte("data-2")}, batch)
+			return nil
+		}))
+
+		assert.NoError(t, c.Close())
+	})
+}
@@ -0,0 +1,342 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var (
+	// Lease not found, it may be committed, rolled back or expired.
+	ErrInvalidLease = errors.New("invalid or expired lease")
+
+	// Get() not allowed while there are uncommitted leases on the reader.
+	ErrPendingLeases = errors.New("pending leases")
+)
+
+// LeaseToken identify records returned by Peek().
+type LeaseToken uint64
+
+// lease is a range of records returned by Peek() but not committed.
+type lease struct {
+	token      LeaseToken
+	start, end int64 // offset range within current read file
+	deadline   time.Time
+	committed  bool
+}
+
+// Peek fetch a batch of records from disk cache(see GetBatch() for maxRecords
+// and maxBytes) without committing them, the returned token should be
+// committed by Commit() or rolled back by Rollback() later.
+//
+// Multiple batches can be peeked before committing, so they can be processed
+// concurrently, and they can be committed in any order. The .pos only moves
+// forward over leading committed batches, records of uncommitted batches are
+// read again after restart.
+//
+// If a batch not committed within the lease timeout(see WithLeaseTimeout()),
+// it's rolled back automatically: the batch, and all uncommitted batches peeked
+// after it, will be returned by Peek() again, and their tokens become invalid.
+// Batches already committed are never returned again.
+//
+// Peeked batches never cross data files, before all leases on current file
+// committed, Peek() returns ErrNoData if current file read to EOF. Get() on
+// the reader fail with ErrPendingLeases until all leases committed(or expired).
+func (c *DiskCache) Peek(maxRecords, maxBytes int) ([][]byte, LeaseToken, error) {
+	if len(c.lanes) > 0 {
+		var (
+			batch [][]byte
+			token LeaseToken
+		)
+
+		err := c.drainLanes(func(l *DiskCache) error {
+			b, t, err := l.Peek(maxRecords, maxBytes)
+			if err == nil {
+				batch, token = b, laneToken(l.lane, t)
+			}
+			return err
+		})
+
+		return batch, token, err
+	}
+
+	r, err := c.defaultReader()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if c.memCapacity > 0 { // leases only work on data files, spill the memory buffer to disk
+		batch, token, err := c.doPeek(r, maxRecords, maxBytes)
+		if !errors.Is(err, ErrNoData) {
+			return batch, token, err
+		}
+
+		if err := c.flushMem(); err != nil {
+			return nil, 0, err
+		}
+
+		if err := c.rotateWriting(); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return c.doPeek(r, maxRecords, maxBytes)
+}
+
+// Commit the records peeked with token.
+func (c *DiskCache) Commit(token LeaseToken) error {
+	if len(c.lanes) > 0 {
+		l, t, err := c.laneOfToken(token)
+		if err != nil {
+			return err
+		}
+
+		return l.Commit(t)
+	}
+
+	r, err := c.defaultReader()
+	if err != nil {
+		return err
+	}
+
+	return c.doCommit(r, token)
+}
+
+// Rollback the records peeked with token, these records, and all uncommitted
+// records peeked after them, will be returned by Peek() again.
+//
+// Rollback always take effect even if WithNoFallbackOnError() set.
+func (c *DiskCache) Rollback(token LeaseToken) error {
+	if len(c.lanes) > 0 {
+		l, t, err := c.laneOfToken(token)
+		if err != nil {
+			return err
+		}
+
+		return l.Rollback(t)
+	}
+
+	r, err := c.defaultReader()
+	if err != nil {
+		return err
+	}
+
+	return c.doRollback(r, token)
+}
+
+func (c *DiskCache) doPeek(r *reader, maxRecords, maxBytes int) ([][]byte, LeaseToken, error) {
+	r.rlock.Lock()
+	defer r.rlock.Unlock()
+
+	if err := c.expireLeases(r); err != nil {
+		return nil, 0, err
+	}
+
+	limit, err := c.skipCommitted(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	start := time.Now()
+
+	// do not switch to next file if any lease pending on current file.
+	batch, total, err := c.readBatch(r, maxRecords, maxBytes, limit, len(r.leases) > 0)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer func() {
+		getLatencyVec.WithLabelValues(c.metricPath()).Observe(time.Since(start).Seconds())
+	}()
+
+	end, err := r.rfd.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, 0, fmt.Errorf("rfd.Seek: %w", err)
+	}
+
+	r.leaseSeq++
+	l := &lease{
+		token:    LeaseToken(r.leaseSeq),
+		start:    end - int64(total),
+		end:      end,
+		deadline: time.Now().Add(c.leaseTimeout),
+	}
+
+	// keep leases in read order, the new lease may be before committed
+	// leases kept on rollback.
+	i := len(r.leases)
+	for i > 0 && r.leases[i-1].start > l.start {
+		i--
+	}
+
+	r.leases = append(r.leases, nil)
+	copy(r.leases[i+1:], r.leases[i:])
+	r.leases[i] = l
+
+	return batch, l.token, nil
+}
+
+func (c *DiskCache) doCommit(r *reader, token LeaseToken) error {
+	r.rlock.Lock()
+	defer r.rlock.Unlock()
+
+	if err := c.expireLeases(r); err != nil {
+		return err
+	}
+
+	i := r.leaseIndex(token)
+	if i < 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidLease, token)
+	}
+
+	r.leases[i].committed = true
+
+	// move .pos over leading committed leases, stop on the gap before
+	// committed leases kept on rollback.
+	n, end := 0, r.leases[0].start
+	for len(r.leases) > 0 && r.leases[0].committed && r.leases[0].start == end {
+		n += int(r.leases[0].end - r.leases[0].start)
+		end = r.leases[0].end
+		r.leases = r.leases[1:]
+	}
+
+	if n > 0 {
+		return c.commitPos(r, n)
+	}
+
+	return nil
+}
+
+func (c *DiskCache) doRollback(r *reader, token LeaseToken) error {
+	r.rlock.Lock()
+	defer r.rlock.Unlock()
+
+	if err := c.expireLeases(r); err != nil {
+		return err
+	}
+
+	i := r.leaseIndex(token)
+	if i < 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidLease, token)
+	}
+
+	return c.rollbackFrom(r, i)
+}
+
+// checkPendingLeases fail with ErrPendingLeases if there are leases not
+// committed(and not expired) on r. The caller should hold r.rlock.
+func (c *DiskCache) checkPendingLeases(r *reader) error {
+	if err := c.expireLeases(r); err != nil {
+		return err
+	}
+
+	for _, l := range r.leases {
+		if !l.committed {
+			return ErrPendingLeases
+		}
+	}
+
+	return nil
+}
+
+// expireLeases rollback the first expired lease(and all leases after it).
+// Leases on dropped file are removed.
+func (c *DiskCache) expireLeases(r *reader) error {
+	if err := c.skipDroppedFile(r); err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	for i, l := range r.leases {
+		if !l.committed && now.After(l.deadline) {
+			leaseExpiredVec.WithLabelValues(c.metricPath()).Inc()
+			return c.rollbackFrom(r, i)
+		}
+	}
+
+	return nil
+}
+
+// rollbackFrom seek back to the i-th lease, and drop it and all uncommitted
+// leases after it. Committed leases after it are kept and skipped on
+// following reads(see skipCommitted()), or they will be delivered again.
+func (c *DiskCache) rollbackFrom(r *reader, i int) error {
+	if r.rfd != nil {
+		if _, err := r.rfd.Seek(r.leases[i].start, io.SeekStart); err != nil {
+			return fmt.Errorf("r.rfd.Seek(%d) on rollback: %w", r.leases[i].start, err)
+		}
+	}
+
+	// leading committed leases already removed on Commit(), so
+	// there is no need to move .pos here.
+	kept := r.leases[:i]
+	for _, l := range r.leases[i+1:] {
+		if l.committed {
+			kept = append(kept, l)
+		}
+	}
+
+	r.leases = kept
+	seekBackVec.WithLabelValues(c.metricPath()).Inc()
+	c.notifyData()
+
+	return nil
+}
+
+// skipCommitted seek over committed leases(kept on rollback) at current read
+// position, and returns on-disk bytes can be read before the next committed
+// lease, 0 for no limit. A committed lease at the head of leases is removed
+// and .pos moved over it, since all records before it are consumed.
+func (c *DiskCache) skipCommitted(r *reader) (int, error) {
+	if r.rfd == nil || len(r.leases) == 0 {
+		return 0, nil
+	}
+
+	pos, err := r.rfd.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, fmt.Errorf("rfd.Seek: %w", err)
+	}
+
+	for i := 0; i < len(r.leases); i++ {
+		l := r.leases[i]
+		if !l.committed || l.end <= pos {
+			continue
+		}
+
+		if l.start > pos {
+			return int(l.start - pos), nil
+		}
+
+		if pos, err = r.rfd.Seek(l.end, io.SeekStart); err != nil {
+			return 0, fmt.Errorf("r.rfd.Seek(%d): %w", l.end, err)
+		}
+
+		if i == 0 {
+			r.leases = r.leases[1:]
+			i--
+
+			if err := c.commitPos(r, int(l.end-l.start)); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return 0, nil
+}
+
+func (r *reader) leaseIndex(token LeaseToken) int {
+	for i, l := range r.leases {
+		if l.token == token {
+			return i
+		}
+	}
+
+	return -1
+}
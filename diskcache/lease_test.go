@@ -0,0 +1,284 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"fmt"
+	T "testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLease(t *T.T) {
+	put := func(t *T.T, c *DiskCache, n int) (expect [][]byte) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			data := []byte(fmt.Sprintf("data-%02d", i))
+			require.NoError(t, c.Put(data))
+			expect = append(expect, data)
+		}
+		require.NoError(t, c.Rotate())
+		return
+	}
+
+	t.Run(`peek-commit`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		expect := put(t, c, 6)
+
+		b1, t1, err := c.Peek(2, 0)
+		require.NoError(t, err)
+		b2, t2, err := c.Peek(2, 0)
+		require.NoError(t, err)
+		b3, t3, err := c.Peek(2, 0)
+		require.NoError(t, err)
+
+		assert.Equal(t, expect[0:2], b1)
+		assert.Equal(t, expect[2:4], b2)
+		assert.Equal(t, expect[4:6], b3)
+
+		// nothing more, and not switch to next file with pending leases
+		_, _, err = c.Peek(2, 0)
+		assert.ErrorIs(t, err, ErrNoData)
+		assert.ErrorIs(t, c.Get(nil), ErrPendingLeases)
+
+		// commit out of order
+		require.NoError(t, c.Commit(t2))
+		require.NoError(t, c.Commit(t3))
+		assert.Equal(t, int64(0), c.reader.pos.Seek) // t1 not committed
+
+		require.NoError(t, c.Commit(t1))
+		assert.Equal(t, int64(6*(dataHeaderLen+7)), c.reader.pos.Seek)
+
+		assert.ErrorIs(t, c.Commit(t1), ErrInvalidLease)
+
+		_, _, err = c.Peek(2, 0)
+		assert.ErrorIs(t, err, ErrNoData)
+		assert.Len(t, c.dataFiles, 0)
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`rollback`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		expect := put(t, c, 6)
+
+		_, t1, err := c.Peek(2, 0)
+		require.NoError(t, err)
+		_, t2, err := c.Peek(2, 0)
+		require.NoError(t, err)
+		_, t3, err := c.Peek(2, 0)
+		require.NoError(t, err)
+
+		require.NoError(t, c.Commit(t1))
+		require.NoError(t, c.Rollback(t2))
+
+		// t3 peeked after t2, it's rolled back too
+		assert.ErrorIs(t, c.Commit(t3), ErrInvalidLease)
+
+		b, tk, err := c.Peek(0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, expect[2:], b)
+		require.NoError(t, c.Commit(tk))
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`rollback-keep-committed`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		expect := put(t, c, 6)
+
+		_, t1, err := c.Peek(2, 0)
+		require.NoError(t, err)
+		_, t2, err := c.Peek(2, 0)
+		require.NoError(t, err)
+		_, t3, err := c.Peek(2, 0)
+		require.NoError(t, err)
+
+		require.NoError(t, c.Commit(t2))
+		require.NoError(t, c.Rollback(t1))
+		assert.ErrorIs(t, c.Commit(t3), ErrInvalidLease)
+
+		// committed t2 not returned again, and the batch not cross it
+		b, tk1, err := c.Peek(0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, expect[0:2], b)
+
+		b, tk2, err := c.Peek(0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, expect[4:6], b)
+
+		require.NoError(t, c.Commit(tk2))
+		assert.Equal(t, int64(0), c.reader.pos.Seek)
+
+		require.NoError(t, c.Commit(tk1))
+		assert.Equal(t, int64(6*(dataHeaderLen+7)), c.reader.pos.Seek)
+
+		_, _, err = c.Peek(0, 0)
+		assert.ErrorIs(t, err, ErrNoData)
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`get-after-expired-with-committed`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithLeaseTimeout(100*time.Millisecond))
+		require.NoError(t, err)
+
+		expect := put(t, c, 6)
+
+		_, _, err = c.Peek(2, 0)
+		require.NoError(t, err)
+		_, t2, err := c.Peek(2, 0)
+		require.NoError(t, err)
+		require.NoError(t, c.Commit(t2))
+
+		// the 1st lease expired, Get() skip the committed records
+		time.Sleep(200 * time.Millisecond)
+		assert.NoError(t, c.GetBatch(0, 0, func(batch [][]byte) error {
+			assert.Equal(t, expect[0:2], batch)
+			return nil
+		}))
+
+		assert.Equal(t, expect[4:6], getAll(t, c))
+
+		assert.NoError(t, c.Close())
+
+		c, err = Open(WithPath(p))
+		require.NoError(t, err)
+		assert.Len(t, getAll(t, c), 0)
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`uncommitted-after-reopen`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		expect := put(t, c, 4)
+
+		_, t1, err := c.Peek(2, 0)
+		require.NoError(t, err)
+		_, _, err = c.Peek(2, 0)
+		require.NoError(t, err)
+		require.NoError(t, c.Commit(t1))
+		require.NoError(t, c.Close())
+
+		c, err = Open(WithPath(p))
+		require.NoError(t, err)
+		assert.Equal(t, expect[2:], getAll(t, c))
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`lease-timeout`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithLeaseTimeout(100*time.Millisecond))
+		require.NoError(t, err)
+
+		expect := put(t, c, 4)
+
+		b1, t1, err := c.Peek(2, 0)
+		require.NoError(t, err)
+		assert.Equal(t, expect[:2], b1)
+
+		time.Sleep(200 * time.Millisecond)
+
+		// expired lease peeked again
+		b, tk, err := c.Peek(2, 0)
+		require.NoError(t, err)
+		assert.Equal(t, expect[:2], b)
+
+		assert.ErrorIs(t, c.Commit(t1), ErrInvalidLease)
+		require.NoError(t, c.Commit(tk))
+
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(Metrics()...)
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+
+		assert.Equal(t, float64(1),
+			metrics.GetMetricOnLabels(mfs, "diskcache_lease_expired_total", c.path).GetCounter().GetValue(),
+			"got metrics\n%s", metrics.MetricFamily2Text(mfs))
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`get-after-lease-timeout`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithLeaseTimeout(100*time.Millisecond))
+		require.NoError(t, err)
+
+		expect := put(t, c, 2)
+
+		_, _, err = c.Peek(1, 0)
+		require.NoError(t, err)
+		assert.ErrorIs(t, c.Get(nil), ErrPendingLeases)
+		assert.ErrorIs(t, c.GetBatch(0, 0, nil), ErrPendingLeases)
+
+		// the lease holder gone, Get() ok after the lease expired
+		time.Sleep(200 * time.Millisecond)
+		assert.NoError(t, c.Get(func(x []byte) error {
+			assert.Equal(t, expect[0], x)
+			return nil
+		}))
+
+		assert.NoError(t, c.GetBatch(0, 0, func(batch [][]byte) error {
+			assert.Equal(t, expect[1:], batch)
+			return nil
+		}))
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`concurrent-consumers`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithConsumers("cloud", "kafka"))
+		require.NoError(t, err)
+
+		expect := put(t, c, 4)
+
+		_, _, err = c.Peek(1, 0)
+		assert.ErrorIs(t, err, ErrConsumerRequired)
+
+		cloud := mustConsumer(t, c, "cloud")
+		kafka := mustConsumer(t, c, "kafka")
+
+		b, tk, err := cloud.Peek(0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, expect, b)
+
+		// leases are per consumer
+		assert.ErrorIs(t, kafka.Commit(tk), ErrInvalidLease)
+		assert.Equal(t, expect, consumeAll(t, kafka))
+
+		require.NoError(t, cloud.Commit(tk))
+		_, _, err = cloud.Peek(0, 0)
+		assert.ErrorIs(t, err, ErrNoData)
+		assert.Len(t, c.dataFiles, 0)
+
+		assert.NoError(t, c.Close())
+	})
+}
@@ -0,0 +1,178 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"errors"
+	"time"
+)
+
+// memPut put data into the memory buffer. If the buffer is full, all data
+// within the buffer are spilled to disk.
+func (c *DiskCache) memPut(data []byte) error {
+	if c.maxDataSize > 0 && int32(len(data)) > c.maxDataSize {
+		return ErrTooLargeData
+	}
+
+	c.memMu.Lock()
+	defer c.memMu.Unlock()
+
+	n := int64(len(data))
+	if c.memSize+n > c.memCapacity {
+		if err := c.spillMem(); err != nil {
+			return err
+		}
+	}
+
+	if n > c.memCapacity { // too large for the buffer
+		return c.putDisk(data)
+	}
+
+	c.memData = append(c.memData, append([]byte(nil), data...))
+	c.memSize += n
+	memSizeVec.WithLabelValues(c.metricPath()).Set(float64(c.memSize))
+
+	c.notifyData()
+	return nil
+}
+
+// spillMem write all data within memory buffer to disk, the oldest first.
+// The caller should hold the memMu.
+func (c *DiskCache) spillMem() error {
+	defer memSizeVec.WithLabelValues(c.metricPath()).Set(float64(c.memSize))
+
+	if len(c.memData) == 0 {
+		return nil
+	}
+
+	n, err := c.putDiskBatch(c.memData)
+
+	for i := 0; i < n; i++ {
+		c.memSize -= int64(len(c.memData[i]))
+		c.memData[i] = nil
+	}
+	c.memData = c.memData[n:]
+	memSpillVec.WithLabelValues(c.metricPath()).Add(float64(n))
+
+	if len(c.memData) == 0 {
+		c.memData = nil
+	}
+
+	return err
+}
+
+// flushMem spill all data within memory buffer to disk.
+func (c *DiskCache) flushMem() error {
+	c.memMu.Lock()
+	defer c.memMu.Unlock()
+
+	return c.spillMem()
+}
+
+// memGet get from disk first(data on disk are older than data within memory
+// buffer), then from memory buffer at most maxRecords records or about
+// maxBytes bytes.
+func (c *DiskCache) memGet(r *reader, get func() error, maxRecords, maxBytes int, fn BatchFn) error {
+	start := time.Now()
+
+	batch, err := c.memPopAfterDisk(get, maxRecords, maxBytes)
+	if err != nil || len(batch) == 0 { // failed, or get on disk ok
+		return err
+	}
+
+	r.rlock.Lock() // serialize fn like Get() on disk
+	defer r.rlock.Unlock()
+
+	defer func() {
+		getLatencyVec.WithLabelValues(c.metricPath()).Observe(time.Since(start).Seconds())
+	}()
+
+	memHitVec.WithLabelValues(c.metricPath()).Add(float64(len(batch)))
+
+	if fn != nil {
+		if err := fn(batch); err != nil {
+			// put back for the next Get(), if the buffer spilled during fn,
+			// these data will be Get() after the spilled data.
+			if !c.noFallbackOnError {
+				c.memPushFront(batch)
+				seekBackVec.WithLabelValues(c.metricPath()).Inc()
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// memPopAfterDisk pop data from memory buffer after all data on disk are
+// read, nothing popped if get on disk ok. Get() on disk returns ErrNoData, but there may be data spilled to
+// current write file, so rotate it and Get() again.
+func (c *DiskCache) memPopAfterDisk(get func() error, maxRecords, maxBytes int) ([][]byte, error) {
+	for {
+		if err := get(); !errors.Is(err, ErrNoData) {
+			return nil, err
+		}
+
+		if batch, spilled := c.memPop(maxRecords, maxBytes); !spilled {
+			if len(batch) == 0 {
+				return nil, ErrNoData
+			}
+			return batch, nil
+		}
+
+		if err := c.rotateWriting(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// memPop pop data from memory buffer, if there are data spilled to current
+// write file, nothing popped.
+func (c *DiskCache) memPop(maxRecords, maxBytes int) (batch [][]byte, spilled bool) {
+	c.memMu.Lock()
+	defer c.memMu.Unlock()
+
+	c.wlock.Lock()
+	spilled = c.curBatchSize > 0
+	c.wlock.Unlock()
+
+	if spilled {
+		return nil, true
+	}
+
+	bytes := 0
+
+	for len(c.memData) > 0 {
+		data := c.memData[0]
+		if len(batch) > 0 &&
+			((maxRecords > 0 && len(batch) >= maxRecords) ||
+				(maxBytes > 0 && bytes+len(data) > maxBytes)) {
+			break
+		}
+
+		batch = append(batch, data)
+		bytes += len(data)
+
+		c.memData[0] = nil
+		c.memData = c.memData[1:]
+		c.memSize -= int64(len(data))
+	}
+
+	memSizeVec.WithLabelValues(c.metricPath()).Set(float64(c.memSize))
+	return batch, false
+}
+
+func (c *DiskCache) memPushFront(batch [][]byte) {
+	c.memMu.Lock()
+	defer c.memMu.Unlock()
+
+	c.memData = append(append([][]byte{}, batch...), c.memData...)
+	for _, data := range batch {
+		c.memSize += int64(len(data))
+	}
+
+	memSizeVec.WithLabelValues(c.metricPath()).Set(float64(c.memSize))
+}
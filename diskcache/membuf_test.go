@@ -0,0 +1,193 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"errors"
+	"fmt"
+	T "testing"
+
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryBuffer(t *T.T) {
+	t.Run(`memory-hit`, func(t *T.T) {
+		ResetMetrics()
+
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(Metrics()...)
+
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithMemoryBuffer(1024))
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, c.Put([]byte(fmt.Sprintf("data-%d", i))))
+		}
+		assert.Equal(t, int64(3*len("data-0")), c.Size())
+
+		assert.Equal(t, [][]byte{[]byte("data-0"), []byte("data-1"), []byte("data-2")}, getAll(t, c))
+		assert.Equal(t, int64(0), c.curBatchSize) // nothing written to disk
+		assert.Len(t, c.dataFiles, 0)
+
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+
+		m := metrics.GetMetricOnLabels(mfs, "diskcache_memory_hit_total", p)
+		require.NotNil(t, m)
+		assert.Equal(t, 3.0, m.GetCounter().GetValue())
+		assert.Nil(t, metrics.GetMetricOnLabels(mfs, "diskcache_memory_spill_total", p))
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`spill-keep-order`, func(t *T.T) {
+		ResetMetrics()
+
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(Metrics()...)
+
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithMemoryBuffer(int64(2*len("data-0"))))
+		require.NoError(t, err)
+
+		var expect [][]byte
+		for i := 0; i < 5; i++ {
+			data := []byte(fmt.Sprintf("data-%d", i))
+			expect = append(expect, data)
+			require.NoError(t, c.Put(data))
+		}
+
+		// data-0..3 spilled, data-4 in memory
+		assert.Len(t, c.memData, 1)
+
+		// data spilled during Get() are still in order
+		require.NoError(t, c.Get(func(x []byte) error {
+			assert.Equal(t, expect[0], x)
+			return nil
+		}))
+
+		for i := 5; i < 8; i++ {
+			data := []byte(fmt.Sprintf("data-%d", i))
+			expect = append(expect, data)
+			require.NoError(t, c.Put(data))
+		}
+
+		assert.Equal(t, expect[1:], getAll(t, c))
+
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+
+		m := metrics.GetMetricOnLabels(mfs, "diskcache_memory_spill_total", p)
+		require.NotNil(t, m)
+		assert.Equal(t, 6.0, m.GetCounter().GetValue())
+
+		m = metrics.GetMetricOnLabels(mfs, "diskcache_memory_size", p)
+		require.NotNil(t, m)
+		assert.Equal(t, 0.0, m.GetGauge().GetValue())
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`spill-across-rotate`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p),
+			WithBatchSize(int64(3*(dataHeaderLen+len("data-00")))),
+			WithMemoryBuffer(int64(10*len("data-00"))))
+		require.NoError(t, err)
+
+		var expect [][]byte
+		for i := 0; i < 11; i++ {
+			data := []byte(fmt.Sprintf("data-%02d", i))
+			expect = append(expect, data)
+			require.NoError(t, c.Put(data))
+		}
+
+		// data-00..09 spilled into multiple files at once
+		assert.Len(t, c.memData, 1)
+		assert.Len(t, c.dataFiles, 3)
+		assert.Equal(t, int64(len("data-00")), c.memSize)
+
+		assert.Equal(t, expect, getAll(t, c))
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`flush-on-close`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithMemoryBuffer(1024))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put([]byte("hello")))
+		require.NoError(t, c.Put([]byte("world")))
+		require.NoError(t, c.Close())
+
+		c, err = Open(WithPath(p))
+		require.NoError(t, err)
+		require.NoError(t, c.Rotate())
+
+		assert.Equal(t, [][]byte{[]byte("hello"), []byte("world")}, getAll(t, c))
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`fallback-and-batch`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithMemoryBuffer(1024))
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, c.Put([]byte(fmt.Sprintf("data-%d", i))))
+		}
+
+		errFail := errors.New("fail")
+		assert.ErrorIs(t, c.Get(func([]byte) error { return errFail }), errFail)
+
+		require.NoError(t, c.GetBatch(2, 0, func(batch [][]byte) error {
+			assert.Equal(t, [][]byte{[]byte("data-0"), []byte("data-1")}, batch)
+			return nil
+		}))
+
+		assert.Equal(t, [][]byte{[]byte("data-2")}, getAll(t, c))
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`peek-spill`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithMemoryBuffer(1024))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put([]byte("hello")))
+
+		batch, tk, err := c.Peek(0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, [][]byte{[]byte("hello")}, batch)
+		require.NoError(t, c.Commit(tk))
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`invalid-combination`, func(t *T.T) {
+		ResetMetrics()
+
+		_, err := Open(WithPath(t.TempDir()), WithMemoryBuffer(1024), WithConsumers("cloud"))
+		assert.Error(t, err)
+
+		p := t.TempDir()
+		_, err = Open(WithPath(p), WithMemoryBuffer(1024), WithShards(2))
+		assert.Error(t, err)
+
+		// the failed Open() should not keep the lock
+		c, err := Open(WithPath(p), WithMemoryBuffer(1024))
+		require.NoError(t, err)
+		assert.NoError(t, c.Close())
+	})
+}
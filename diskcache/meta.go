@@ -7,19 +7,47 @@ package diskcache
 
 // Size return current size of the cache.
 func (c *DiskCache) Size() int64 {
+	if len(c.lanes) > 0 {
+		var n int64
+		for _, l := range c.lanes {
+			n += l.Size()
+		}
+		return n
+	}
+
 	c.rwlock.Lock()
 	defer c.rwlock.Unlock()
 
 	if len(c.dataFiles) > 0 { // there are files waiting to be read
-		return c.size.Load()
+		return c.size.Load() + c.memBytes()
 	} else {
-		return 0
+		return c.memBytes()
 	}
 }
 
 // RawSize return current size plus current writing file(`data') of the cache.
 func (c *DiskCache) RawSize() int64 {
-	return c.size.Load()
+	if len(c.lanes) > 0 {
+		var n int64
+		for _, l := range c.lanes {
+			n += l.RawSize()
+		}
+		return n
+	}
+
+	return c.size.Load() + c.memBytes()
+}
+
+// memBytes return bytes within memory buffer.
+func (c *DiskCache) memBytes() int64 {
+	if c.memCapacity <= 0 {
+		return 0
+	}
+
+	c.memMu.Lock()
+	defer c.memMu.Unlock()
+
+	return c.memSize
 }
 
 // Capacity return max capacity of the cache.
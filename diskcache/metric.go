@@ -15,6 +15,10 @@ var (
 	removeVec,
 	wakeupVec,
 	posUpdatedVec,
+	decryptErrorVec,
+	leaseExpiredVec,
+	memHitVec,
+	memSpillVec,
 	seekBackVec *prometheus.CounterVec
 
 	sizeVec,
@@ -23,12 +27,16 @@ var (
 	capVec,
 	maxDataVec,
 	batchSizeVec,
+	consumerLagVec,
+	archiveSizeVec,
+	memSizeVec,
 	datafilesVec *prometheus.GaugeVec
 
 	droppedDataVec,
 	putBytesVec,
 	getBytesVec,
 	getLatencyVec,
+	groupCommitVec,
 	putLatencyVec *prometheus.SummaryVec
 
 	ns = "diskcache"
@@ -63,6 +71,20 @@ func setupMetrics() {
 		[]string{"path"},
 	)
 
+	groupCommitVec = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Namespace: ns,
+			Name:      "group_commit_puts",
+			Help:      "Put() count synced within a single group-commit fsync",
+			Objectives: map[float64]float64{
+				0.5:  0.05,
+				0.9:  0.01,
+				0.99: 0.001,
+			},
+		},
+		[]string{"path"},
+	)
+
 	putBytesVec = prometheus.NewSummaryVec(
 		prometheus.SummaryOpts{
 			Namespace: ns,
@@ -150,6 +172,24 @@ func setupMetrics() {
 		[]string{"path"},
 	)
 
+	leaseExpiredVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: ns,
+			Name:      "lease_expired_total",
+			Help:      "Peek() leases rolled back on lease timeout",
+		},
+		[]string{"path"},
+	)
+
+	decryptErrorVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: ns,
+			Name:      "decrypt_error_total",
+			Help:      "Get() failed on decrypt data, key_id is unknown if the key not configured",
+		},
+		[]string{"path", "key_id"},
+	)
+
 	capVec = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: ns,
@@ -220,6 +260,51 @@ func setupMetrics() {
 		[]string{"path"},
 	)
 
+	consumerLagVec = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "consumer_lag",
+			Help:      "Bytes not consumed by named consumer",
+		},
+		[]string{"path", "consumer"},
+	)
+
+	memSizeVec = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "memory_size",
+			Help:      "Bytes within memory buffer",
+		},
+		[]string{"path"},
+	)
+
+	memHitVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: ns,
+			Name:      "memory_hit_total",
+			Help:      "Data Get() from memory buffer",
+		},
+		[]string{"path"},
+	)
+
+	memSpillVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: ns,
+			Name:      "memory_spill_total",
+			Help:      "Data spilled from memory buffer to disk",
+		},
+		[]string{"path"},
+	)
+
+	archiveSizeVec = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "archive_size",
+			Help:      "Bytes of consumed data files kept in archive",
+		},
+		[]string{"path"},
+	)
+
 	metrics.MustRegister(Metrics()...)
 }
 
@@ -230,13 +315,21 @@ func ResetMetrics() {
 	wakeupVec.Reset()
 	posUpdatedVec.Reset()
 	seekBackVec.Reset()
+	decryptErrorVec.Reset()
+	leaseExpiredVec.Reset()
 	capVec.Reset()
 	batchSizeVec.Reset()
 	maxDataVec.Reset()
 	sizeVec.Reset()
 	datafilesVec.Reset()
+	consumerLagVec.Reset()
+	archiveSizeVec.Reset()
+	memSizeVec.Reset()
+	memHitVec.Reset()
+	memSpillVec.Reset()
 	getLatencyVec.Reset()
 	putLatencyVec.Reset()
+	groupCommitVec.Reset()
 	putBytesVec.Reset()
 	getBytesVec.Reset()
 }
@@ -249,6 +342,8 @@ func Metrics() []prometheus.Collector {
 		wakeupVec,
 		posUpdatedVec,
 		seekBackVec,
+		decryptErrorVec,
+		leaseExpiredVec,
 
 		sizeVec,
 		openTimeVec,
@@ -257,9 +352,15 @@ func Metrics() []prometheus.Collector {
 		maxDataVec,
 		batchSizeVec,
 		datafilesVec,
+		consumerLagVec,
+		archiveSizeVec,
+		memSizeVec,
+		memHitVec,
+		memSpillVec,
 
 		getLatencyVec,
 		putLatencyVec,
+		groupCommitVec,
 		getBytesVec,
 		putBytesVec,
 	}
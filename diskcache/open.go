@@ -6,11 +6,13 @@
 package diskcache
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -55,20 +57,60 @@ func defaultInstance() *DiskCache {
 		maxDataSize: 0, // not set
 
 		wlock:  &sync.Mutex{},
-		rlock:  &sync.Mutex{},
 		rwlock: &sync.Mutex{},
 
-		wakeup:    time.Second * 3,
-		dirPerms:  0o750,
-		filePerms: 0o640,
-		pos: &pos{
-			Seek: 0,
-			Name: nil,
-		},
+		wakeup:       time.Second * 3,
+		waitRotate:   100 * time.Millisecond,
+		leaseTimeout: time.Minute,
+		dirPerms:     0o750,
+		filePerms:    0o640,
+
+		reader:        newReader(""),
+		dataFileSizes: map[string]int64{},
 	}
 }
 
-func (c *DiskCache) doOpen() error {
+// checkOptions check invalid or conflicted options. It's called before
+// the .lock acquired, so there is nothing to release on error.
+func (c *DiskCache) checkOptions() error {
+	if c.optErr != nil {
+		return c.optErr
+	}
+
+	for _, name := range c.consumerNames {
+		if !consumerNameRe.MatchString(name) {
+			return fmt.Errorf("invalid consumer name %q", name)
+		}
+	}
+
+	if c.memCapacity > 0 && (len(c.consumerNames) > 0 || c.laneCount > 1 || c.shardCount > 1) {
+		return fmt.Errorf("memory buffer not work with named consumers, priority lanes or shards")
+	}
+
+	if c.laneCount > 1 || c.shardCount > 1 {
+		if len(c.consumerNames) > 0 {
+			return fmt.Errorf("priority lanes or shards not work with named consumers")
+		}
+
+		if c.shardCount > 1 && c.laneCount > 1 {
+			return fmt.Errorf("priority lanes not work with shards")
+		}
+	}
+
+	if c.compress == CompressZstd {
+		if err := setupZstd(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *DiskCache) doOpen() (err error) {
+	if err := c.checkOptions(); err != nil {
+		return err
+	}
+
 	if c.dirPerms == 0 {
 		c.dirPerms = 0o755
 	}
@@ -100,18 +142,46 @@ func (c *DiskCache) doOpen() error {
 		}
 	}
 
+	defer func() {
+		if err != nil { // release the .lock and opened files
+			c.Close() //nolint:errcheck,gosec
+		}
+	}()
+
+	// setup readers
+	if len(c.consumerNames) == 0 {
+		c.readers = []*reader{c.reader}
+	} else {
+		for _, name := range c.consumerNames {
+			c.readers = append(c.readers, newReader(name))
+		}
+	}
+
 	if !c.noPos {
 		// use `.pos' file to remember the reading position.
-		c.pos.fname = filepath.Join(c.path, ".pos")
+		c.pos.fname = c.reader.posFile(c.path)
+		for _, r := range c.readers {
+			r.pos.fname = r.posFile(c.path)
+		}
 	}
 	c.curWriteFile = filepath.Join(c.path, "data")
 
 	c.syncEnv()
 
 	// set stable metrics
-	capVec.WithLabelValues(c.path).Set(float64(c.capacity))
-	maxDataVec.WithLabelValues(c.path).Set(float64(c.maxDataSize))
-	batchSizeVec.WithLabelValues(c.path).Set(float64(c.batchSize))
+	if !c.isShard() {
+		capVec.WithLabelValues(c.metricPath()).Set(float64(c.capacity))
+		maxDataVec.WithLabelValues(c.metricPath()).Set(float64(c.maxDataSize))
+		batchSizeVec.WithLabelValues(c.metricPath()).Set(float64(c.batchSize))
+	}
+
+	if c.shardCount > 1 { // all data are within shards
+		return c.openLanes("shard", c.shardCount)
+	}
+
+	if c.laneCount > 1 { // all data are within lanes
+		return c.openLanes("lane", c.laneCount)
+	}
 
 	// write append fd, always write to the same-name file
 	if err := c.openWriteFile(); err != nil {
@@ -126,6 +196,13 @@ func (c *DiskCache) doOpen() error {
 			}
 
 			if fi.IsDir() {
+				if path != c.path { // such as directories of lanes
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if strings.HasPrefix(filepath.Base(path), ".pos.") { // pos files of named consumers
 				return nil
 			}
 
@@ -138,8 +215,9 @@ func (c *DiskCache) doOpen() error {
 				// waiting to be Get().
 			default:
 				c.size.Add(fi.Size())
-				sizeVec.WithLabelValues(c.path).Add(float64(fi.Size()))
+				sizeVec.WithLabelValues(c.metricPath()).Add(float64(fi.Size()))
 				c.dataFiles = append(c.dataFiles, path)
+				c.dataFileSizes[path] = fi.Size()
 			}
 
 			return nil
@@ -149,13 +227,31 @@ func (c *DiskCache) doOpen() error {
 
 	sort.Strings(c.dataFiles) // make file-name sorted for FIFO Get()
 
+	if c.archive {
+		if err := c.loadArchive(); err != nil {
+			return err
+		}
+	}
+
+	if err := c.dropExpiredFiles(); err != nil {
+		return err
+	}
+
 	// first get, try load .pos
 	if !c.noPos {
-		if err := c.loadUnfinishedFile(); err != nil {
-			return err
+		for _, r := range c.readers {
+			if err := c.loadUnfinishedFile(r); err != nil {
+				return err
+			}
 		}
 	}
 
+	c.updateLag()
+
+	if c.maxAge > 0 {
+		c.startSweeper()
+	}
+
 	return nil
 }
 
@@ -163,18 +259,41 @@ func (c *DiskCache) doOpen() error {
 // Close is safe to call concurrently with other operations and will
 // block until all other operations finish.
 func (c *DiskCache) Close() error {
+	if c.memCapacity > 0 { // data within memory buffer should not lost
+		if err := c.flushMem(); err != nil {
+			return err
+		}
+	}
+
+	c.stopSweeper()
+
 	c.rwlock.Lock()
 	defer c.rwlock.Unlock()
 
 	defer func() {
-		lastCloseTimeVec.WithLabelValues(c.path).Set(float64(time.Now().Unix()))
+		lastCloseTimeVec.WithLabelValues(c.metricPath()).Set(float64(time.Now().Unix()))
 	}()
 
-	if c.rfd != nil {
-		if err := c.rfd.Close(); err != nil {
+	c.closed.Store(true)
+	defer c.notifyData() // wakeup GetContext() waiting
+
+	for _, l := range c.lanes {
+		if err := l.Close(); err != nil {
 			return err
 		}
-		c.rfd = nil
+	}
+
+	for _, r := range c.readers {
+		fname := r.curReadfile
+		if err := r.close(); err != nil {
+			return err
+		}
+
+		if r.dropped.Load() { // dropped file still reading, remove it
+			if err := os.Remove(fname); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
 	}
 
 	if !c.noLock {
@@ -185,6 +304,10 @@ func (c *DiskCache) Close() error {
 		}
 	}
 
+	if err := c.flushSyncBatch(); err != nil {
+		return err
+	}
+
 	if c.wfd != nil {
 		if err := c.wfd.Close(); err != nil {
 			return err
@@ -192,8 +315,8 @@ func (c *DiskCache) Close() error {
 		c.wfd = nil
 	}
 
-	if c.pos != nil {
-		if err := c.pos.close(); err != nil {
+	if c.reader != nil { // the default reader may not in readers
+		if err := c.reader.close(); err != nil {
 			return err
 		}
 	}
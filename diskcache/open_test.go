@@ -92,6 +92,20 @@ func TestOpen(t *T.T) {
 		})
 	})
 
+	t.Run("unlock-on-open-error", func(t *T.T) {
+		p := t.TempDir()
+
+		// write file can't be opened after locked
+		assert.NoError(t, os.Mkdir(filepath.Join(p, "data"), 0o750))
+		_, err := Open(WithPath(p))
+		assert.Error(t, err)
+
+		assert.NoError(t, os.Remove(filepath.Join(p, "data")))
+		c, err := Open(WithPath(p))
+		assert.NoError(t, err)
+		assert.NoError(t, c.Close())
+	})
+
 	t.Run("multi-open-until-ok", func(t *T.T) {
 		p := t.TempDir()
 		c, err := Open(WithPath(p))
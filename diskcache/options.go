@@ -6,6 +6,7 @@
 package diskcache
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"time"
@@ -14,6 +15,13 @@ import (
 // A CacheOption used to set various options on DiskCache.
 type CacheOption func(c *DiskCache)
 
+// setOptErr remember the first invalid option, Open() fail on it.
+func (c *DiskCache) setOptErr(err error) {
+	if c.optErr == nil {
+		c.optErr = err
+	}
+}
+
 // WithNoFallbackOnError disable fallback on fn() error.
 //
 // During Get(fn(data []btye)error{...}), if fn() failed with error,
@@ -80,6 +88,95 @@ func WithWakeup(wakeup time.Duration) CacheOption {
 	}
 }
 
+// WithWaitRotate set how long(default 100ms) the write file kept before
+// rotated for GetContext() waiting on it. A larger value make less(and
+// larger) data files on slow writing, but GetContext() may wait longer.
+func WithWaitRotate(d time.Duration) CacheOption {
+	return func(c *DiskCache) {
+		if int64(d) > 0 {
+			c.waitRotate = d
+		}
+	}
+}
+
+// WithLeaseTimeout set how long(default 1min) records returned by Peek() can be
+// kept uncommitted, they are rolled back on timeout.
+func WithLeaseTimeout(d time.Duration) CacheOption {
+	return func(c *DiskCache) {
+		if int64(d) > 0 {
+			c.leaseTimeout = d
+		}
+	}
+}
+
+// WithMaxAge set max age of cached data, data files older than d are
+// dropped(on Get(), rotate and by a background sweeper) even if the cache
+// not full. The age of data file based on it's newest data.
+func WithMaxAge(d time.Duration) CacheOption {
+	return func(c *DiskCache) {
+		if int64(d) > 0 {
+			c.maxAge = d
+		}
+	}
+}
+
+// WithPriorityLanes split the cache into n(at most 256) priority lanes, data put
+// by PutPriority() with higher priority are Get() first, and on cache full,
+// data of lower priority are dropped first. Put() write to the lowest lane(0).
+//
+// Each lane is a sub-cache under directory lane.<priority>, all lanes share the
+// capacity of the cache. Priority lanes not work with WithConsumers().
+func WithPriorityLanes(n int) CacheOption {
+	return func(c *DiskCache) {
+		if n > 1 && n <= maxLanes {
+			c.laneCount = n
+		}
+	}
+}
+
+// WithMemoryBuffer put data into a memory buffer of capacity bytes in front
+// of data files. Get() read data files first, then the memory buffer, so if
+// the consumer keeps up, data never written to disk. The buffer spilled to
+// disk if it's full, or on Close().
+//
+// Data within the buffer are lost if the process crashed. Peek() spill the
+// buffer if no data on disk, and the buffer not work with WithConsumers(),
+// WithPriorityLanes() and WithShards().
+func WithMemoryBuffer(capacity int64) CacheOption {
+	return func(c *DiskCache) {
+		if capacity > 0 {
+			c.memCapacity = capacity
+		}
+	}
+}
+
+// WithShards split the cache into n(at most 256) shards, so concurrent Put()
+// on different shards do not block each other. Put() write to shards in
+// round-robin, and PutKey() write to the shard selected by key, so data of the
+// same key keep their order. Get() read shards in turn.
+//
+// Each shard is a sub-cache under directory shard.<i>, all shards share the
+// capacity and metric labels of the cache. Shards not work with WithConsumers()
+// and WithPriorityLanes().
+func WithShards(n int) CacheOption {
+	return func(c *DiskCache) {
+		if n > 1 && n <= maxLanes {
+			c.shardCount = n
+		}
+	}
+}
+
+// WithArchive keep consumed data files under directory archive instead of
+// removing them, so they can be read again by Rewind()/SeekTo(). The oldest
+// archived files are removed if archive size exceed capacity(no limit if
+// capacity <= 0) or they are older than max-age(see WithMaxAge()).
+func WithArchive(capacity int64) CacheOption {
+	return func(c *DiskCache) {
+		c.archive = true
+		c.archiveCapacity = capacity
+	}
+}
+
 // WithBatchSize set file size, default 64MB.
 func WithBatchSize(size int64) CacheOption {
 	return func(c *DiskCache) {
@@ -113,7 +210,7 @@ func WithExtraCapacity(size int64) CacheOption {
 		if c.capacity+size > 0 {
 			c.capacity += size
 			if c.path != "" {
-				capVec.WithLabelValues(c.path).Set(float64(c.capacity))
+				capVec.WithLabelValues(c.metricPath()).Set(float64(c.capacity))
 			}
 		}
 	}
@@ -129,6 +226,118 @@ func WithNoSync(on bool) CacheOption {
 	}
 }
 
+// WithGroupCommit enable group-commit on Put(): concurrent Put() are
+// batched to share a single fsync, and each Put() still return after it's
+// data synced to disk.
+//
+// Put() arrived during the previous fsync are always batched together, and
+// the batch wait at most maxDelay for more Put(), or until it's bytes reached
+// maxBytes(default 1MB). maxDelay 0 means do not wait, which is fine for most
+// cases.
+//
+// Group-commit do not work if WithNoSync() enabled.
+func WithGroupCommit(maxDelay time.Duration, maxBytes int64) CacheOption {
+	return func(c *DiskCache) {
+		if maxDelay < 0 {
+			maxDelay = 0
+		}
+
+		if maxBytes <= 0 {
+			maxBytes = defaultGroupCommitBytes
+		}
+
+		c.groupCommit = true
+		c.gcMaxDelay = maxDelay
+		c.gcMaxBytes = maxBytes
+	}
+}
+
+// WithChecksum enable/disable CRC32C checksum on each Put() data.
+//
+// With checksum enabled, a corrupted data(such as torn write or bit-flip) will be
+// detected during Get(), and only the corrupted data dropped, Get() continue on next
+// valid data within the same data file. Data files without checksum still readable.
+func WithChecksum(on bool) CacheOption {
+	return func(c *DiskCache) {
+		c.checksum = on
+	}
+}
+
+// WithCompression set compression algorithm on Put() data, default no compression.
+//
+// The algorithm recorded within each data, so changing the algorithm on an
+// existing cache is ok, all cached data still readable. Cache size and capacity
+// are based on compressed(on-disk) bytes.
+func WithCompression(algo CompressAlgo) CacheOption {
+	return func(c *DiskCache) {
+		if algo <= maxCompressAlgo {
+			c.compress = algo
+		}
+	}
+}
+
+// WithEncryption enable AES-GCM encryption on Put() data with key.
+//
+// The key ID tagged within each encrypted data, during Get(), if the data
+// encrypted by a unknown key ID, the data dropped(see the dropped data metric
+// with reason unknown-encryption-key). If the data can't be decrypted with the
+// key of the same ID, the data is broken and dropped, so do not reuse key ID on
+// different keys.
+//
+// To rotate the key, set the new key here, and set old keys with WithDecryptionKeys(),
+// so data encrypted by old keys still readable.
+//
+// Invalid key(empty key or key ID longer than 255 bytes) fail the Open().
+func WithEncryption(key *EncryptionKey) CacheOption {
+	return func(c *DiskCache) {
+		if key == nil {
+			return
+		}
+
+		k, err := newAEADKey(key)
+		if err != nil {
+			c.setOptErr(fmt.Errorf("WithEncryption: %w", err))
+			return
+		}
+
+		c.encKey = k
+		c.addDecryptionKey(k)
+	}
+}
+
+// WithDecryptionKeys set extra keys to decrypt cached data, these keys not used on Put().
+func WithDecryptionKeys(keys ...*EncryptionKey) CacheOption {
+	return func(c *DiskCache) {
+		for _, key := range keys {
+			if key == nil {
+				continue
+			}
+
+			k, err := newAEADKey(key)
+			if err != nil {
+				c.setOptErr(fmt.Errorf("WithDecryptionKeys: %w", err))
+				continue
+			}
+
+			c.addDecryptionKey(k)
+		}
+	}
+}
+
+// WithConsumers set named consumers on the cache.
+//
+// Each consumer has it's own read position and read all cached data, a data file
+// removed only after all consumers read through it. With named consumers set,
+// Get() on DiskCache not allowed, use Consumer(name).Get() instead.
+//
+// NOTE: consumer names should be the same among cache re-Open(), or data
+// files may be removed before some consumer read them.
+func WithConsumers(names ...string) CacheOption {
+	return func(c *DiskCache) {
+		c.consumerNames = append(c.consumerNames, names...)
+	}
+}
+
 // WithDirPermission set disk dir permission mode.
 func WithDirPermission(perms os.FileMode) CacheOption {
 	return func(c *DiskCache) {
@@ -9,75 +9,184 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"time"
 )
 
 // IsFull test if reach max capacity limit after put newData into cache.
 func (c *DiskCache) IsFull(newData []byte) bool {
-	return c.capacity > 0 && c.size.Load()+int64(len(newData)) > c.capacity
+	return c.isFull(int64(len(newData)))
+}
+
+func (c *DiskCache) isFull(n int64) bool {
+	if c.parent != nil { // lanes(and shards) share the capacity of parent
+		return c.parent.lanesFull(n)
+	}
+
+	if len(c.lanes) > 0 {
+		return c.lanesFull(n)
+	}
+
+	return c.capacity > 0 && c.size.Load()+n > c.capacity
 }
 
 // Put write @data to disk cache, if reached batch size, a new batch is rotated.
+// With priority lanes, data put to the lowest lane, and with shards, data put
+// to shards in round-robin.
 // Put is safe to call concurrently with other operations and will
 // block until all other operations finish.
 func (c *DiskCache) Put(data []byte) error {
-	start := time.Now() // count time before lock
+	if len(c.lanes) > 0 {
+		return c.putLane().Put(data)
+	}
 
-	c.wlock.Lock()
-	defer c.wlock.Unlock()
+	start := time.Now() // count time before lock
 
 	defer func() {
-		putLatencyVec.WithLabelValues(c.path).Observe(time.Since(start).Seconds())
+		putLatencyVec.WithLabelValues(c.metricPath()).Observe(time.Since(start).Seconds())
 	}()
 
-	if c.IsFull(data) {
-		if c.noDrop {
-			return ErrCacheFull
-		}
+	if c.memCapacity > 0 {
+		return c.memPut(data)
+	}
 
-		if c.filoDrop { // do not accept new data
-			droppedDataVec.WithLabelValues(c.path, reasonExceedCapacity).Observe(float64(len(data)))
-			return ErrCacheFull
-		}
+	return c.putDisk(data)
+}
 
-		if err := c.dropBatch(); err != nil {
-			return err
+// putDisk write data to disk and wait it durable.
+func (c *DiskCache) putDisk(data []byte) error {
+	b, leader, err := c.doPut(data)
+	if b != nil { // wait even on error, we may be the leader of other Put()
+		if serr := c.waitSyncBatch(b, leader); err == nil {
+			err = serr
 		}
 	}
 
+	return err
+}
+
+// doPut write data to current write file, with group-commit enabled, the
+// sync batch returned for waiting the data to be durable.
+func (c *DiskCache) doPut(data []byte) (b *syncBatch, leader bool, err error) {
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+
 	if c.maxDataSize > 0 && int32(len(data)) > c.maxDataSize {
-		return ErrTooLargeData
+		return nil, false, ErrTooLargeData
 	}
 
-	hdr := make([]byte, dataHeaderLen)
+	// encode data before capacity checking, the cache size are based on on-disk bytes.
+	rec, err := c.encodeRecord(data)
+	if err != nil {
+		return nil, false, err
+	}
 
-	binary.LittleEndian.PutUint32(hdr, uint32(len(data)))
-	if _, err := c.wfd.Write(hdr); err != nil {
-		return err
+	if err := c.makeRoom(int64(len(rec))); err != nil {
+		return nil, false, err
 	}
 
-	if _, err := c.wfd.Write(data); err != nil {
-		return err
+	if _, err := c.wfd.Write(rec); err != nil {
+		return nil, false, err
 	}
 
 	if !c.noSync {
-		if err := c.wfd.Sync(); err != nil {
-			return err
+		if c.groupCommit {
+			b, leader = c.joinSyncBatch(int64(len(rec)))
+		} else if err := c.wfd.Sync(); err != nil {
+			return nil, false, err
 		}
 	}
 
-	c.curBatchSize += int64(len(data) + dataHeaderLen)
+	c.curBatchSize += int64(len(rec))
 	c.wfdLastWrite = time.Now()
+	c.notifyData()
 
 	// rotate new file
 	if c.curBatchSize >= c.batchSize {
 		if err := c.rotate(); err != nil {
-			return err
+			return b, leader, err
 		}
 	}
 
-	return nil
+	return b, leader, nil
+}
+
+// makeRoom drop old data if the cache is full for n bytes. The caller should
+// hold the wlock.
+func (c *DiskCache) makeRoom(n int64) error {
+	if !c.isFull(n) {
+		return nil
+	}
+
+	if c.noDrop {
+		return ErrCacheFull
+	}
+
+	if c.filoDrop { // do not accept new data
+		droppedDataVec.WithLabelValues(c.metricPath(), reasonExceedCapacity).Observe(float64(n))
+		return ErrCacheFull
+	}
+
+	return c.dropBatch()
+}
+
+// putDiskBatch write all data within batch to disk, and sync only once(plus
+// once on each rotate) for all of them. It return how many data written.
+func (c *DiskCache) putDiskBatch(batch [][]byte) (int, error) {
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+
+	dirty := false
+	sync := func() error {
+		if c.noSync || !dirty {
+			return nil
+		}
+
+		dirty = false
+		return c.wfd.Sync()
+	}
+
+	for i, data := range batch {
+		if c.maxDataSize > 0 && int32(len(data)) > c.maxDataSize {
+			return i, errors.Join(ErrTooLargeData, sync())
+		}
+
+		rec, err := c.encodeRecord(data)
+		if err != nil {
+			return i, errors.Join(err, sync())
+		}
+
+		if err := c.makeRoom(int64(len(rec))); err != nil {
+			return i, errors.Join(err, sync())
+		}
+
+		if _, err := c.wfd.Write(rec); err != nil {
+			return i, errors.Join(err, sync())
+		}
+
+		dirty = true
+		c.curBatchSize += int64(len(rec))
+		c.wfdLastWrite = time.Now()
+
+		// rotate new file
+		if c.curBatchSize >= c.batchSize {
+			if err := sync(); err != nil {
+				return i + 1, err
+			}
+
+			if err := c.rotate(); err != nil {
+				return i + 1, err
+			}
+		}
+	}
+
+	if err := sync(); err != nil {
+		return len(batch), err
+	}
+
+	c.notifyData()
+	return len(batch), nil
 }
 
 // StreamPut read from r for bytes and write to storage.
@@ -86,9 +195,8 @@ func (c *DiskCache) Put(data []byte) error {
 // we can use StreamPut to avoid a intermediate buffer to accept the huge(may be) body.
 func (c *DiskCache) StreamPut(r io.Reader, size int) error {
 	var (
-		//nolint:ineffassign
-		total       int64
 		err         error
+		written     bool // any bytes written to wfd
 		startOffset int64
 		start       = time.Now()
 	)
@@ -97,13 +205,22 @@ func (c *DiskCache) StreamPut(r io.Reader, size int) error {
 		return ErrInvalidStreamSize
 	}
 
-	c.wlock.Lock()
-	defer c.wlock.Unlock()
+	if len(c.lanes) > 0 {
+		return c.putLane().StreamPut(r, size)
+	}
 
-	if c.capacity > 0 && c.size.Load()+int64(size) > c.capacity {
-		return ErrCacheFull
+	if c.memCapacity > 0 { // data within memory buffer are older than the stream
+		c.memMu.Lock()
+		defer c.memMu.Unlock()
+
+		if err := c.spillMem(); err != nil {
+			return err
+		}
 	}
 
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+
 	if c.maxDataSize > 0 && size > int(c.maxDataSize) {
 		return ErrTooLargeData
 	}
@@ -113,32 +230,113 @@ func (c *DiskCache) StreamPut(r io.Reader, size int) error {
 	}
 
 	defer func() {
-		if total > 0 && err != nil { // fallback to origin position
+		if written && err != nil { // drop the torn record and fallback to origin position
+			if terr := c.wfd.Truncate(startOffset); terr != nil {
+				c.LastErr = terr
+			}
+
 			if _, serr := c.wfd.Seek(startOffset, io.SeekStart); serr != nil {
 				c.LastErr = serr
 			}
 		}
 
-		putLatencyVec.WithLabelValues(c.path).Observe(time.Since(start).Seconds())
+		putLatencyVec.WithLabelValues(c.metricPath()).Observe(time.Since(start).Seconds())
 	}()
 
-	if size > 0 {
+	var n int64 // record size on disk
+
+	switch {
+	case c.compress != NoCompress || c.encKey != nil:
+		// compression/encryption need the whole data, so the stream buffered here.
+		data := make([]byte, size)
+		if _, err = io.ReadFull(r, data); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+
+		var rec []byte
+		if rec, err = c.encodeRecord(data); err != nil {
+			return err
+		}
+
+		n = int64(len(rec))
+		if c.isFull(n) {
+			return ErrCacheFull
+		}
+
+		written = true
+		if _, err = c.wfd.Write(rec); err != nil {
+			return err
+		}
+
+	case c.extRecord():
+		if size >= maxExtRecordSize {
+			return ErrTooLargeData
+		}
+
+		n = int64(dataHeaderLen + recordFlagsLen + size + recordCRCLen)
+		if c.isFull(n) {
+			return ErrCacheFull
+		}
+
+		// the crc trailer calculated during copy, so we do not need to buffer the stream.
+		hdr := make([]byte, dataHeaderLen+recordFlagsLen)
+		binary.LittleEndian.PutUint32(hdr, uint32(size)|extRecordFlag)
+		hdr[dataHeaderLen] = c.recordFlags(NoCompress)
+
+		written = true
+		if _, err = c.wfd.Write(hdr); err != nil {
+			return err
+		}
+
+		crc := crc32.New(castagnoli)
+		crc.Write(hdr) //nolint:errcheck,gosec
+
+		if _, err = io.CopyN(io.MultiWriter(c.wfd, crc), r, int64(size)); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+
+		tail := make([]byte, recordCRCLen)
+		binary.LittleEndian.PutUint32(tail, crc.Sum32())
+		if _, err = c.wfd.Write(tail); err != nil {
+			return err
+		}
+
+	default:
+		n = int64(dataHeaderLen + size)
+		if c.isFull(n) {
+			return ErrCacheFull
+		}
+
 		binary.LittleEndian.PutUint32(c.batchHeader, uint32(size))
-		if _, err := c.wfd.Write(c.batchHeader); err != nil {
+
+		written = true
+		if _, err = c.wfd.Write(c.batchHeader); err != nil {
 			return err
 		}
-	}
 
-	total, err = io.CopyN(c.wfd, r, int64(size))
-	if err != nil && !errors.Is(err, io.EOF) {
-		return err
+		if _, err = io.CopyN(c.wfd, r, int64(size)); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
 	}
 
-	c.curBatchSize += (total + dataHeaderLen)
+	// the record is complete, only account it here
+	c.curBatchSize += n
+	c.wfdLastWrite = time.Now()
+	c.notifyData()
 
 	if c.curBatchSize >= c.batchSize {
-		if err := c.rotate(); err != nil {
-			return err
+		// do not assign to err: the record already written should be kept
+		if rerr := c.rotate(); rerr != nil {
+			return rerr
 		}
 	}
 
@@ -7,6 +7,7 @@ package diskcache
 
 import (
 	"errors"
+	"io"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -115,6 +116,147 @@ func BenchmarkPutGet(b *T.B) {
 	})
 }
 
+func BenchmarkGroupCommitPut(b *T.B) {
+	_1kb := make([]byte, 1024)
+
+	cases := []struct {
+		name string
+		opts []CacheOption
+	}{
+		{
+			name: "sync-per-put",
+		},
+		{
+			name: "group-commit",
+			opts: []CacheOption{WithGroupCommit(0, 0)},
+		},
+		{
+			name: "group-commit-1ms-delay",
+			opts: []CacheOption{WithGroupCommit(time.Millisecond, 0)},
+		},
+		{
+			name: "nosync",
+			opts: []CacheOption{WithNoSync(true)},
+		},
+	}
+
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *T.B) {
+			c, err := Open(append([]CacheOption{
+				WithPath(b.TempDir()),
+				WithBatchSize(1024 * 1024 * 4),
+				WithCapacity(4 * 1024 * 1024 * 1024),
+			}, tc.opts...)...)
+			require.NoError(b, err)
+
+			b.SetParallelism(16) // there should be enough concurrent Put() to group
+			b.SetBytes(int64(len(_1kb)))
+			b.ResetTimer()
+
+			b.RunParallel(func(pb *T.PB) {
+				for pb.Next() {
+					if err := c.Put(_1kb); err != nil {
+						b.Error(err)
+					}
+				}
+			})
+
+			b.StopTimer()
+			assert.NoError(b, c.Close())
+			ResetMetrics()
+		})
+	}
+}
+
+func TestGroupCommit(t *T.T) {
+	t.Run(`concurrent-put`, func(t *T.T) {
+		ResetMetrics()
+
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(Metrics()...)
+
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithGroupCommit(10*time.Millisecond, 0))
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		wg.Add(8)
+		for i := 0; i < 8; i++ {
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 10; j++ {
+					assert.NoError(t, c.Put([]byte("hello")))
+				}
+			}()
+		}
+		wg.Wait()
+
+		require.NoError(t, c.Rotate())
+		assert.Len(t, getAll(t, c), 80)
+
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+
+		m := metrics.GetMetricOnLabels(mfs, "diskcache_group_commit_puts", c.path)
+		require.NotNil(t, m)
+		assert.Equal(t, 80.0, m.GetSummary().GetSampleSum())
+		assert.Less(t, m.GetSummary().GetSampleCount(), uint64(80)) // fsync shared
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`max-bytes`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithGroupCommit(time.Hour, 10))
+		require.NoError(t, err)
+
+		done := make(chan error)
+		go func() { done <- c.Put([]byte("reach max bytes")) }()
+
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(10 * time.Second):
+			assert.Fail(t, "Put() not returned on max bytes")
+		}
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`flush-on-rotate-and-close`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithGroupCommit(time.Hour, 0))
+		require.NoError(t, err)
+
+		done := make(chan error, 2)
+		go func() { done <- c.Put([]byte("flushed on rotate")) }()
+
+		time.Sleep(100 * time.Millisecond) // wait Put() to join the batch
+		require.NoError(t, c.Rotate())
+
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(10 * time.Second):
+			assert.Fail(t, "Put() not returned on Rotate()")
+		}
+
+		go func() { done <- c.Put([]byte("flushed on close")) }()
+
+		time.Sleep(100 * time.Millisecond)
+		assert.NoError(t, c.Close())
+
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(10 * time.Second):
+			assert.Fail(t, "Put() not returned on Close()")
+		}
+	})
+}
+
 func TestConcurrentPutGet(t *T.T) {
 	var (
 		mb     = int64(1 << 20)
@@ -483,4 +625,59 @@ func TestStreamPut(t *T.T) {
 			ResetMetrics()
 		})
 	})
+
+	t.Run(`short-stream`, func(t *T.T) {
+		for name, opts := range map[string][]CacheOption{
+			"plain":    nil,
+			"checksum": {WithChecksum(true)},
+			"zstd":     {WithCompression(CompressZstd)},
+		} {
+			t.Run(name, func(t *T.T) {
+				p := t.TempDir()
+				c, err := Open(append(opts, WithPath(p))...)
+				require.NoError(t, err)
+
+				t.Cleanup(func() {
+					assert.NoError(t, c.Close())
+					ResetMetrics()
+				})
+
+				// stream shorter than the declared size
+				err = c.StreamPut(strings.NewReader("01234"), 10)
+				require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+				assert.Equal(t, int64(0), c.curBatchSize)
+
+				fi, err := c.wfd.Stat()
+				require.NoError(t, err)
+				assert.Equal(t, int64(0), fi.Size()) // no torn record left
+
+				raw := "0123456789"
+				require.NoError(t, c.StreamPut(strings.NewReader(raw), len(raw)))
+				require.NoError(t, c.Rotate())
+
+				assert.NoError(t, c.Get(func(data []byte) error {
+					assert.Equal(t, []byte(raw), data)
+					return nil
+				}))
+			})
+		}
+	})
+
+	t.Run(`full-on-encoded-size`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithChecksum(true))
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+
+		raw := "0123456789"
+
+		// capacity can hold the raw data, but not the record with header and crc
+		c.capacity = int64(len(raw) + dataHeaderLen)
+		assert.ErrorIs(t, c.StreamPut(strings.NewReader(raw), len(raw)), ErrCacheFull)
+		assert.Equal(t, int64(0), c.curBatchSize)
+	})
 }
@@ -0,0 +1,307 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+// Record layout within data file.
+//
+// Legacy record(always readable):
+//
+//	| len(4B) | payload(len bytes) |
+//
+// Extended record(the highest bit of len is set):
+//
+//	| len(4B) | flags(1B) | payload(len bytes) | crc32c(4B) |
+//
+// The CRC32C(Castagnoli) checksum is placed after the payload, so StreamPut()
+// can calculate it without buffering the whole stream. The checksum covers
+// the len, flags and payload bytes.
+//
+// The len of extended record is the on-disk payload size. For compressed
+// payload, the compression algorithm recorded in flags, and the payload
+// prefixed with 4 bytes raw data length. For encrypted payload, the
+// compressed(if enabled) payload encrypted and prefixed with the key ID.
+const (
+	extRecordFlag  = uint32(1 << 31)
+	recordFlagsLen = 1
+	recordCRCLen   = 4
+
+	// Max payload size of extended record, so len(with extRecordFlag set)
+	// never equal to EOFHint.
+	maxExtRecordSize = 1 << 30
+)
+
+// Flags of extended record.
+const (
+	flagChecksum uint8 = 1 << iota // CRC32C checksum available
+
+	// all known bits of flags, see flagCodecMask and flagEncrypted.
+	recordFlagsMask = flagChecksum | flagCodecMask | flagEncrypted
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// extRecord test if we should write data in extended record format.
+func (c *DiskCache) extRecord() bool {
+	return c.checksum || c.compress != NoCompress || c.encKey != nil
+}
+
+// recordFlags build the flags of extended record.
+func (c *DiskCache) recordFlags(codec CompressAlgo) uint8 {
+	var flags uint8
+	if c.checksum {
+		flags |= flagChecksum
+	}
+
+	flags |= uint8(codec) << flagCodecShift
+
+	if c.encKey != nil {
+		flags |= flagEncrypted
+	}
+
+	return flags
+}
+
+// encodeRecord build the on-disk bytes of data as a single record.
+func (c *DiskCache) encodeRecord(data []byte) ([]byte, error) {
+	if !c.extRecord() {
+		buf := make([]byte, dataHeaderLen+len(data))
+		binary.LittleEndian.PutUint32(buf, uint32(len(data)))
+		copy(buf[dataHeaderLen:], data)
+		return buf, nil
+	}
+
+	if len(data) >= maxExtRecordSize { // raw length of the compressed record limited on Get()
+		return nil, ErrTooLargeData
+	}
+
+	payload, err := c.compress.compress(data)
+	if err != nil {
+		return nil, err
+	}
+
+	codec := c.compress
+	if payload == nil { // compression disabled or not worth
+		codec, payload = NoCompress, data
+	}
+
+	plen := len(payload)
+	if c.encKey != nil {
+		plen = c.encKey.sealedLen(plen)
+	}
+
+	if plen >= maxExtRecordSize {
+		return nil, ErrTooLargeData
+	}
+
+	// header, payload and crc all in one write, so a torn write
+	// will break the checksum of the record.
+	n := dataHeaderLen + recordFlagsLen + plen
+	buf := make([]byte, n+recordCRCLen)
+	binary.LittleEndian.PutUint32(buf, uint32(plen)|extRecordFlag)
+	buf[dataHeaderLen] = c.recordFlags(codec)
+
+	if c.encKey != nil { // encrypt after compression, the header authenticated along with the payload
+		if payload, err = c.encKey.seal(payload, buf[:dataHeaderLen+recordFlagsLen]); err != nil {
+			return nil, err
+		}
+	}
+
+	copy(buf[dataHeaderLen+recordFlagsLen:], payload)
+	binary.LittleEndian.PutUint32(buf[n:], crc32.Checksum(buf[:n], castagnoli))
+
+	return buf, nil
+}
+
+// resyncBufSize is the buffer size used to scan for the next valid record.
+const resyncBufSize = 64 * 1024
+
+// recordScanner look for the next valid record within a data file with a
+// bounded buffer.
+//
+// Record with checksum validated by it's checksum. Record without checksum
+// can't be validated by itself, so it's accepted only if it followed by
+// extended records that exactly end at the end of the file(or the EOF mark).
+// Legacy records never accepted on resync: we can't tell them from garbage.
+type recordScanner struct {
+	f    io.ReaderAt
+	size int64
+
+	buf,
+	crcbuf []byte
+	hdr [dataHeaderLen + recordFlagsLen]byte
+}
+
+func newRecordScanner(f io.ReaderAt, size int64) *recordScanner {
+	return &recordScanner{
+		f:      f,
+		size:   size,
+		buf:    make([]byte, resyncBufSize),
+		crcbuf: make([]byte, resyncBufSize),
+	}
+}
+
+// next find the first valid record(or the EOF mark) at or after off,
+// s.size returned if not found.
+func (s *recordScanner) next(off int64) (int64, error) {
+	const hdrLen = dataHeaderLen + recordFlagsLen
+
+	for off < s.size {
+		b := s.buf
+		if rest := s.size - off; rest < int64(len(b)) {
+			b = b[:rest]
+		}
+
+		if _, err := s.f.ReadAt(b, off); err != nil {
+			return 0, err
+		}
+
+		end := len(b)
+		if off+int64(end) < s.size { // header cross the buffer, leave it to next buffer
+			end -= hdrLen - 1
+		}
+
+		for i := 0; i < end; i++ {
+			ok, err := s.validAt(b[i:], off+int64(i))
+			if err != nil {
+				return 0, err
+			}
+
+			if ok {
+				return off + int64(i), nil
+			}
+		}
+
+		off += int64(end)
+	}
+
+	return s.size, nil
+}
+
+// extHeader parse b(located at off) as an extended record header, it returns
+// on-disk bytes and flags of the record. ok is false if b is not an extended
+// record header, or the record do not fit within the file.
+func (s *recordScanner) extHeader(b []byte, off int64) (n int64, flags uint8, ok bool) {
+	if len(b) < dataHeaderLen+recordFlagsLen {
+		return 0, 0, false
+	}
+
+	hdr := binary.LittleEndian.Uint32(b)
+	if hdr&extRecordFlag == 0 || hdr == EOFHint {
+		return 0, 0, false
+	}
+
+	flags = b[dataHeaderLen]
+	if flags&^recordFlagsMask != 0 {
+		return 0, 0, false
+	}
+
+	n = int64(dataHeaderLen+recordFlagsLen+recordCRCLen) + int64(hdr&^extRecordFlag)
+	return n, flags, off+n <= s.size
+}
+
+// validAt test if there is a valid record(or the EOF mark) at the beginning
+// of b, b is the bytes located at off of the file.
+func (s *recordScanner) validAt(b []byte, off int64) (bool, error) {
+	if len(b) >= dataHeaderLen && binary.LittleEndian.Uint32(b) == EOFHint {
+		return off+dataHeaderLen == s.size, nil // EOF must be the last 4 bytes of the file
+	}
+
+	n, flags, ok := s.extHeader(b, off)
+	if !ok {
+		return false, nil
+	}
+
+	if flags&flagChecksum != 0 {
+		return s.checksumOK(off, n)
+	}
+
+	return s.chainOK(off + n)
+}
+
+// checksumOK verify checksum of the extended record at off with n on-disk bytes.
+func (s *recordScanner) checksumOK(off, n int64) (bool, error) {
+	h := crc32.New(castagnoli)
+	if _, err := io.CopyBuffer(h, io.NewSectionReader(s.f, off, n-recordCRCLen), s.crcbuf); err != nil {
+		return false, err
+	}
+
+	crc := s.hdr[:recordCRCLen]
+	if _, err := s.f.ReadAt(crc, off+n-recordCRCLen); err != nil {
+		return false, err
+	}
+
+	return binary.LittleEndian.Uint32(crc) == h.Sum32(), nil
+}
+
+// chainOK test if records start from off are extended records that exactly
+// end at the end of the file(or the EOF mark). The chain stopped(and
+// validated) on the first record with checksum.
+func (s *recordScanner) chainOK(off int64) (bool, error) {
+	for off < s.size {
+		b := s.hdr[:]
+		if rest := s.size - off; rest < int64(len(b)) {
+			b = b[:rest]
+		}
+
+		if _, err := s.f.ReadAt(b, off); err != nil {
+			return false, err
+		}
+
+		if len(b) >= dataHeaderLen && binary.LittleEndian.Uint32(b) == EOFHint {
+			return off+dataHeaderLen == s.size, nil
+		}
+
+		n, flags, ok := s.extHeader(b, off)
+		if !ok {
+			return false, nil
+		}
+
+		if flags&flagChecksum != 0 {
+			return s.checksumOK(off, n)
+		}
+
+		off += n
+	}
+
+	return true, nil
+}
+
+// resync look for next valid record after the broken record that started at
+// offset start within r's current read file, and move read position there. If no
+// valid record found, all remaining data of the file are dropped.
+func (c *DiskCache) resync(r *reader, start int64) error {
+	next, err := newRecordScanner(r.rfd, r.curReadSize).next(start + 1)
+	if err != nil {
+		return err
+	}
+
+	droppedDataVec.WithLabelValues(c.metricPath(), reasonBadChecksum).Observe(float64(next - start))
+
+	if next >= r.curReadSize { // nothing valid within the file
+		return c.switchNextFile(r)
+	}
+
+	if _, err := r.rfd.Seek(next, io.SeekStart); err != nil {
+		return err
+	}
+
+	if !c.noPos {
+		r.pos.Seek = next
+		if err := r.pos.dumpFile(); err != nil {
+			return err
+		}
+
+		posUpdatedVec.WithLabelValues("resync", c.metricPath()).Inc()
+	}
+
+	return nil
+}
@@ -0,0 +1,254 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	T "testing"
+
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getAll(t *T.T, c *DiskCache) (res [][]byte) {
+	t.Helper()
+
+	for {
+		if err := c.Get(func(x []byte) error {
+			res = append(res, append([]byte{}, x...))
+			return nil
+		}); err != nil {
+			require.ErrorIs(t, err, ErrNoData)
+			return
+		}
+	}
+}
+
+func TestChecksum(t *T.T) {
+	t.Run(`basic`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithChecksum(true))
+		require.NoError(t, err)
+
+		for i := 0; i < 10; i++ {
+			require.NoError(t, c.Put([]byte(fmt.Sprintf("data-%d", i))))
+		}
+
+		require.NoError(t, c.StreamPut(bytes.NewReader([]byte("stream-data")), len("stream-data")))
+		require.NoError(t, c.Rotate())
+
+		res := getAll(t, c)
+		require.Len(t, res, 11)
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, fmt.Sprintf("data-%d", i), string(res[i]))
+		}
+		assert.Equal(t, "stream-data", string(res[10]))
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`bit-flip`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithChecksum(true))
+		require.NoError(t, err)
+
+		data := make([]byte, 100)
+		for i := 0; i < 10; i++ {
+			data[0] = byte(i)
+			require.NoError(t, c.Put(data))
+		}
+		require.NoError(t, c.Rotate())
+
+		// flip a bit within the payload of the 4th record
+		recLen := dataHeaderLen + recordFlagsLen + len(data) + recordCRCLen
+		raw, err := os.ReadFile(c.dataFiles[0])
+		require.NoError(t, err)
+		raw[3*recLen+dataHeaderLen+recordFlagsLen+50] ^= 0x01
+		require.NoError(t, os.WriteFile(c.dataFiles[0], raw, 0o600))
+
+		res := getAll(t, c)
+		require.Len(t, res, 9)
+		for i, x := range res {
+			if i < 3 {
+				assert.Equal(t, byte(i), x[0])
+			} else {
+				assert.Equal(t, byte(i+1), x[0]) // the 4th record dropped
+			}
+		}
+
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(Metrics()...)
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+
+		m := metrics.GetMetricOnLabels(mfs, "diskcache_dropped_data", c.path, reasonBadChecksum)
+		require.NotNil(t, m, "got metrics\n%s", metrics.MetricFamily2Text(mfs))
+		assert.Equal(t, uint64(1), m.GetSummary().GetSampleCount())
+		assert.Equal(t, float64(recLen), m.GetSummary().GetSampleSum())
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`broken-len`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithChecksum(true))
+		require.NoError(t, err)
+
+		data := make([]byte, 100)
+		for i := 0; i < 3; i++ {
+			data[0] = byte(i)
+			require.NoError(t, c.Put(data))
+		}
+		require.NoError(t, c.Rotate())
+
+		// set a very large length on the 1st record
+		raw, err := os.ReadFile(c.dataFiles[0])
+		require.NoError(t, err)
+		raw[2] = 0xff
+		require.NoError(t, os.WriteFile(c.dataFiles[0], raw, 0o600))
+
+		res := getAll(t, c)
+		require.Len(t, res, 2)
+		assert.Equal(t, byte(1), res[0][0])
+		assert.Equal(t, byte(2), res[1][0])
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`resync-across-buffer`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithChecksum(true), WithBatchSize(4*resyncBufSize))
+		require.NoError(t, err)
+
+		// the 2nd record's header cross the 1st scan buffer
+		data := make([]byte, resyncBufSize-(dataHeaderLen+recordFlagsLen+recordCRCLen)-2)
+		for i := 0; i < 3; i++ {
+			data[0] = byte(i)
+			require.NoError(t, c.Put(data))
+		}
+		require.NoError(t, c.Rotate())
+
+		raw, err := os.ReadFile(c.dataFiles[0])
+		require.NoError(t, err)
+		raw[dataHeaderLen+recordFlagsLen+10] ^= 0x01
+		require.NoError(t, os.WriteFile(c.dataFiles[0], raw, 0o600))
+
+		res := getAll(t, c)
+		require.Len(t, res, 2)
+		assert.Equal(t, byte(1), res[0][0])
+		assert.Equal(t, byte(2), res[1][0])
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`broken-len-without-checksum`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithCompression(CompressZstd))
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, c.Put([]byte(fmt.Sprintf("%d:%s", i, bytes.Repeat([]byte("compressible,"), 10)))))
+		}
+		require.NoError(t, c.Rotate())
+
+		// set a very large length on the 1st record, the following
+		// records(end with EOF mark) still found on resync.
+		raw, err := os.ReadFile(c.dataFiles[0])
+		require.NoError(t, err)
+		raw[2] = 0xff
+		require.NoError(t, os.WriteFile(c.dataFiles[0], raw, 0o600))
+
+		res := getAll(t, c)
+		require.Len(t, res, 2)
+		assert.Equal(t, byte('1'), res[0][0])
+		assert.Equal(t, byte('2'), res[1][0])
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`torn-write`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithChecksum(true))
+		require.NoError(t, err)
+
+		data := make([]byte, 100)
+		require.NoError(t, c.Put(data))
+		require.NoError(t, c.Put(data))
+
+		// truncate the last record of the write file, then rotate it
+		fi, err := os.Stat(c.curWriteFile)
+		require.NoError(t, err)
+		require.NoError(t, os.Truncate(c.curWriteFile, fi.Size()-10))
+		require.NoError(t, c.Rotate())
+
+		require.NoError(t, c.Put([]byte("next-file")))
+		require.NoError(t, c.Rotate())
+
+		res := getAll(t, c)
+		require.Len(t, res, 2)
+		assert.Equal(t, data, res[0])
+		assert.Equal(t, "next-file", string(res[1]))
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`mixed-legacy-records`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put([]byte("legacy-1")))
+		require.NoError(t, c.Close())
+
+		// reopen with checksum, append to the same write file
+		c, err = Open(WithPath(p), WithChecksum(true))
+		require.NoError(t, err)
+		require.NoError(t, c.Put([]byte("checksum-1")))
+		require.NoError(t, c.Rotate())
+
+		res := getAll(t, c)
+		require.Len(t, res, 2)
+		assert.Equal(t, "legacy-1", string(res[0]))
+		assert.Equal(t, "checksum-1", string(res[1]))
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`fallback-on-error`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithChecksum(true))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put([]byte("hello")))
+		require.NoError(t, c.Rotate())
+
+		require.Error(t, c.Get(func(_ []byte) error {
+			return errors.New("get error")
+		}))
+		assert.Equal(t, int64(0), c.pos.Seek)
+
+		assert.NoError(t, c.Get(func(x []byte) error {
+			assert.Equal(t, "hello", string(x))
+			return nil
+		}))
+		assert.Equal(t, int64(dataHeaderLen+recordFlagsLen+len("hello")+recordCRCLen), c.pos.Seek)
+
+		assert.NoError(t, c.Close())
+	})
+}
@@ -21,6 +21,19 @@ import (
 // NOTE: You do not need to call Rotate() during daily usage, we export
 // that function for testing cases.
 func (c *DiskCache) Rotate() error {
+	for _, l := range c.lanes {
+		if err := l.Rotate(); err != nil {
+			return err
+		}
+	}
+
+	if len(c.lanes) > 0 {
+		return nil
+	}
+
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+
 	return c.rotate()
 }
 
@@ -30,8 +43,8 @@ func (c *DiskCache) rotate() error {
 	defer c.rwlock.Unlock()
 
 	defer func() {
-		rotateVec.WithLabelValues(c.path).Inc()
-		datafilesVec.WithLabelValues(c.path).Set(float64(len(c.dataFiles)))
+		rotateVec.WithLabelValues(c.metricPath()).Inc()
+		c.setDatafilesMetric()
 	}()
 
 	eof := make([]byte, dataHeaderLen)
@@ -42,13 +55,38 @@ func (c *DiskCache) rotate() error {
 
 	// NOTE: EOF bytes do not count to size
 
-	// rotate file
-	var newfile string
-	if len(c.dataFiles) == 0 {
+	// data within the file should be durable before close
+	if err := c.flushSyncBatch(); err != nil {
+		return fmt.Errorf("rotate on sync: %w", err)
+	}
+
+	// rotate file, the new file should be newer than any file that has been
+	// read, or readers will treat it as read and skip it.
+	var newfile, last string
+	if n := len(c.dataFiles); n > 0 {
+		last = c.dataFiles[n-1]
+	}
+
+	for _, r := range c.readers {
+		if r.lastReadfile > last {
+			last = r.lastReadfile
+		}
+	}
+
+	if c.lastDropped > last { // the dropped file may be still reading
+		last = c.lastDropped
+	}
+
+	if n := len(c.archiveFiles); n > 0 {
+		if f := filepath.Join(c.path, filepath.Base(c.archiveFiles[n-1])); f > last {
+			last = f
+		}
+	}
+
+	if last == "" {
 		newfile = filepath.Join(c.path, fmt.Sprintf("data.%032d", 0)) // first rotate file
 	} else {
 		// parse last file's name, such as `data.000003', the new rotate file is `data.000004`
-		last := c.dataFiles[len(c.dataFiles)-1]
 		arr := strings.Split(filepath.Base(last), ".")
 		if len(arr) != 2 {
 			return ErrInvalidDataFileName
@@ -75,59 +113,47 @@ func (c *DiskCache) rotate() error {
 
 	// new file added, plus it's size to cache size
 	if fi, err := os.Stat(newfile); err == nil {
+		c.dataFileSizes[newfile] = fi.Size()
+
 		if fi.Size() > dataHeaderLen {
 			c.size.Add(fi.Size())
-			sizeVec.WithLabelValues(c.path).Add(float64(fi.Size()))
-			putBytesVec.WithLabelValues(c.path).Observe(float64(fi.Size()))
+			sizeVec.WithLabelValues(c.metricPath()).Add(float64(fi.Size()))
+			putBytesVec.WithLabelValues(c.metricPath()).Observe(float64(fi.Size()))
 		}
 	}
 
 	c.dataFiles = append(c.dataFiles, newfile)
 	sort.Strings(c.dataFiles)
+	c.updateLag()
 
 	// reopen new write file
 	if err := c.openWriteFile(); err != nil {
 		return err
 	}
 
-	return nil
+	c.notifyData()
+
+	return c.dropExpiredFiles()
 }
 
-// after file read on EOF, remove the file.
-func (c *DiskCache) removeCurrentReadingFile() error {
+// after file read on EOF, remove the file if all readers read through it.
+func (c *DiskCache) removeCurrentReadingFile(r *reader) error {
 	c.rwlock.Lock()
 	defer c.rwlock.Unlock()
 
 	defer func() {
-		removeVec.WithLabelValues(c.path).Inc()
-		datafilesVec.WithLabelValues(c.path).Set(float64(len(c.dataFiles)))
+		c.setDatafilesMetric()
 	}()
 
-	if c.rfd != nil {
-		if err := c.rfd.Close(); err != nil {
+	if r.rfd != nil {
+		if err := r.rfd.Close(); err != nil {
 			return err
 		}
-		c.rfd = nil
+		r.rfd = nil
 	}
 
-	if fi, err := os.Stat(c.curReadfile); err == nil { // file exist
-		if fi.Size() > dataHeaderLen {
-			c.size.Add(-fi.Size())
-			sizeVec.WithLabelValues(c.path).Sub(float64(fi.Size()))
-		}
-
-		getBytesVec.WithLabelValues(c.path).Observe(float64(fi.Size()))
-
-		if err := os.Remove(c.curReadfile); err != nil {
-			return fmt.Errorf("removeCurrentReadingFile: %q: %w", c.curReadfile, err)
-		}
-	}
-
-	c.curReadfile = ""
-
-	if len(c.dataFiles) > 0 {
-		c.dataFiles = c.dataFiles[1:] // first file removed
-	}
+	r.lastReadfile = r.curReadfile
+	r.curReadfile = ""
 
-	return nil
+	return c.removeConsumedFiles()
 }
@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"errors"
+	"hash/fnv"
+)
+
+// isShard test if c is a shard of the parent cache.
+func (c *DiskCache) isShard() bool {
+	return c.parent != nil && c.parent.shardCount > 1
+}
+
+// metricPath is the path label of metrics, all shards share the same
+// labels of their parent.
+func (c *DiskCache) metricPath() string {
+	if c.isShard() {
+		return c.parent.path
+	}
+
+	return c.path
+}
+
+// setDatafilesMetric update data files count of the cache. Shards report
+// the delta, so the gauge is the sum of all shards.
+func (c *DiskCache) setDatafilesMetric() {
+	n := int64(len(c.dataFiles))
+
+	if c.isShard() {
+		datafilesVec.WithLabelValues(c.metricPath()).Add(float64(n - c.reportedDatafiles))
+		c.reportedDatafiles = n
+		return
+	}
+
+	datafilesVec.WithLabelValues(c.metricPath()).Set(float64(n))
+}
+
+// setArchiveSizeMetric update archive size of the cache, see setDatafilesMetric().
+func (c *DiskCache) setArchiveSizeMetric() {
+	if c.isShard() {
+		archiveSizeVec.WithLabelValues(c.metricPath()).Add(float64(c.archiveSize - c.reportedArchiveSize))
+		c.reportedArchiveSize = c.archiveSize
+		return
+	}
+
+	archiveSizeVec.WithLabelValues(c.metricPath()).Set(float64(c.archiveSize))
+}
+
+// putLane select the lane(or shard) for Put(). Shards are selected in
+// round-robin, and lanes always the lowest one.
+func (c *DiskCache) putLane() *DiskCache {
+	if c.shardCount > 1 {
+		return c.lanes[c.putSeq.Add(1)%uint64(len(c.lanes))]
+	}
+
+	return c.lanes[0]
+}
+
+// PutKey write data into the shard selected by hash of key, so data of the
+// same key are Get() in the same order they put. Without WithShards(), the
+// key ignored.
+func (c *DiskCache) PutKey(key, data []byte) error {
+	if c.shardCount <= 1 {
+		return c.Put(data)
+	}
+
+	h := fnv.New32a()
+	h.Write(key) //nolint:errcheck,gosec
+
+	return c.lanes[h.Sum32()%uint32(len(c.lanes))].Put(data)
+}
+
+// drainShards call fn on shards until fn not returns ErrNoData. Each call
+// start from the next shard, so all shards are drained fairly.
+func (c *DiskCache) drainShards(fn func(l *DiskCache) error) error {
+	n := uint64(len(c.lanes))
+	start := c.getSeq.Add(1)
+
+	for i := uint64(0); i < n; i++ {
+		if err := fn(c.lanes[(start+i)%n]); !errors.Is(err, ErrNoData) {
+			return err
+		}
+	}
+
+	return ErrNoData
+}
+
+// dropLargestShard drop the oldest data file of the largest shard.
+func (c *DiskCache) dropLargestShard() error {
+	var x *DiskCache
+	for _, l := range c.lanes {
+		if x == nil || l.size.Load() > x.size.Load() {
+			x = l
+		}
+	}
+
+	x.rwlock.Lock()
+	defer x.rwlock.Unlock()
+
+	if len(x.dataFiles) == 0 {
+		return nil
+	}
+
+	return x.dropOldestFile(reasonExceedCapacity)
+}
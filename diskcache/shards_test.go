@@ -0,0 +1,210 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	T "testing"
+
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkShardsPut(b *T.B) {
+	_1kb := make([]byte, 1024)
+
+	for _, n := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("shards-%d", n), func(b *T.B) {
+			c, err := Open(WithPath(b.TempDir()),
+				WithShards(n),
+				WithBatchSize(1024*1024*4),
+				WithCapacity(4*1024*1024*1024))
+			require.NoError(b, err)
+
+			b.SetParallelism(16)
+			b.SetBytes(int64(len(_1kb)))
+			b.ResetTimer()
+
+			b.RunParallel(func(pb *T.PB) {
+				for pb.Next() {
+					if err := c.Put(_1kb); err != nil {
+						b.Error(err)
+					}
+				}
+			})
+
+			b.StopTimer()
+			assert.NoError(b, c.Close())
+			ResetMetrics()
+		})
+	}
+}
+
+func TestShards(t *T.T) {
+	t.Run(`round-robin`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithShards(4))
+		require.NoError(t, err)
+		require.Len(t, c.lanes, 4)
+
+		for i := 0; i < 8; i++ {
+			require.NoError(t, c.Put([]byte(fmt.Sprintf("data-%d", i))))
+		}
+		require.NoError(t, c.Rotate())
+
+		for _, l := range c.lanes {
+			assert.Equal(t, 2*int64(dataHeaderLen+len("data-0"))+dataHeaderLen, l.Size()) // with EOF
+		}
+
+		assert.Len(t, getAll(t, c), 8)
+		assert.Equal(t, int64(0), c.Size())
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`key-order`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithShards(4))
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		wg.Add(8)
+		for i := 0; i < 8; i++ {
+			go func(key string) {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					assert.NoError(t, c.PutKey([]byte(key), []byte(fmt.Sprintf("%s:%d", key, j))))
+				}
+			}(fmt.Sprintf("key-%d", i))
+		}
+		wg.Wait()
+		require.NoError(t, c.Rotate())
+
+		last := map[string]int{}
+		n := 0
+		for _, x := range getAll(t, c) {
+			arr := strings.Split(string(x), ":")
+			require.Len(t, arr, 2)
+
+			seq, err := strconv.Atoi(arr[1])
+			require.NoError(t, err)
+
+			if prev, ok := last[arr[0]]; ok {
+				assert.Equal(t, prev+1, seq, "key %s out of order", arr[0])
+			} else {
+				assert.Equal(t, 0, seq)
+			}
+
+			last[arr[0]] = seq
+			n++
+		}
+
+		assert.Equal(t, 400, n)
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`get-fairly`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithShards(2))
+		require.NoError(t, err)
+
+		for i := 0; i < 4; i++ {
+			require.NoError(t, c.lanes[0].Put([]byte("shard-0")))
+		}
+		require.NoError(t, c.lanes[1].Put([]byte("shard-1")))
+		require.NoError(t, c.Rotate())
+
+		// shard-1 not starved by shard-0
+		got := getAll(t, c)
+		require.Len(t, got, 5)
+		assert.Contains(t, got[:2], []byte("shard-1"))
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`shared-capacity-and-metrics`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+
+		data := bytes.Repeat([]byte("x"), 1000)
+		c, err := Open(WithPath(p),
+			WithShards(4),
+			WithBatchSize(4*1024),
+			WithCapacity(32*1024))
+		require.NoError(t, err)
+
+		for i := 0; i < 128; i++ {
+			require.NoError(t, c.Put(data))
+		}
+		require.NoError(t, c.Rotate())
+		assert.Less(t, c.RawSize(), int64(64*len(data)))
+
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(Metrics()...)
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+
+		// all shards report with labels of the cache
+		m := metrics.GetMetricOnLabels(mfs, "diskcache_size", p)
+		require.NotNil(t, m)
+		assert.Equal(t, float64(c.RawSize()), m.GetGauge().GetValue())
+
+		m = metrics.GetMetricOnLabels(mfs, "diskcache_datafiles", p)
+		require.NotNil(t, m)
+		nfiles := 0
+		for _, l := range c.lanes {
+			nfiles += len(l.dataFiles)
+		}
+		assert.Equal(t, float64(nfiles), m.GetGauge().GetValue())
+
+		m = metrics.GetMetricOnLabels(mfs, "diskcache_capacity", p)
+		require.NotNil(t, m)
+		assert.Equal(t, float64(32*1024), m.GetGauge().GetValue())
+
+		assert.NotNil(t, metrics.GetMetricOnLabels(mfs, "diskcache_dropped_data", p, reasonExceedCapacity))
+
+		for _, l := range c.lanes {
+			assert.Nil(t, metrics.GetMetricOnLabels(mfs, "diskcache_size", l.path))
+		}
+
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`reopen`, func(t *T.T) {
+		ResetMetrics()
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithShards(2))
+		require.NoError(t, err)
+
+		require.NoError(t, c.PutKey([]byte("a"), []byte("hello")))
+		require.NoError(t, c.PutKey([]byte("b"), []byte("world")))
+		require.NoError(t, c.Close())
+
+		c, err = Open(WithPath(p), WithShards(2))
+		require.NoError(t, err)
+		require.NoError(t, c.Rotate())
+
+		assert.ElementsMatch(t, [][]byte{[]byte("hello"), []byte("world")}, getAll(t, c))
+		assert.NoError(t, c.Close())
+	})
+
+	t.Run(`invalid-combination`, func(t *T.T) {
+		ResetMetrics()
+
+		_, err := Open(WithPath(t.TempDir()), WithShards(2), WithConsumers("cloud"))
+		assert.Error(t, err)
+
+		_, err = Open(WithPath(t.TempDir()), WithShards(2), WithPriorityLanes(2))
+		assert.Error(t, err)
+	})
+}
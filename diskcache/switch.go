@@ -14,12 +14,12 @@ import (
 )
 
 // switch to next file remembered in .pos file.
-func (c *DiskCache) loadUnfinishedFile() error {
-	if _, err := os.Stat(c.pos.fname); err != nil {
+func (c *DiskCache) loadUnfinishedFile(r *reader) error {
+	if _, err := os.Stat(r.pos.fname); err != nil {
 		return nil // .pos file not exist
 	}
 
-	pos, err := posFromFile(c.pos.fname)
+	pos, err := posFromFile(r.pos.fname)
 	if err != nil {
 		return fmt.Errorf("posFromFile: %w", err)
 	}
@@ -30,7 +30,7 @@ func (c *DiskCache) loadUnfinishedFile() error {
 
 	// check file's healty
 	if _, err := os.Stat(string(pos.Name)); err != nil { // not exist
-		if err := c.pos.reset(); err != nil {
+		if err := r.pos.reset(); err != nil {
 			return err
 		}
 
@@ -42,6 +42,14 @@ func (c *DiskCache) loadUnfinishedFile() error {
 		return nil
 	}
 
+	// the file has been read to EOF, but still kept for other consumers
+	if pos.Seek < 0 {
+		r.lastReadfile = string(pos.Name)
+		r.pos.Name = pos.Name
+		r.pos.Seek = pos.Seek
+		return nil
+	}
+
 	fd, err := os.OpenFile(string(pos.Name), os.O_RDONLY, c.filePerms)
 	if err != nil {
 		return fmt.Errorf("OpenFile: %w", err)
@@ -51,53 +59,72 @@ func (c *DiskCache) loadUnfinishedFile() error {
 		return fmt.Errorf("Seek(%q: %d, 0): %w", pos.Name, pos.Seek, err)
 	}
 
-	c.rfd = fd
-	c.curReadfile = string(pos.Name)
-	c.pos.Name = pos.Name
-	c.pos.Seek = pos.Seek
+	if fi, err := fd.Stat(); err != nil {
+		return fmt.Errorf("on fd.Stat(): %w", err)
+	} else {
+		r.curReadSize = fi.Size()
+	}
+
+	r.rfd = fd
+	r.curReadfile = string(pos.Name)
+	r.pos.Name = pos.Name
+	r.pos.Seek = pos.Seek
 
 	return nil
 }
 
 // open next read file.
-func (c *DiskCache) doSwitchNextFile() error {
+func (c *DiskCache) doSwitchNextFile(r *reader) error {
 	c.rwlock.Lock()
 	defer c.rwlock.Unlock()
 
+	defer c.updateLag()
+
 	// clear .pos: prepare for new .pos for next new file.
 	if !c.noPos {
-		if err := c.pos.reset(); err != nil {
+		if err := r.pos.reset(); err != nil {
 			return err
 		}
 	}
 
-	if len(c.dataFiles) == 0 {
+	next := c.nextFileOf(r)
+	if next == "" {
+		// for named consumer, remember the file read to EOF, we should not
+		// read it again on restart if the file kept for other consumers.
+		if !c.noPos && r.name != "" && r.lastReadfile != "" {
+			r.pos.Name = []byte(r.lastReadfile)
+			r.pos.Seek = -1
+			if err := r.pos.dumpFile(); err != nil {
+				return err
+			}
+		}
+
 		return nil
 	} else {
-		c.curReadfile = c.dataFiles[0]
+		r.curReadfile = next
 	}
 
-	fd, err := os.OpenFile(c.curReadfile, os.O_RDONLY, c.filePerms)
+	fd, err := os.OpenFile(r.curReadfile, os.O_RDONLY, c.filePerms)
 	if err != nil {
 		return fmt.Errorf("under switchNextFile, OpenFile: %w, datafile: %+#v, ", err, c.dataFiles)
 	}
 
-	c.rfd = fd
+	r.rfd = fd
 
-	if fi, err := c.rfd.Stat(); err != nil {
+	if fi, err := r.rfd.Stat(); err != nil {
 		return fmt.Errorf("on rfd.Stat(): %w", err)
 	} else {
-		c.curReadSize = fi.Size()
+		r.curReadSize = fi.Size()
 	}
 
 	if !c.noPos {
-		c.pos.Name = []byte(c.curReadfile)
-		c.pos.Seek = 0
-		if err := c.pos.dumpFile(); err != nil {
+		r.pos.Name = []byte(r.curReadfile)
+		r.pos.Seek = 0
+		if err := r.pos.dumpFile(); err != nil {
 			return err
 		}
 
-		posUpdatedVec.WithLabelValues("switch", c.path).Inc()
+		posUpdatedVec.WithLabelValues("switch", c.metricPath()).Inc()
 	}
 
 	return nil
@@ -123,6 +150,7 @@ func (c *DiskCache) openWriteFile() error {
 	}
 
 	c.wfdLastWrite = time.Now()
+	c.wfdOpened = c.wfdLastWrite
 	c.wfd = wfd
 	return nil
 }
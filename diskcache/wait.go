@@ -0,0 +1,138 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// minWaitRotateSize is the write file size that always rotated for waiting readers.
+const minWaitRotateSize = 1024 * 1024
+
+// dataSignal return a channel that closed on new data available.
+func (c *DiskCache) dataSignal() <-chan struct{} {
+	c.dataMu.Lock()
+	defer c.dataMu.Unlock()
+
+	if c.dataCh == nil {
+		c.dataCh = make(chan struct{})
+	}
+
+	return c.dataCh
+}
+
+// notifyData wakeup all GetContext() waiting for new data.
+func (c *DiskCache) notifyData() {
+	c.dataMu.Lock()
+	if c.dataCh != nil { // nobody waiting if nil
+		close(c.dataCh)
+		c.dataCh = nil
+	}
+	c.dataMu.Unlock()
+
+	if c.parent != nil { // GetContext() may wait on the parent of the lane
+		c.parent.notifyData()
+	}
+}
+
+// rotateWriting rotate the current write file if any data within it, so the
+// data can be read at once.
+func (c *DiskCache) rotateWriting() error {
+	_, err := c.rotateWaiting(0)
+	return err
+}
+
+// rotateWaiting rotate the current write file for readers waiting on it. To
+// not scatter the cache into tiny data files, the file rotated only if it's
+// large enough(minWaitRotateSize) or it's been opened for at least minAge.
+// It return how long to wait before the file can be rotated, 0 if rotated
+// or nothing to rotate.
+func (c *DiskCache) rotateWaiting(minAge time.Duration) (time.Duration, error) {
+	var wait time.Duration
+	for _, l := range c.lanes {
+		x, err := l.rotateWaiting(minAge)
+		if err != nil {
+			return 0, err
+		}
+
+		if x > 0 && (wait == 0 || x < wait) {
+			wait = x
+		}
+	}
+
+	if len(c.lanes) > 0 {
+		return wait, nil
+	}
+
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+
+	if c.curBatchSize == 0 || c.wfd == nil {
+		return 0, nil
+	}
+
+	if c.curBatchSize < minWaitRotateSize {
+		if age := time.Since(c.wfdOpened); age < minAge {
+			return minAge - age, nil
+		}
+	}
+
+	return 0, c.rotate()
+}
+
+// GetContext fetch new data from disk cache, then passing to fn. If there is
+// no data, GetContext block until new data Put() into cache, or ctx done.
+//
+// Different from Get(), the data in current write file are readable soon(see
+// WithWaitRotate()), we do not have to wait wakeup to rotate the write file.
+func (c *DiskCache) GetContext(ctx context.Context, fn Fn) error {
+	return c.waitGet(ctx, func() error { return c.Get(fn) })
+}
+
+// waitGet retry get until ok or failed on error other than ErrNoData.
+func (c *DiskCache) waitGet(ctx context.Context, get func() error) error {
+	for {
+		// get the signal before Get() to not miss new data between them
+		ch := c.dataSignal()
+
+		err := get()
+		if !errors.Is(err, ErrNoData) {
+			return err
+		}
+
+		if c.closed.Load() {
+			return ErrCacheClosed
+		}
+
+		wait, err := c.rotateWaiting(c.waitRotate)
+		if err != nil {
+			return err
+		}
+
+		if wait == 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ch:
+			}
+
+			continue
+		}
+
+		// write file not rotated, retry on it's rotatable
+		tick := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			tick.Stop()
+			return ctx.Err()
+		case <-ch:
+			tick.Stop()
+		case <-tick.C:
+		}
+	}
+}
@@ -0,0 +1,535 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package point
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AggregateFunc is the function used to roll up field values within a window.
+type AggregateFunc string
+
+const (
+	AggSum   AggregateFunc = "sum"
+	AggAvg   AggregateFunc = "avg"
+	AggMin   AggregateFunc = "min"
+	AggMax   AggregateFunc = "max"
+	AggCount AggregateFunc = "count"
+	AggLast  AggregateFunc = "last"
+
+	defaultAggWindow = time.Minute
+)
+
+// AggPercentile get the percentile function, p in (0, 100], the function
+// named like p99/p99.9.
+func AggPercentile(p float64) AggregateFunc {
+	return AggregateFunc("p" + strconv.FormatFloat(p, 'f', -1, 64))
+}
+
+// percentile get percentile value of percentile function.
+func (f AggregateFunc) percentile() (float64, bool) {
+	if !strings.HasPrefix(string(f), "p") {
+		return 0, false
+	}
+
+	p, err := strconv.ParseFloat(string(f[1:]), 64)
+	if err != nil || p <= 0 || p > 100 {
+		return 0, false
+	}
+
+	return p, true
+}
+
+func (f AggregateFunc) valid() bool {
+	switch f {
+	case AggSum, AggAvg, AggMin, AggMax, AggCount, AggLast:
+		return true
+	default:
+		_, ok := f.percentile()
+		return ok
+	}
+}
+
+// AggregatorOption used to configure the Aggregator.
+type AggregatorOption func(*Aggregator)
+
+// WithAggWindow set the tumbling window size, default 1min.
+func WithAggWindow(d time.Duration) AggregatorOption {
+	return func(a *Aggregator) { a.window = d }
+}
+
+// WithAggGrace set grace period of the window: the window is kept open for
+// late points within the grace period after the window end.
+func WithAggGrace(d time.Duration) AggregatorOption {
+	return func(a *Aggregator) { a.grace = d }
+}
+
+// WithAggField set aggregate functions on field key. If multiple functions
+// set, the rolled-up fields are named <key>_<func>, such as latency_p99.
+func WithAggField(key string, fns ...AggregateFunc) AggregatorOption {
+	return func(a *Aggregator) { a.fieldFns[key] = fns }
+}
+
+// WithAggDefaultFuncs set aggregate functions on fields that not set by
+// WithAggField and without metric type. Default is last.
+func WithAggDefaultFuncs(fns ...AggregateFunc) AggregatorOption {
+	return func(a *Aggregator) { a.defaultFns = fns }
+}
+
+// WithAggPointOptions set options on rolled-up points.
+func WithAggPointOptions(opts ...Option) AggregatorOption {
+	return func(a *Aggregator) { a.ptOpts = append(a.ptOpts, opts...) }
+}
+
+// numKind is the kind of numeric field value.
+type numKind int8
+
+const (
+	numNone numKind = iota // non-numeric
+	numInt
+	numUint
+	numFloat
+)
+
+// aggField hold rolled-up state of a field within the window.
+type aggField struct {
+	key   string
+	fns   []AggregateFunc
+	unit  string
+	mtype MetricType
+
+	count    int64 // all values, include non-numeric
+	numeric  int64
+	kind     numKind // numInt/numUint if all numeric values are of the kind, or numFloat
+	sum      float64
+	min, max float64
+	values   []float64 // for percentiles
+
+	// integer sum/min/max, to keep precision beyond float64
+	isum, imin, imax int64
+	usum, umin, umax uint64
+	sumOverflow      bool // integer sum overflowed, sum rolled up as float
+
+	last     any // raw value
+	lastTime int64
+}
+
+func (af *aggField) add(f *Field, ts int64) {
+	af.count++
+
+	if af.count == 1 || ts >= af.lastTime {
+		af.last, af.lastTime = f.Raw(), ts
+	}
+
+	v, kind := aggValue(f)
+	if kind == numNone {
+		return
+	}
+
+	if af.numeric == 0 {
+		af.min, af.max, af.kind = v, v, kind
+	} else if af.kind != kind { // mixed kinds rolled up as float
+		af.kind = numFloat
+	}
+
+	af.numeric++
+	af.sum += v
+	af.min = math.Min(af.min, v)
+	af.max = math.Max(af.max, v)
+
+	switch x := f.Val.(type) {
+	case *Field_I:
+		if af.numeric == 1 {
+			af.imin, af.imax = x.I, x.I
+		}
+		if (x.I > 0 && af.isum > math.MaxInt64-x.I) || (x.I < 0 && af.isum < math.MinInt64-x.I) {
+			af.sumOverflow = true
+		}
+		af.isum += x.I
+		if x.I < af.imin {
+			af.imin = x.I
+		}
+		if x.I > af.imax {
+			af.imax = x.I
+		}
+	case *Field_U:
+		if af.numeric == 1 {
+			af.umin, af.umax = x.U, x.U
+		}
+		if af.usum > math.MaxUint64-x.U {
+			af.sumOverflow = true
+		}
+		af.usum += x.U
+		if x.U < af.umin {
+			af.umin = x.U
+		}
+		if x.U > af.umax {
+			af.umax = x.U
+		}
+	}
+
+	for _, fn := range af.fns {
+		if _, ok := fn.percentile(); ok {
+			af.values = append(af.values, v)
+			break
+		}
+	}
+}
+
+// aggValue get numeric value and its kind of f.
+func aggValue(f *Field) (float64, numKind) {
+	switch x := f.Val.(type) {
+	case *Field_I:
+		return float64(x.I), numInt
+	case *Field_U:
+		return float64(x.U), numUint
+	case *Field_F:
+		return x.F, numFloat
+	default:
+		return 0, numNone
+	}
+}
+
+// intValue get integer result of fn, sum/min/max on integers are still
+// integers of the same kind. Overflowed sum is not an integer result.
+func (af *aggField) intValue(fn AggregateFunc) (any, bool) {
+	if fn == AggSum && af.sumOverflow {
+		return nil, false
+	}
+
+	switch af.kind {
+	case numInt:
+		switch fn {
+		case AggSum:
+			return af.isum, true
+		case AggMin:
+			return af.imin, true
+		case AggMax:
+			return af.imax, true
+		}
+	case numUint:
+		switch fn {
+		case AggSum:
+			return af.usum, true
+		case AggMin:
+			return af.umin, true
+		case AggMax:
+			return af.umax, true
+		}
+	case numNone, numFloat: // pass
+	}
+
+	return nil, false
+}
+
+// percentile get p-th percentile of sorted values with linear interpolation.
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
+
+// rollup get rolled-up fields of af.
+func (af *aggField) rollup() (kvs KVs) {
+	if af.values != nil {
+		sort.Float64s(af.values)
+	}
+
+	for _, fn := range af.fns {
+		name := af.key
+		if len(af.fns) > 1 {
+			name = af.key + "_" + string(fn)
+		}
+
+		switch fn {
+		case AggLast:
+			kvs = kvs.Add(name, af.last, WithKVUnit(af.unit), WithKVType(af.mtype))
+			continue
+		case AggCount:
+			kvs = kvs.Add(name, af.count, WithKVType(COUNT))
+			continue
+		}
+
+		if af.numeric == 0 { // non-numeric field only support last/count
+			continue
+		}
+
+		// rolled-up values are gauges(if typed), except sum of deltas.
+		t := af.mtype
+		if t != UNSPECIFIED && !(t == DELTA && fn == AggSum) {
+			t = GAUGE
+		}
+
+		if x, ok := af.intValue(fn); ok {
+			kvs = kvs.Add(name, x, WithKVUnit(af.unit), WithKVType(t))
+			continue
+		}
+
+		var v float64
+		switch fn {
+		case AggSum:
+			v = af.sum
+		case AggAvg:
+			v = af.sum / float64(af.numeric)
+		case AggMin:
+			v = af.min
+		case AggMax:
+			v = af.max
+		default: // percentiles
+			p, _ := fn.percentile()
+			v = percentile(af.values, p)
+		}
+
+		kvs = kvs.Add(name, v, WithKVUnit(af.unit), WithKVType(t))
+	}
+
+	return kvs
+}
+
+// aggSeries is the measurement and tag set within the window, fields of
+// the same series rolled up into a single point.
+type aggSeries struct {
+	name   string
+	tags   KVs
+	fields []*aggField // in order of appearance
+}
+
+// aggWindow is the rolled-up state of a window.
+type aggWindow struct {
+	series map[string]*aggSeries // measurement and tags -> series
+	fields map[string]*aggField  // time series(see Point.TimeSeriesHash()) -> field
+}
+
+// Aggregator roll up points within tumbling windows. Each field is a time
+// series(same as Point.TimeSeriesHash()), rolled up by its aggregate functions:
+//
+//   - functions set by WithAggField
+//   - last for COUNT fields(cumulative counters), their sum is meaningless
+//   - sum for DELTA fields(see DeltaConverter)
+//   - avg for GAUGE and RATE fields
+//   - functions set by WithAggDefaultFuncs for other fields
+//
+// Non-numeric fields only support last and count. Fields of the same measurement
+// and tag set rolled up into a single point, the point's time is the window start.
+type Aggregator struct {
+	window, grace time.Duration
+	fieldFns      map[string][]AggregateFunc
+	defaultFns    []AggregateFunc
+	ptOpts        []Option
+
+	mtx      sync.Mutex
+	windows  map[int64]*aggWindow // window start -> window
+	closedTo int64                // windows end before it are flushed
+	dropped  int64
+}
+
+// NewAggregator create an Aggregator.
+func NewAggregator(opts ...AggregatorOption) (*Aggregator, error) {
+	a := &Aggregator{
+		window:     defaultAggWindow,
+		fieldFns:   map[string][]AggregateFunc{},
+		defaultFns: []AggregateFunc{AggLast},
+		windows:    map[int64]*aggWindow{},
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(a)
+		}
+	}
+
+	if a.window <= 0 {
+		return nil, fmt.Errorf("invalid window %s", a.window)
+	}
+
+	fns := append([]AggregateFunc{}, a.defaultFns...)
+	for _, arr := range a.fieldFns {
+		fns = append(fns, arr...)
+	}
+
+	for _, fn := range fns {
+		if !fn.valid() {
+			return nil, fmt.Errorf("invalid aggregate function %q", fn)
+		}
+	}
+
+	return a, nil
+}
+
+func (a *Aggregator) funcsOf(f *Field) []AggregateFunc {
+	if fns, ok := a.fieldFns[f.Key]; ok {
+		return fns
+	}
+
+	switch f.Type {
+	case COUNT:
+		return []AggregateFunc{AggLast}
+	case DELTA:
+		return []AggregateFunc{AggSum}
+	case GAUGE, RATE:
+		return []AggregateFunc{AggAvg}
+	case UNSPECIFIED: // pass
+	}
+
+	return a.defaultFns
+}
+
+// Add add points to the aggregator. Late points, which window(with grace
+// period) already flushed, are dropped.
+func (a *Aggregator) Add(pts ...*Point) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	for _, pt := range pts {
+		ts := pt.pt.Time
+		start := ts - ts%int64(a.window)
+		if ts < 0 && ts%int64(a.window) != 0 {
+			start -= int64(a.window)
+		}
+
+		if start+int64(a.window) <= a.closedTo {
+			a.dropped++
+			continue
+		}
+
+		w, ok := a.windows[start]
+		if !ok {
+			w = &aggWindow{
+				series: map[string]*aggSeries{},
+				fields: map[string]*aggField{},
+			}
+			a.windows[start] = w
+		}
+
+		var s *aggSeries
+		fields := pt.Fields()
+
+		for i, id := range pt.TimeSeriesHash() {
+			f := fields[i]
+			if f.Val == nil {
+				continue
+			}
+
+			af, ok := w.fields[id]
+			if !ok {
+				if s == nil {
+					s = w.seriesOf(pt)
+				}
+
+				af = &aggField{
+					key:   f.Key,
+					fns:   a.funcsOf(f),
+					unit:  f.Unit,
+					mtype: f.Type,
+				}
+				w.fields[id] = af
+				s.fields = append(s.fields, af)
+			}
+
+			af.add(f, ts)
+		}
+	}
+}
+
+// seriesOf get the series of pt within the window.
+func (w *aggWindow) seriesOf(pt *Point) *aggSeries {
+	id := string(pt.hashstr())
+	if s, ok := w.series[id]; ok {
+		return s
+	}
+
+	s := &aggSeries{name: pt.pt.Name}
+
+	// copy tags: pt may be put back to point pool after added
+	for _, kv := range pt.Tags() {
+		s.tags = append(s.tags, NewKV(kv.Key, kv.GetS(), WithKVTagSet(true)))
+	}
+
+	w.series[id] = s
+	return s
+}
+
+// Flush get rolled-up points of windows that closed at now: the window end
+// plus grace period not after now.
+func (a *Aggregator) Flush(now time.Time) []*Point {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	closedTo := now.UnixNano() - int64(a.grace)
+	if closedTo > a.closedTo {
+		a.closedTo = closedTo
+	}
+
+	return a.flush(func(start int64) bool {
+		return start+int64(a.window) <= a.closedTo
+	})
+}
+
+// FlushAll get rolled-up points of all windows, used on exit. Points of
+// these windows added later are dropped.
+func (a *Aggregator) FlushAll() []*Point {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	for start := range a.windows {
+		if end := start + int64(a.window); end > a.closedTo {
+			a.closedTo = end
+		}
+	}
+
+	return a.flush(func(int64) bool { return true })
+}
+
+func (a *Aggregator) flush(closed func(start int64) bool) (pts []*Point) {
+	var starts []int64
+	for start := range a.windows {
+		if closed(start) {
+			starts = append(starts, start)
+		}
+	}
+
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+
+	for _, start := range starts {
+		w := a.windows[start]
+		delete(a.windows, start)
+
+		ids := make([]string, 0, len(w.series))
+		for id := range w.series {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		for _, id := range ids {
+			s := w.series[id]
+
+			kvs := append(KVs{}, s.tags...)
+			for _, af := range s.fields {
+				kvs = append(kvs, af.rollup()...)
+			}
+
+			pts = append(pts, NewPoint(s.name, kvs,
+				append([]Option{WithTime(time.Unix(0, start))}, a.ptOpts...)...))
+		}
+	}
+
+	return pts
+}
+
+// Dropped get count of dropped late points.
+func (a *Aggregator) Dropped() int64 {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	return a.dropped
+}
@@ -0,0 +1,217 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package point
+
+import (
+	"math"
+	T "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregator(t *T.T) {
+	base := time.Unix(1000, 0) // aligned to 10s window
+
+	t.Run(`metric-type`, func(t *T.T) {
+		a, err := NewAggregator(WithAggWindow(10 * time.Second))
+		require.NoError(t, err)
+
+		for i := 0; i < 10; i++ {
+			for _, host := range []string{"h1", "h2"} {
+				var kvs KVs
+				kvs = kvs.Add("cpu", float64(i), WithKVType(GAUGE), WithKVUnit("percent")).
+					Add("reqs", int64(i), WithKVType(COUNT)).
+					Add("delta", int64(i), WithKVType(DELTA)).
+					Add("status", host+"-ok").
+					AddTag("host", host)
+
+				a.Add(NewPoint("abc", kvs, WithTime(base.Add(time.Duration(i)*time.Second))))
+			}
+		}
+
+		assert.Empty(t, a.Flush(base.Add(9*time.Second))) // window not closed
+
+		pts := a.Flush(base.Add(10 * time.Second))
+		require.Len(t, pts, 2)
+
+		for _, pt := range pts {
+			assert.Equal(t, base, pt.Time())
+			assert.Equal(t, "abc", pt.Name())
+
+			cpu := pt.Fields().Get("cpu")
+			assert.Equal(t, 4.5, cpu.GetF())
+			assert.Equal(t, GAUGE, cpu.Type)
+			assert.Equal(t, "percent", cpu.Unit)
+
+			// cumulative counter keep the last value
+			reqs := pt.Fields().Get("reqs")
+			assert.Equal(t, int64(9), reqs.GetI())
+			assert.Equal(t, COUNT, reqs.Type)
+
+			delta := pt.Fields().Get("delta")
+			assert.Equal(t, int64(45), delta.GetI())
+			assert.Equal(t, DELTA, delta.Type)
+
+			assert.Equal(t, pt.GetTag("host")+"-ok", pt.Get("status"))
+		}
+
+		assert.Empty(t, a.FlushAll())
+	})
+
+	t.Run(`field-funcs`, func(t *T.T) {
+		a, err := NewAggregator(WithAggWindow(time.Minute),
+			WithAggField("latency", AggMin, AggMax, AggAvg, AggCount, AggLast, AggPercentile(50), AggPercentile(90)),
+			WithAggField("msg", AggCount, AggSum))
+		require.NoError(t, err)
+
+		for i := 1; i <= 11; i++ {
+			var kvs KVs
+			kvs = kvs.Add("latency", int64(i*10)).Add("msg", "hello")
+			a.Add(NewPoint("abc", kvs, WithTime(base.Add(time.Duration(11-i)*time.Second))))
+		}
+
+		pts := a.FlushAll()
+		require.Len(t, pts, 1)
+		pt := pts[0]
+
+		assert.Equal(t, int64(10), pt.Get("latency_min"))
+		assert.Equal(t, int64(110), pt.Get("latency_max"))
+		assert.Equal(t, 60.0, pt.Get("latency_avg"))
+		assert.Equal(t, int64(11), pt.Get("latency_count"))
+		assert.Equal(t, int64(10), pt.Get("latency_last")) // the latest point
+		assert.Equal(t, 60.0, pt.Get("latency_p50"))
+		assert.Equal(t, 100.0, pt.Get("latency_p90"))
+
+		assert.Equal(t, int64(11), pt.Get("msg_count"))
+		assert.Nil(t, pt.Get("msg_sum")) // not numeric
+	})
+
+	t.Run(`integer-precision`, func(t *T.T) {
+		a, err := NewAggregator(WithAggWindow(time.Minute),
+			WithAggField("i", AggSum, AggMin, AggMax),
+			WithAggField("u", AggSum, AggMin, AggMax, AggAvg),
+			WithAggField("mixed", AggSum, AggMax))
+		require.NoError(t, err)
+
+		const big = int64(1) << 60 // beyond float64 precision
+
+		for i := int64(1); i <= 3; i++ {
+			var kvs KVs
+			kvs = kvs.Add("i", big+i).
+				Add("u", uint64(big)*4+uint64(i)). // sum beyond int64
+				Add("mixed", i)
+			if i == 3 {
+				kvs = kvs.Set("mixed", 1.5)
+			}
+			a.Add(NewPoint("abc", kvs, WithTime(base.Add(time.Duration(i)*time.Second))))
+		}
+
+		pts := a.FlushAll()
+		require.Len(t, pts, 1)
+		pt := pts[0]
+
+		assert.Equal(t, 3*big+6, pt.Get("i_sum"))
+		assert.Equal(t, big+1, pt.Get("i_min"))
+		assert.Equal(t, big+3, pt.Get("i_max"))
+
+		assert.Equal(t, uint64(big)*4+1, pt.Get("u_min"))
+		assert.Equal(t, uint64(big)*4+3, pt.Get("u_max"))
+		assert.Equal(t, uint64(big)*12+6, pt.Get("u_sum"))
+		assert.IsType(t, float64(0), pt.Get("u_avg"))
+
+		// mixed int and float rolled up as float
+		assert.Equal(t, 4.5, pt.Get("mixed_sum"))
+		assert.Equal(t, 2.0, pt.Get("mixed_max"))
+	})
+
+	t.Run(`integer-overflow`, func(t *T.T) {
+		a, err := NewAggregator(WithAggWindow(time.Minute),
+			WithAggField("i", AggSum, AggMax),
+			WithAggField("neg", AggSum),
+			WithAggField("u", AggSum, AggMax))
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			var kvs KVs
+			kvs = kvs.Add("i", int64(math.MaxInt64)).
+				Add("neg", int64(math.MinInt64)).
+				Add("u", uint64(math.MaxUint64))
+			a.Add(NewPoint("abc", kvs, WithTime(base.Add(time.Duration(i)*time.Second))))
+		}
+
+		pts := a.FlushAll()
+		require.Len(t, pts, 1)
+		pt := pts[0]
+
+		// overflowed sum fallback to float, min/max still integers
+		assert.Equal(t, 2*float64(math.MaxInt64), pt.Get("i_sum"))
+		assert.Equal(t, int64(math.MaxInt64), pt.Get("i_max"))
+		assert.Equal(t, 2*float64(math.MinInt64), pt.Get("neg"))
+		assert.Equal(t, 2*float64(math.MaxUint64), pt.Get("u_sum"))
+		assert.Equal(t, uint64(math.MaxUint64), pt.Get("u_max"))
+	})
+
+	t.Run(`time-series`, func(t *T.T) {
+		a, err := NewAggregator(WithAggWindow(time.Minute))
+		require.NoError(t, err)
+
+		// fields of the same measurement and tags from different points
+		a.Add(NewPoint("abc", KVs{NewKV("f1", 1.0, WithKVType(GAUGE))}.AddTag("host", "h1"), WithTime(base)))
+		a.Add(NewPoint("abc", KVs{NewKV("f2", 2.0, WithKVType(GAUGE))}.AddTag("host", "h1"), WithTime(base)))
+		a.Add(NewPoint("abc", KVs{NewKV("f1", 3.0, WithKVType(GAUGE))}.AddTag("host", "h1"), WithTime(base)))
+
+		pts := a.FlushAll()
+		require.Len(t, pts, 1)
+		assert.Equal(t, 2.0, pts[0].Get("f1"))
+		assert.Equal(t, 2.0, pts[0].Get("f2"))
+	})
+
+	t.Run(`grace-period`, func(t *T.T) {
+		a, err := NewAggregator(WithAggWindow(10*time.Second), WithAggGrace(5*time.Second))
+		require.NoError(t, err)
+
+		add := func(sec int, v float64) {
+			a.Add(NewPoint("abc", KVs{NewKV("f1", v, WithKVType(GAUGE))},
+				WithTime(base.Add(time.Duration(sec)*time.Second))))
+		}
+
+		add(1, 1)
+		add(11, 100) // next window
+
+		assert.Empty(t, a.Flush(base.Add(12*time.Second)))
+
+		add(9, 3) // late, but within grace period
+
+		pts := a.Flush(base.Add(15 * time.Second))
+		require.Len(t, pts, 1)
+		assert.Equal(t, 2.0, pts[0].Get("f1"))
+
+		add(8, 5) // window flushed, dropped
+		assert.Equal(t, int64(1), a.Dropped())
+
+		pts = a.FlushAll()
+		require.Len(t, pts, 1)
+		assert.Equal(t, base.Add(10*time.Second), pts[0].Time())
+		assert.Equal(t, 100.0, pts[0].Get("f1"))
+
+		add(19, 7) // window flushed by FlushAll, dropped
+		assert.Equal(t, int64(2), a.Dropped())
+		assert.Empty(t, a.FlushAll())
+	})
+
+	t.Run(`invalid-func`, func(t *T.T) {
+		_, err := NewAggregator(WithAggField("f1", "median"))
+		assert.Error(t, err)
+
+		_, err = NewAggregator(WithAggDefaultFuncs(AggPercentile(0)))
+		assert.Error(t, err)
+
+		_, err = NewAggregator(WithAggWindow(0))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,468 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package point
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/ipc"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	types "github.com/gogo/protobuf/types"
+)
+
+// Arrow payload is a sequence of Arrow IPC streams, each stream hold a
+// single measurement: the schema metadata is the measurement name, column
+// time is the point's timestamp(in nanosecond), other columns are tags and
+// fields. Tag values are dictionary-encoded, field columns are typed by their
+// KeyType. Keys not exist in some point are null in the column.
+//
+// Same key with different type/unit/metric-type are different columns, the
+// first one named by the key, others named <key>#<n> with the real key in
+// the column metadata, so column names within the schema are unique.
+const (
+	arrowColTime = "time"
+
+	arrowMetaMeasurement = "measurement"
+	arrowMetaKey         = "key"
+	arrowMetaKeyType     = "key_type"
+	arrowMetaTag         = "tag"
+	arrowMetaUnit        = "unit"
+	arrowMetaMetricType  = "metric_type"
+)
+
+var arrowTagType = &arrow.DictionaryType{
+	IndexType: arrow.PrimitiveTypes.Int32,
+	ValueType: arrow.BinaryTypes.String,
+}
+
+// arrowColumn is a tag or field column within the record batch. Same key with
+// different type/unit/metric-type are different columns.
+type arrowColumn struct {
+	key   string
+	t     KeyType
+	isTag bool
+	unit  string
+	mtype MetricType
+}
+
+func newArrowColumn(kv *Field) arrowColumn {
+	return arrowColumn{
+		key:   kv.Key,
+		t:     PBType(kv.Val),
+		isTag: kv.IsTag,
+		unit:  kv.Unit,
+		mtype: kv.Type,
+	}
+}
+
+func (c *arrowColumn) dataType() arrow.DataType {
+	switch c.t { //nolint:exhaustive
+	case I:
+		return arrow.PrimitiveTypes.Int64
+	case U:
+		return arrow.PrimitiveTypes.Uint64
+	case F:
+		return arrow.PrimitiveTypes.Float64
+	case B:
+		return arrow.FixedWidthTypes.Boolean
+	case S:
+		if c.isTag {
+			return arrowTagType
+		}
+		return arrow.BinaryTypes.String
+	default: // D and A
+		return arrow.BinaryTypes.Binary
+	}
+}
+
+func (c *arrowColumn) field() arrow.Field {
+	keys := []string{arrowMetaKeyType}
+	vals := []string{c.t.String()}
+
+	if c.isTag {
+		keys = append(keys, arrowMetaTag)
+		vals = append(vals, "true")
+	}
+
+	if c.unit != "" {
+		keys = append(keys, arrowMetaUnit)
+		vals = append(vals, c.unit)
+	}
+
+	if c.mtype != UNSPECIFIED {
+		keys = append(keys, arrowMetaMetricType)
+		vals = append(vals, c.mtype.String())
+	}
+
+	return arrow.Field{
+		Name:     c.key,
+		Type:     c.dataType(),
+		Nullable: true,
+		Metadata: arrow.NewMetadata(keys, vals),
+	}
+}
+
+// arrowColumnOf restore column info from schema field.
+func arrowColumnOf(f *arrow.Field) (*arrowColumn, error) {
+	c := &arrowColumn{key: f.Name}
+
+	if x, ok := f.Metadata.GetValue(arrowMetaKey); ok {
+		c.key = x
+	}
+
+	kt, ok := f.Metadata.GetValue(arrowMetaKeyType)
+	if !ok {
+		return nil, fmt.Errorf("column %q: missing %s", f.Name, arrowMetaKeyType)
+	}
+
+	if x, ok := KeyType_value[kt]; ok {
+		c.t = KeyType(x)
+	} else {
+		return nil, fmt.Errorf("column %q: invalid key type %q", f.Name, kt)
+	}
+
+	if x, ok := f.Metadata.GetValue(arrowMetaTag); ok {
+		c.isTag, _ = strconv.ParseBool(x)
+	}
+
+	c.unit, _ = f.Metadata.GetValue(arrowMetaUnit)
+
+	if x, ok := f.Metadata.GetValue(arrowMetaMetricType); ok {
+		c.mtype = MetricType(MetricType_value[x])
+	}
+
+	return c, nil
+}
+
+// arrowBatch is points within the same measurement.
+type arrowBatch struct {
+	name string
+	pts  []*Point
+	cols []arrowColumn
+	idx  map[arrowColumn]int
+}
+
+func (b *arrowBatch) add(pt *Point) {
+	b.pts = append(b.pts, pt)
+
+	for _, kv := range pt.pt.Fields {
+		c := newArrowColumn(kv)
+		if c.t == X || c.t == NIL {
+			continue // ignored
+		}
+
+		if _, ok := b.idx[c]; !ok {
+			b.idx[c] = len(b.cols)
+			b.cols = append(b.cols, c)
+		}
+	}
+}
+
+func (b *arrowBatch) schema() *arrow.Schema {
+	fields := []arrow.Field{
+		{Name: arrowColTime, Type: arrow.FixedWidthTypes.Timestamp_ns},
+	}
+
+	names := b.columnNames()
+	for i := range b.cols {
+		f := b.cols[i].field()
+
+		if names[i] != f.Name {
+			f.Metadata = arrow.NewMetadata(
+				append(f.Metadata.Keys(), arrowMetaKey),
+				append(f.Metadata.Values(), f.Name))
+			f.Name = names[i]
+		}
+
+		fields = append(fields, f)
+	}
+
+	md := arrow.NewMetadata([]string{arrowMetaMeasurement}, []string{b.name})
+	return arrow.NewSchema(fields, &md)
+}
+
+// columnNames get unique name of each column, the first column of the key
+// named by the key, others named <key>#<n>.
+func (b *arrowBatch) columnNames() []string {
+	var (
+		names = make([]string, len(b.cols))
+		used  = map[string]bool{arrowColTime: true}
+	)
+
+	for i := range b.cols {
+		if k := b.cols[i].key; !used[k] {
+			names[i] = k
+			used[k] = true
+		}
+	}
+
+	for i := range b.cols {
+		if names[i] != "" {
+			continue
+		}
+
+		for n := 1; ; n++ {
+			if x := fmt.Sprintf("%s#%d", b.cols[i].key, n); !used[x] {
+				names[i] = x
+				used[x] = true
+				break
+			}
+		}
+	}
+
+	return names
+}
+
+func (b *arrowBatch) record(mem memory.Allocator, schema *arrow.Schema) (arrow.Record, error) {
+	rb := array.NewRecordBuilder(mem, schema)
+	defer rb.Release()
+
+	row := make([]*Field, len(b.cols))
+
+	for _, pt := range b.pts {
+		rb.Field(0).(*array.TimestampBuilder).Append(arrow.Timestamp(pt.pt.Time))
+
+		for i := range row {
+			row[i] = nil
+		}
+
+		for _, kv := range pt.pt.Fields {
+			if i, ok := b.idx[newArrowColumn(kv)]; ok {
+				row[i] = kv
+			}
+		}
+
+		for i, kv := range row {
+			if err := arrowAppend(rb.Field(i+1), kv); err != nil {
+				return nil, fmt.Errorf("column %q: %w", b.cols[i].key, err)
+			}
+		}
+	}
+
+	return rb.NewRecord(), nil
+}
+
+func arrowAppend(b array.Builder, kv *Field) error {
+	if kv == nil {
+		b.AppendNull()
+		return nil
+	}
+
+	switch x := kv.Val.(type) {
+	case *Field_I:
+		b.(*array.Int64Builder).Append(x.I)
+	case *Field_U:
+		b.(*array.Uint64Builder).Append(x.U)
+	case *Field_F:
+		b.(*array.Float64Builder).Append(x.F)
+	case *Field_B:
+		b.(*array.BooleanBuilder).Append(x.B)
+	case *Field_D:
+		b.(*array.BinaryBuilder).Append(x.D)
+	case *Field_S:
+		if db, ok := b.(*array.BinaryDictionaryBuilder); ok {
+			return db.AppendString(x.S)
+		}
+		b.(*array.StringBuilder).Append(x.S)
+	case *Field_A:
+		data, err := x.A.Marshal()
+		if err != nil {
+			return err
+		}
+		b.(*array.BinaryBuilder).Append(data)
+	default:
+		b.AppendNull()
+	}
+
+	return nil
+}
+
+// arrowMarshal encode pts into Arrow IPC streams, one record batch per measurement.
+func arrowMarshal(pts []*Point) ([]byte, error) {
+	var (
+		mem     = memory.NewGoAllocator()
+		batches []*arrowBatch
+		names   = map[string]*arrowBatch{}
+		buf     bytes.Buffer
+	)
+
+	for _, pt := range pts {
+		if pt == nil {
+			continue
+		}
+
+		b, ok := names[pt.pt.Name]
+		if !ok {
+			b = &arrowBatch{name: pt.pt.Name, idx: map[arrowColumn]int{}}
+			names[pt.pt.Name] = b
+			batches = append(batches, b)
+		}
+
+		b.add(pt)
+	}
+
+	for _, b := range batches {
+		schema := b.schema()
+
+		rec, err := b.record(mem, schema)
+		if err != nil {
+			return nil, err
+		}
+
+		w := ipc.NewWriter(&buf, ipc.WithSchema(schema), ipc.WithAllocator(mem))
+		err = w.Write(rec)
+		rec.Release()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// arrowUnmarshal decode points from Arrow IPC streams.
+func arrowUnmarshal(data []byte) ([]*Point, error) {
+	var (
+		mem = memory.NewGoAllocator()
+		r   = bytes.NewReader(data)
+		pts []*Point
+	)
+
+	for r.Len() > 0 {
+		if err := arrowReadStream(r, mem, func(pt *Point, err error) error {
+			if err != nil {
+				return err
+			}
+
+			pts = append(pts, pt)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return pts, nil
+}
+
+// arrowReadStream read a single Arrow IPC stream from r, fn called on each
+// row(point) of the stream, row conversion error passed to fn, if fn return
+// error, the read terminated.
+func arrowReadStream(r io.Reader, mem memory.Allocator, fn func(*Point, error) error) error {
+	rdr, err := ipc.NewReader(r, ipc.WithAllocator(mem))
+	if err != nil {
+		return err
+	}
+	defer rdr.Release()
+
+	schema := rdr.Schema()
+
+	name, _ := schema.Metadata().GetValue(arrowMetaMeasurement)
+
+	if len(schema.Fields()) == 0 || schema.Field(0).Name != arrowColTime {
+		return fmt.Errorf("measurement %q: missing column %s", name, arrowColTime)
+	}
+
+	var cols []*arrowColumn
+	for i := 1; i < len(schema.Fields()); i++ {
+		f := schema.Field(i)
+		c, err := arrowColumnOf(&f)
+		if err != nil {
+			return err
+		}
+		cols = append(cols, c)
+	}
+
+	for rdr.Next() {
+		rec := rdr.Record()
+
+		ts, ok := rec.Column(0).(*array.Timestamp)
+		if !ok {
+			return fmt.Errorf("measurement %q: invalid column %s type %s",
+				name, arrowColTime, rec.Column(0).DataType())
+		}
+
+		for i := 0; i < int(rec.NumRows()); i++ {
+			if err := fn(arrowRowPoint(name, cols, rec, int64(ts.Value(i)), i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return rdr.Err()
+}
+
+// arrowRowPoint get point from the i-th row of rec.
+func arrowRowPoint(name string, cols []*arrowColumn, rec arrow.Record, ts int64, i int) (*Point, error) {
+	pbpt := &PBPoint{Name: name, Time: ts}
+
+	for j, c := range cols {
+		val, err := arrowValue(rec.Column(j+1), i, c)
+		if err != nil {
+			return nil, fmt.Errorf("measurement %q: column %q: %w", name, c.key, err)
+		}
+
+		if val == nil {
+			continue
+		}
+
+		pbpt.Fields = append(pbpt.Fields, &Field{
+			Key:   c.key,
+			Val:   val,
+			IsTag: c.isTag,
+			Type:  c.mtype,
+			Unit:  c.unit,
+		})
+	}
+
+	pt := &Point{pt: pbpt}
+	pt.SetFlag(Ppb)
+	return pt, nil
+}
+
+func arrowValue(col arrow.Array, i int, c *arrowColumn) (isField_Val, error) {
+	if col.IsNull(i) {
+		return nil, nil
+	}
+
+	switch x := col.(type) {
+	case *array.Int64:
+		return &Field_I{I: x.Value(i)}, nil
+	case *array.Uint64:
+		return &Field_U{U: x.Value(i)}, nil
+	case *array.Float64:
+		return &Field_F{F: x.Value(i)}, nil
+	case *array.Boolean:
+		return &Field_B{B: x.Value(i)}, nil
+	case *array.String:
+		return &Field_S{S: string([]byte(x.Value(i)))}, nil // copy out of arrow buffer
+	case *array.Binary:
+		if c.t == A {
+			var a types.Any
+			if err := a.Unmarshal(x.Value(i)); err != nil {
+				return nil, err
+			}
+			return &Field_A{A: &a}, nil
+		}
+		return &Field_D{D: append([]byte(nil), x.Value(i)...)}, nil
+	case *array.Dictionary:
+		dict, ok := x.Dictionary().(*array.String)
+		if !ok {
+			return nil, fmt.Errorf("invalid dictionary type %s", x.Dictionary().DataType())
+		}
+		return &Field_S{S: string([]byte(dict.Value(x.GetValueIndex(i))))}, nil
+	default:
+		return nil, fmt.Errorf("unsupported column type %s", col.DataType())
+	}
+}
@@ -0,0 +1,190 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package point
+
+import (
+	"bytes"
+	"fmt"
+	T "testing"
+	"time"
+
+	"github.com/apache/arrow/go/v12/arrow/ipc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func arrowRoundTrip(t *T.T, pts []*Point, opts ...EncoderOption) []*Point {
+	t.Helper()
+
+	enc := GetEncoder(append([]EncoderOption{WithEncEncoding(Arrow)}, opts...)...)
+	defer PutEncoder(enc)
+
+	batches, err := enc.Encode(pts)
+	require.NoError(t, err)
+
+	dec := GetDecoder(WithDecEncoding(Arrow))
+	defer PutDecoder(dec)
+
+	var res []*Point
+	for _, b := range batches {
+		arr, err := dec.Decode(b)
+		require.NoError(t, err)
+		res = append(res, arr...)
+	}
+
+	return res
+}
+
+func TestArrowEncoding(t *T.T) {
+	t.Run(`content-type`, func(t *T.T) {
+		assert.Equal(t, Arrow, HTTPContentType(Arrow.HTTPContentType()))
+		assert.Equal(t, Arrow, EncodingStr(Arrow.String()))
+		assert.Equal(t, "application/vnd.apache.arrow.stream", Arrow.HTTPContentType())
+	})
+
+	t.Run(`basic-types`, func(t *T.T) {
+		ts := time.Unix(0, 123)
+		kvs := NewKVs(map[string]any{
+			"i":   int64(-1),
+			"u":   uint64(1),
+			"f":   3.14,
+			"b":   true,
+			"d":   []byte("raw-bytes"),
+			"s":   "str-value",
+			"arr": []string{"s1", "s2"},
+		}).AddTag("t1", "tv1").AddTag("t2", "tv2")
+
+		kvs = kvs.Add("latency", int64(100), WithKVUnit("ms"), WithKVType(GAUGE))
+
+		pt := NewPoint("abc", kvs, WithTime(ts))
+
+		res := arrowRoundTrip(t, []*Point{pt})
+		require.Len(t, res, 1)
+
+		eq, reason := pt.EqualWithReason(res[0])
+		assert.True(t, eq, "reason: %s\n%s\n%s", reason, pt.Pretty(), res[0].Pretty())
+
+		assert.Equal(t, "ms", res[0].Fields().Get("latency").Unit)
+		assert.Equal(t, GAUGE, res[0].Fields().Get("latency").Type)
+	})
+
+	t.Run(`multi-measurements`, func(t *T.T) {
+		var pts []*Point
+		for i := 0; i < 10; i++ {
+			pts = append(pts, NewPoint(fmt.Sprintf("m-%d", i%3),
+				NewKVs(map[string]any{"f1": int64(i), "f2": float64(i)}).
+					AddTag("host", fmt.Sprintf("host-%d", i%2)),
+				WithTime(time.Unix(0, int64(i+1)))))
+		}
+
+		res := arrowRoundTrip(t, pts)
+		require.Len(t, res, len(pts))
+
+		// points grouped by measurement
+		got := map[string][]*Point{}
+		for _, pt := range res {
+			got[pt.Name()] = append(got[pt.Name()], pt)
+		}
+
+		idx := map[string]int{}
+		for _, pt := range pts {
+			x := got[pt.Name()][idx[pt.Name()]]
+			idx[pt.Name()]++
+
+			eq, reason := pt.EqualWithReason(x)
+			assert.True(t, eq, "reason: %s", reason)
+		}
+	})
+
+	t.Run(`sparse-and-conflict-keys`, func(t *T.T) {
+		pts := []*Point{
+			NewPoint("abc", NewKVs(map[string]any{"f1": int64(1)}).AddTag("t1", "v1"), WithTime(time.Unix(0, 1))),
+			NewPoint("abc", NewKVs(map[string]any{"f2": "hello"}).AddTag("t2", "v2"), WithTime(time.Unix(0, 2))),
+			NewPoint("abc", NewKVs(map[string]any{"f1": 1.0}), WithTime(time.Unix(0, 3))), // f1 as float
+			NewPoint("abc", NewKVs(map[string]any{"f1": int64(2), "f1#1": "x"}).
+				SetKV(NewKV("f1", int64(3), WithKVUnit("byte"))), WithTime(time.Unix(0, 4))), // f1 with unit
+		}
+
+		res := arrowRoundTrip(t, pts)
+		require.Len(t, res, len(pts))
+
+		for i := range pts {
+			eq, reason := pts[i].EqualWithReason(res[i])
+			assert.True(t, eq, "reason: %s", reason)
+		}
+
+		// column names are unique within the schema
+		data, err := arrowMarshal(pts)
+		require.NoError(t, err)
+
+		rdr, err := ipc.NewReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		defer rdr.Release()
+
+		names := map[string]bool{}
+		for _, f := range rdr.Schema().Fields() {
+			assert.False(t, names[f.Name], "duplicated column %q", f.Name)
+			names[f.Name] = true
+		}
+		assert.Len(t, names, 8) // time, t1, t2, f1(i), f2, f1(f), f1#1, f1(i, byte)
+	})
+
+	t.Run(`rand-points`, func(t *T.T) {
+		r := NewRander(WithFixedTags(true), WithRandText(3))
+		pts := r.Rand(100)
+
+		for _, pt := range pts {
+			pt.MustAdd("i-arr", []int{1, 2})
+			pt.MustAdd("f-arr", []float64{1.414, 3.14})
+		}
+
+		res := arrowRoundTrip(t, pts, WithEncBatchSize(30))
+		require.Len(t, res, len(pts))
+
+		for i := range pts {
+			eq, reason := pts[i].EqualWithReason(res[i])
+			assert.True(t, eq, "reason: %s", reason)
+		}
+	})
+
+	t.Run(`invalid-payload`, func(t *T.T) {
+		dec := GetDecoder(WithDecEncoding(Arrow))
+		defer PutDecoder(dec)
+
+		_, err := dec.Decode([]byte("not-arrow-data"))
+		assert.Error(t, err)
+	})
+}
+
+func BenchmarkArrowEncode(b *T.B) {
+	r := NewRander(WithFixedKeys(true), WithFixedTags(true))
+	pts := r.Rand(1000)
+	for _, pt := range pts {
+		pt.pt.Name = "bench" // columnar encoding works on the same measurement
+	}
+
+	b.Run(`arrow`, func(b *T.B) {
+		enc := GetEncoder(WithEncEncoding(Arrow))
+		defer PutEncoder(enc)
+
+		for i := 0; i < b.N; i++ {
+			if _, err := enc.Encode(pts); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run(`protobuf`, func(b *T.B) {
+		enc := GetEncoder(WithEncEncoding(Protobuf))
+		defer PutEncoder(enc)
+
+		for i := 0; i < b.N; i++ {
+			if _, err := enc.Encode(pts); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -37,6 +37,12 @@ type Decoder struct {
 
 	easyproto bool
 
+	// For stream decoding.
+	batchSize     int
+	maxPointBytes int
+	errFn         DecodeErrFn
+	recycle       bool
+
 	// For line-protocol parsing, keep original error.
 	detailedError error
 }
@@ -72,6 +78,10 @@ func (d *Decoder) reset() {
 	d.fn = nil
 	d.detailedError = nil
 	d.easyproto = false
+	d.batchSize = 0
+	d.maxPointBytes = 0
+	d.errFn = nil
+	d.recycle = false
 }
 
 // nolint: gocritic
@@ -129,6 +139,27 @@ func (d *Decoder) doDecode(data []byte, c *cfg) ([]*Point, error) {
 			}
 		}
 
+	case ProtobufDict:
+		if d.easyproto {
+			pts, err = unmarshalDictPoints(data)
+		} else {
+			pts, err = dictUnmarshal(data)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+	case Arrow:
+		if pts, err = arrowUnmarshal(data); err != nil {
+			return nil, err
+		}
+
+	case RemoteWrite:
+		if pts, err = decodeRemoteWrite(data); err != nil {
+			return nil, err
+		}
+
 	case LineProtocol:
 		pts, err = parseLPPoints(data, c)
 		if err != nil {
@@ -143,6 +174,33 @@ func (d *Decoder) doDecode(data []byte, c *cfg) ([]*Point, error) {
 	return pts, err
 }
 
+// adjustPointTime set point's time to nowNano if not set, or adjust
+// the time to nano-second according to precision.
+func adjustPointTime(pt *Point, c *cfg, nowNano int64) {
+	// use current time
+	if pt.pt.Time == 0 {
+		pt.pt.Time = nowNano
+	} else { // adjust point's timestamp
+		switch c.precision {
+		case PrecDyn:
+			pt.pt.Time = detectTimestampPrecision(pt.pt.Time)
+		case PrecUS:
+			pt.pt.Time *= int64(time.Microsecond)
+		case PrecMS:
+			pt.pt.Time *= int64(time.Millisecond)
+		case PrecS:
+			pt.pt.Time *= int64(time.Second)
+		case PrecM:
+			pt.pt.Time *= int64(time.Minute)
+		case PrecH:
+			pt.pt.Time *= int64(time.Hour)
+		case PrecNS: // pass
+		case PrecW, PrecD: // not used
+		default: // pass
+		}
+	}
+}
+
 func decodeAdjustPoints(pts []*Point, c *cfg) ([]*Point, error) {
 	var (
 		chk       *checker
@@ -162,28 +220,7 @@ func decodeAdjustPoints(pts []*Point, c *cfg) ([]*Point, error) {
 
 	// adjust and check the point.
 	for idx, pt := range pts {
-		// use current time
-		if pt.pt.Time == 0 {
-			pt.pt.Time = nowNano
-		} else { // adjust point's timestamp
-			switch c.precision {
-			case PrecDyn:
-				pt.pt.Time = detectTimestampPrecision(pt.pt.Time)
-			case PrecUS:
-				pt.pt.Time *= int64(time.Microsecond)
-			case PrecMS:
-				pt.pt.Time *= int64(time.Millisecond)
-			case PrecS:
-				pt.pt.Time *= int64(time.Second)
-			case PrecM:
-				pt.pt.Time *= int64(time.Minute)
-			case PrecH:
-				pt.pt.Time *= int64(time.Hour)
-			case PrecNS: // pass
-			case PrecW, PrecD: // not used
-			default: // pass
-			}
-		}
+		adjustPointTime(pt, c, nowNano)
 
 		if c.precheck {
 			pts[idx] = chk.check(pts[idx])
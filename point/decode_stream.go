@@ -0,0 +1,602 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package point
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/golang/snappy"
+)
+
+const (
+	defaultStreamBatchSize = 1000
+	defaultMaxPointBytes   = 32 * 1024 * 1024
+)
+
+// DecodeErrFn used to report error on a single point during stream decoding,
+// the decoding will go on after the error reported.
+type DecodeErrFn func(err error)
+
+// PointDecodeError is the error on a single point within the stream.
+type PointDecodeError struct {
+	// Position of the point within the stream(start from 1): line number
+	// for line-protocol, sequence number for other encodings.
+	Pos int
+	Err error
+}
+
+func (e *PointDecodeError) Error() string {
+	return fmt.Sprintf("point #%d: %s", e.Pos, e.Err)
+}
+
+func (e *PointDecodeError) Unwrap() error {
+	return e.Err
+}
+
+var errPointTooLarge = errors.New("point too large")
+
+// WithDecBatchSize set max points passed to the DecodeFn on stream decoding.
+func WithDecBatchSize(n int) DecoderOption {
+	return func(d *Decoder) { d.batchSize = n }
+}
+
+// WithDecMaxPointBytes set max bytes of a single point(a single line for
+// line-protocol) on stream decoding, too large point are skipped.
+func WithDecMaxPointBytes(n int) DecoderOption {
+	return func(d *Decoder) { d.maxPointBytes = n }
+}
+
+// WithDecErrFn set callback on bad point during stream decoding.
+func WithDecErrFn(fn DecodeErrFn) DecoderOption {
+	return func(d *Decoder) { d.errFn = fn }
+}
+
+// WithDecRecycle put points back to the point pool(if set) after the
+// DecodeFn returned on stream decoding, so the DecodeFn should not hold these
+// points after it returned.
+func WithDecRecycle(on bool) DecoderOption {
+	return func(d *Decoder) { d.recycle = on }
+}
+
+// streamDecoder hold states during a single DecodeReader() call.
+type streamDecoder struct {
+	d *Decoder
+	c *cfg
+
+	chk     *checker
+	nowNano int64
+
+	batch []*Point
+	pos   int // position of current point
+}
+
+// reportErr report bad point, nil error ignored.
+func (sd *streamDecoder) reportErr(err error) {
+	if err != nil && sd.d.errFn != nil {
+		sd.d.errFn(&PointDecodeError{Pos: sd.pos, Err: err})
+	}
+}
+
+// add adjust and check the point(same as Decode), then add it to current batch.
+func (sd *streamDecoder) add(pt *Point) error {
+	adjustPointTime(pt, sd.c, sd.nowNano)
+
+	if sd.chk != nil {
+		pt = sd.chk.check(pt)
+		sd.chk.reset()
+	}
+
+	if sd.c.callback != nil {
+		x, err := sd.c.callback(pt)
+		if err != nil {
+			sd.reportErr(err)
+			return nil
+		}
+
+		if x == nil { // point dropped
+			return nil
+		}
+		pt = x
+	}
+
+	sd.batch = append(sd.batch, pt)
+	if len(sd.batch) >= sd.d.batchSize {
+		return sd.flush()
+	}
+
+	return nil
+}
+
+func (sd *streamDecoder) flush() error {
+	if len(sd.batch) == 0 {
+		return nil
+	}
+
+	if err := sd.d.fn(sd.batch); err != nil {
+		return err
+	}
+
+	if sd.d.recycle && defaultPTPool != nil {
+		for _, pt := range sd.batch {
+			defaultPTPool.Put(pt)
+		}
+		sd.batch = sd.batch[:0]
+	} else {
+		sd.batch = nil // the DecodeFn may hold the batch
+	}
+
+	return nil
+}
+
+// lineScanner track quotes and escapes within a line-protocol point, so that
+// raw '\n' within quoted string fields do not end the point. It's the
+// incremental version of scanLine() in influxdb1-client/models.
+type lineScanner struct {
+	escaped,
+	quoted,
+	fields bool
+
+	equals,
+	commas,
+	brackets int
+}
+
+// scan feed frag into the scanner, return true if frag ends the point.
+func (ls *lineScanner) scan(frag []byte) bool {
+	for _, c := range frag {
+		if ls.escaped {
+			ls.escaped = false
+			continue
+		}
+
+		if c == '\\' {
+			ls.escaped = true
+			continue
+		}
+
+		if c == ' ' {
+			ls.fields = true
+		}
+
+		if ls.fields {
+			switch {
+			case !ls.quoted && c == '=':
+				ls.equals++
+				continue
+			case !ls.quoted && c == ',':
+				if ls.brackets == 0 {
+					ls.commas++
+				}
+				continue
+			case !ls.quoted && c == '[':
+				ls.brackets++
+				continue
+			case !ls.quoted && c == ']':
+				ls.brackets--
+				continue
+			case c == '"' && ls.equals > ls.commas:
+				ls.quoted = !ls.quoted
+				continue
+			}
+		}
+
+		if c == '\n' && !ls.quoted {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readLine read next point from br(without the tailing '\n'). The point may
+// span multiple lines if there are '\n' within quoted string fields. If the
+// point exceed max bytes, the point is dropped and errPointTooLarge returned.
+func readLine(br *bufio.Reader, maxBytes int) ([]byte, error) {
+	var (
+		line    []byte
+		tooLong bool
+		ls      lineScanner
+	)
+
+	for {
+		frag, err := br.ReadSlice('\n')
+		if !tooLong {
+			if len(line)+len(frag) > maxBytes+1 { // +1 for the '\n'
+				tooLong, line = true, nil
+			} else {
+				line = append(line, frag...)
+			}
+		}
+
+		end := ls.scan(frag)
+
+		switch {
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case err == nil && !end: // '\n' within quoted string
+			continue
+		case err != nil && !(errors.Is(err, io.EOF) && (len(line) > 0 || tooLong)):
+			return nil, err
+		}
+
+		if tooLong {
+			return nil, errPointTooLarge
+		}
+		return bytes.TrimSuffix(line, []byte("\n")), nil
+	}
+}
+
+func (sd *streamDecoder) decodeLineProtocol(br *bufio.Reader) error {
+	lines := 0 // extra lines within the last point
+
+	for {
+		line, err := readLine(br, sd.d.maxPointBytes)
+		sd.pos += 1 + lines
+		lines = bytes.Count(line, []byte("\n"))
+
+		switch {
+		case errors.Is(err, io.EOF):
+			return nil
+		case errors.Is(err, errPointTooLarge):
+			sd.reportErr(err)
+			continue
+		case err != nil:
+			return err
+		}
+
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		pts, err := parseLPPoints(line, sd.c)
+		if err != nil {
+			sd.reportErr(simplifyLPError(err))
+			continue
+		}
+
+		for _, pt := range pts {
+			if err := sd.add(pt); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// unmarshalPBPoint unmarshal a single PBPoint from data.
+func (sd *streamDecoder) unmarshalPBPoint(data []byte) (*Point, error) {
+	if sd.d.easyproto {
+		return unmarshalPoint(data)
+	}
+
+	var pt *Point
+	if defaultPTPool != nil {
+		pt = defaultPTPool.Get()
+		pt.SetFlag(Ppooled)
+	} else {
+		pt = &Point{pt: &PBPoint{}}
+	}
+
+	if err := pt.pt.Unmarshal(data); err != nil {
+		if defaultPTPool != nil {
+			defaultPTPool.Put(pt)
+		}
+		return nil, err
+	}
+
+	pt.SetFlag(Ppb)
+	return pt, nil
+}
+
+// readPBFields read length-delimited fields of a protobuf message from br one
+// by one, other fields are skipped. The point field(numbered ptField) is
+// counted as a point, too large point are skipped and reported.
+func (sd *streamDecoder) readPBFields(br *bufio.Reader, ptField uint64,
+	fn func(fieldNum uint64, data []byte) error,
+) error {
+	for {
+		key, err := binary.ReadUvarint(br)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		fieldNum, wireType := key>>3, key&0x7
+
+		var n uint64
+		switch wireType {
+		case 0: // varint
+			_, err = binary.ReadUvarint(br)
+		case 1: // fixed64
+			n = 8
+		case 2: // length-delimited
+			n, err = binary.ReadUvarint(br)
+		case 5: // fixed32
+			n = 4
+		default:
+			return fmt.Errorf("unknown wire type %d", wireType)
+		}
+
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+
+		if wireType != 2 { // not message or string, skip it
+			if _, err := br.Discard(int(n)); err != nil {
+				return unexpectedEOF(err)
+			}
+			continue
+		}
+
+		if fieldNum == ptField {
+			sd.pos++
+		}
+
+		if n > uint64(sd.d.maxPointBytes) {
+			if _, err := br.Discard(int(n)); err != nil {
+				return unexpectedEOF(err)
+			}
+
+			if fieldNum != ptField {
+				return fmt.Errorf("field %d: %w", fieldNum, errPointTooLarge)
+			}
+
+			sd.reportErr(errPointTooLarge)
+			continue
+		}
+
+		// NOTE: do not reuse the buffer, easyproto's strings are reference to it.
+		data := make([]byte, n)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return unexpectedEOF(err)
+		}
+
+		if err := fn(fieldNum, data); err != nil {
+			return err
+		}
+	}
+}
+
+// decodeProtobuf read PBPoints from br. Each PBPoint within the PBPoints is a
+// length-delimited field 1, so we can read them one by one.
+func (sd *streamDecoder) decodeProtobuf(br *bufio.Reader) error {
+	return sd.readPBFields(br, 1, func(fieldNum uint64, data []byte) error {
+		if fieldNum != 1 { // not PBPoint
+			return nil
+		}
+
+		pt, err := sd.unmarshalPBPoint(data)
+		if err != nil {
+			sd.reportErr(err)
+			return nil
+		}
+
+		return sd.add(pt)
+	})
+}
+
+// decodeProtobufDict read DictPoints from br. The string table(field 1) are
+// placed before all DictPoint(field 2), so each DictPoint can be decoded once
+// it's read.
+func (sd *streamDecoder) decodeProtobufDict(br *bufio.Reader) error {
+	var strs []string
+
+	return sd.readPBFields(br, 2, func(fieldNum uint64, data []byte) error {
+		switch fieldNum {
+		case 1:
+			strs = append(strs, string(data))
+			return nil
+		case 2: // pass
+		default:
+			return nil
+		}
+
+		var (
+			pt  *Point
+			err error
+		)
+
+		if sd.d.easyproto {
+			pt, err = unmarshalDictPoint(data, strs)
+		} else {
+			var dpt DictPoint
+			if err = dpt.Unmarshal(data); err == nil {
+				var pts []*Point
+				if pts, err = fromDictPoints(&DictPoints{Strs: strs, Arr: []*DictPoint{&dpt}}); err == nil {
+					pt = pts[0]
+				}
+			}
+		}
+
+		if err != nil {
+			sd.reportErr(err)
+			return nil
+		}
+
+		return sd.add(pt)
+	})
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// decodeJSON read JSON array of points from br.
+func (sd *streamDecoder) decodeJSON(br *bufio.Reader) error {
+	dec := json.NewDecoder(br)
+
+	tk, err := dec.Token()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+
+	if tk != json.Delim('[') {
+		return fmt.Errorf("expect JSON array, got %v", tk)
+	}
+
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+
+		sd.pos++
+
+		var pt Point
+		if err := json.Unmarshal(raw, &pt); err != nil {
+			sd.reportErr(err)
+			continue
+		}
+
+		if err := sd.add(&pt); err != nil {
+			return err
+		}
+	}
+
+	_, err = dec.Token() // the tailing ']'
+	return err
+}
+
+// decodeArrow read Arrow IPC streams from br.
+func (sd *streamDecoder) decodeArrow(br *bufio.Reader) error {
+	mem := memory.NewGoAllocator()
+
+	for {
+		if _, err := br.Peek(1); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		if err := arrowReadStream(br, mem, func(pt *Point, err error) error {
+			sd.pos++
+
+			if err != nil {
+				sd.reportErr(err)
+				return nil
+			}
+
+			return sd.add(pt)
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+// decodeRemoteWrite read remote-write payload from br. Snappy block format
+// is not streamable, so the whole payload are read into memory.
+func (sd *streamDecoder) decodeRemoteWrite(br *bufio.Reader) error {
+	limit := int64(snappy.MaxEncodedLen(maxRemoteWriteBytes))
+	data, err := io.ReadAll(io.LimitReader(br, limit+1))
+	if err != nil {
+		return err
+	}
+
+	if int64(len(data)) > limit {
+		return fmt.Errorf("remote-write payload too large: exceed %d bytes", limit)
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	pts, err := decodeRemoteWrite(data)
+	if err != nil {
+		return err
+	}
+
+	for _, pt := range pts {
+		sd.pos++
+		if err := sd.add(pt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DecodeReader decode points from r in streaming, decoded points are passed to
+// the DecodeFn in batches(see WithDecBatchSize), so the whole payload are not
+// required in memory.
+//
+// Bad points(invalid line, bad PBPoint/DictPoint/JSON object/Arrow row, point rejected by
+// the point callback, too large point) are reported to the DecodeErrFn as
+// *PointDecodeError and skipped. The decoding terminated on broken stream, or
+// the DecodeFn returned error.
+func (d *Decoder) DecodeReader(r io.Reader, opts ...Option) error {
+	if d.fn == nil {
+		return fmt.Errorf("DecodeFn not set")
+	}
+
+	if d.batchSize <= 0 {
+		d.batchSize = defaultStreamBatchSize
+	}
+
+	if d.maxPointBytes <= 0 {
+		d.maxPointBytes = defaultMaxPointBytes
+	}
+
+	c := GetCfg(opts...)
+	defer PutCfg(c)
+
+	sd := &streamDecoder{
+		d:       d,
+		c:       c,
+		nowNano: c.timestamp,
+	}
+
+	if sd.nowNano == 0 {
+		sd.nowNano = time.Now().UnixNano()
+	}
+
+	if c.precheck {
+		sd.chk = &checker{cfg: c}
+	}
+
+	br := bufio.NewReader(r)
+
+	var err error
+
+	//nolint:exhaustive
+	switch d.enc {
+	case LineProtocol:
+		err = sd.decodeLineProtocol(br)
+	case Protobuf:
+		err = sd.decodeProtobuf(br)
+	case JSON:
+		err = sd.decodeJSON(br)
+	case Arrow:
+		err = sd.decodeArrow(br)
+	case RemoteWrite:
+		err = sd.decodeRemoteWrite(br)
+	case ProtobufDict:
+		err = sd.decodeProtobufDict(br)
+	default:
+		return fmt.Errorf("not support encode: %s", d.enc)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return sd.flush()
+}
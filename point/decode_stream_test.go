@@ -0,0 +1,255 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package point
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	T "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamDecode decode all points from payload with DecodeReader.
+func streamDecode(t *T.T, enc Encoding, payload []byte, opts ...DecoderOption) (pts []*Point, batches int, errs []error) {
+	t.Helper()
+
+	opts = append([]DecoderOption{
+		WithDecEncoding(enc),
+		WithDecFn(func(arr []*Point) error {
+			pts = append(pts, arr...)
+			batches++
+			return nil
+		}),
+		WithDecErrFn(func(err error) {
+			errs = append(errs, err)
+		}),
+	}, opts...)
+
+	dec := GetDecoder(opts...)
+	defer PutDecoder(dec)
+
+	require.NoError(t, dec.DecodeReader(bytes.NewReader(payload)))
+	return pts, batches, errs
+}
+
+func TestDecodeReader(t *T.T) {
+	r := NewRander(WithFixedTags(true), WithRandText(3))
+	origin := r.Rand(100)
+
+	for _, enc := range []Encoding{LineProtocol, Protobuf, JSON, Arrow, RemoteWrite} {
+		t.Run(enc.String(), func(t *T.T) {
+			pts := origin
+			if enc == RemoteWrite { // only numeric fields supported
+				pts = nil
+				for i := 0; i < 100; i++ {
+					var kvs KVs
+					kvs = kvs.Add("f1", float64(i)).AddTag("host", fmt.Sprintf("host-%d", i))
+					pts = append(pts, NewPoint("abc", kvs, WithTime(time.Unix(int64(i+1), 0))))
+				}
+			}
+
+			encoder := GetEncoder(WithEncEncoding(enc))
+			defer PutEncoder(encoder)
+
+			arr, err := encoder.Encode(pts)
+			require.NoError(t, err)
+			require.Len(t, arr, 1)
+
+			dec := GetDecoder(WithDecEncoding(enc))
+			defer PutDecoder(dec)
+
+			expect, err := dec.Decode(arr[0])
+			require.NoError(t, err)
+
+			got, batches, errs := streamDecode(t, enc, arr[0], WithDecBatchSize(7))
+			assert.Empty(t, errs)
+			assert.Equal(t, (len(expect)+6)/7, batches)
+			require.Len(t, got, len(expect))
+
+			for i := range expect {
+				eq, reason := expect[i].EqualWithReason(got[i])
+				assert.True(t, eq, "reason: %s", reason)
+			}
+		})
+	}
+
+	t.Run(`easyproto`, func(t *T.T) {
+		encoder := GetEncoder(WithEncEncoding(Protobuf))
+		defer PutEncoder(encoder)
+
+		arr, err := encoder.Encode(origin)
+		require.NoError(t, err)
+
+		got, _, errs := streamDecode(t, Protobuf, arr[0], WithDecEasyproto(true))
+		assert.Empty(t, errs)
+		require.Len(t, got, len(origin))
+
+		for i := range origin {
+			eq, reason := origin[i].EqualWithReason(got[i])
+			assert.True(t, eq, "reason: %s", reason)
+		}
+	})
+
+	t.Run(`bad-lines`, func(t *T.T) {
+		payload := strings.Join([]string{
+			"abc f1=1i 123",
+			"",
+			"bad-line",
+			"abc f1=2i 124",
+			"abc f1=3i " + strings.Repeat("9", 64), // too large
+			"abc f1=4i 125",
+		}, "\n")
+
+		got, _, errs := streamDecode(t, LineProtocol, []byte(payload), WithDecMaxPointBytes(32))
+		require.Len(t, got, 3)
+		assert.Equal(t, int64(2), got[1].Get("f1"))
+		assert.Equal(t, int64(4), got[2].Get("f1")) // the last line without '\n'
+
+		require.Len(t, errs, 2)
+
+		var pde *PointDecodeError
+		require.True(t, errors.As(errs[0], &pde))
+		assert.Equal(t, 3, pde.Pos)
+		assert.ErrorIs(t, errs[0], ErrInvalidLineProtocol)
+
+		require.True(t, errors.As(errs[1], &pde))
+		assert.Equal(t, 5, pde.Pos)
+		assert.ErrorIs(t, errs[1], errPointTooLarge)
+	})
+
+	t.Run(`multi-line-string`, func(t *T.T) {
+		lines := []string{
+			`abc message="line1`,
+			`line2",f1=1i 123`,
+			`abc message="line1`,
+			`line2 with \" and , and =",f1=2i 124`,
+		}
+
+		dec := GetDecoder(WithDecEncoding(LineProtocol))
+		defer PutDecoder(dec)
+
+		expect, err := dec.Decode([]byte(strings.Join(lines, "\n")))
+		require.NoError(t, err)
+		require.Len(t, expect, 2)
+
+		payload := strings.Join(append(lines, "bad-line"), "\n")
+		got, _, errs := streamDecode(t, LineProtocol, []byte(payload))
+		require.Len(t, got, 2)
+		for i := range expect {
+			eq, reason := expect[i].EqualWithReason(got[i])
+			assert.True(t, eq, "reason: %s", reason)
+		}
+		assert.Equal(t, "line1\nline2", got[0].Get("message"))
+
+		require.Len(t, errs, 1)
+
+		var pde *PointDecodeError
+		require.True(t, errors.As(errs[0], &pde))
+		assert.Equal(t, 5, pde.Pos) // line number counted across multi-line points
+	})
+
+	t.Run(`bad-pb-point`, func(t *T.T) {
+		var pbpts PBPoints
+		for _, pt := range origin[:3] {
+			pbpts.Arr = append(pbpts.Arr, pt.PBPoint())
+		}
+
+		good, err := pbpts.Marshal()
+		require.NoError(t, err)
+
+		// a broken PBPoint: field 1(length 3) with invalid content
+		payload := append([]byte{0x0a, 0x03, 0xff, 0xff, 0xff}, good...)
+
+		got, _, errs := streamDecode(t, Protobuf, payload)
+		require.Len(t, errs, 1)
+		require.Len(t, got, 3)
+
+		var pde *PointDecodeError
+		require.True(t, errors.As(errs[0], &pde))
+		assert.Equal(t, 1, pde.Pos)
+
+		// truncated stream
+		dec := GetDecoder(WithDecEncoding(Protobuf), WithDecFn(func([]*Point) error { return nil }))
+		defer PutDecoder(dec)
+		assert.Error(t, dec.DecodeReader(bytes.NewReader(good[:len(good)-1])))
+	})
+
+	t.Run(`point-callback`, func(t *T.T) {
+		payload := "abc f1=1i 123\nabc f1=2i 124\nabc f1=3i 125"
+
+		var got []*Point
+		dec := GetDecoder(WithDecEncoding(LineProtocol), WithDecFn(func(arr []*Point) error {
+			got = append(got, arr...)
+			return nil
+		}), WithDecErrFn(func(err error) {
+			assert.Contains(t, err.Error(), "reject f1=2")
+		}))
+		defer PutDecoder(dec)
+
+		require.NoError(t, dec.DecodeReader(strings.NewReader(payload), WithCallback(func(pt *Point) (*Point, error) {
+			switch pt.Get("f1") {
+			case int64(2):
+				return nil, fmt.Errorf("reject f1=2")
+			case int64(3):
+				return nil, nil // dropped
+			default:
+				return pt, nil
+			}
+		})))
+
+		require.Len(t, got, 1)
+		assert.Equal(t, int64(1), got[0].Get("f1"))
+	})
+
+	t.Run(`abort-on-fn-error`, func(t *T.T) {
+		payload := "abc f1=1i 123\nabc f1=2i 124\nabc f1=3i 125"
+
+		n := 0
+		dec := GetDecoder(WithDecEncoding(LineProtocol), WithDecBatchSize(1), WithDecFn(func(arr []*Point) error {
+			n++
+			return fmt.Errorf("stop")
+		}))
+		defer PutDecoder(dec)
+
+		assert.Error(t, dec.DecodeReader(strings.NewReader(payload)))
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run(`recycle`, func(t *T.T) {
+		pp := NewReservedCapPointPool(1000)
+		SetPointPool(pp)
+		defer ClearPointPool()
+
+		encoder := GetEncoder(WithEncEncoding(Protobuf))
+		defer PutEncoder(encoder)
+
+		arr, err := encoder.Encode(origin[:10])
+		require.NoError(t, err)
+
+		var batches [][]*Point
+		dec := GetDecoder(WithDecEncoding(Protobuf),
+			WithDecBatchSize(5),
+			WithDecRecycle(true),
+			WithDecFn(func(pts []*Point) error {
+				batches = append(batches, append([]*Point{}, pts...))
+				return nil
+			}))
+		defer PutDecoder(dec)
+
+		require.NoError(t, dec.DecodeReader(bytes.NewReader(arr[0])))
+		require.Len(t, batches, 2)
+
+		// points of the 2nd batch are reused from the 1st batch
+		for _, pt := range batches[1] {
+			assert.Contains(t, batches[0], pt)
+		}
+	})
+}
@@ -0,0 +1,234 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package point
+
+import (
+	"crypto/md5" //nolint:gosec
+	"fmt"
+	"sync"
+	"time"
+)
+
+const defaultDeltaStaleAfter = 5 * time.Minute
+
+// DeltaOption used to configure the DeltaConverter.
+type DeltaOption func(*DeltaConverter)
+
+// WithDeltaRate convert cumulative counters to per-second rates(RATE), by
+// default they are converted to deltas(DELTA).
+func WithDeltaRate(on bool) DeltaOption {
+	return func(c *DeltaConverter) { c.rate = on }
+}
+
+// WithDeltaStaleAfter set duration after which series not updated are stale,
+// default 5min. Stale series are removed by Expire, and a series updated
+// after stale duration is restarted. Set 0 to disable stale checking.
+func WithDeltaStaleAfter(d time.Duration) DeltaOption {
+	return func(c *DeltaConverter) { c.staleAfter = d }
+}
+
+// deltaState is the last observed sample of a cumulative counter.
+type deltaState struct {
+	kind numKind
+	i    int64
+	u    uint64
+	f    float64
+	ts   int64 // point time
+}
+
+// DeltaConverter convert cumulative COUNT fields(such as prometheus counters)
+// into deltas(DELTA) or per-second rates(RATE), keyed by time series(see
+// Point.TimeSeriesHash). Other fields(include DELTA fields already converted)
+// are not changed, so converting points again is a no-op.
+//
+// For each series:
+//
+//   - the first sample(and the first sample after restarted) only recorded,
+//     the field is removed from the point
+//   - if the value decreased, the counter is reset, the value itself is the delta
+//   - samples not newer than the last one are removed
+type DeltaConverter struct {
+	rate       bool
+	staleAfter time.Duration
+
+	mtx    sync.Mutex
+	series map[string]*deltaState
+}
+
+// NewDeltaConverter create a DeltaConverter.
+func NewDeltaConverter(opts ...DeltaOption) *DeltaConverter {
+	c := &DeltaConverter{
+		staleAfter: defaultDeltaStaleAfter,
+		series:     map[string]*deltaState{},
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(c)
+		}
+	}
+
+	return c
+}
+
+// convert get delta(or rate) of counter f, ok is false if no delta.
+func (c *DeltaConverter) convert(id string, f *Field, ts int64) (*Field, bool) {
+	cur := &deltaState{ts: ts}
+	switch x := f.Val.(type) {
+	case *Field_I:
+		cur.kind, cur.i = numInt, x.I
+	case *Field_U:
+		cur.kind, cur.u = numUint, x.U
+	case *Field_F:
+		cur.kind, cur.f = numFloat, x.F
+	default:
+		return nil, false
+	}
+
+	last, ok := c.series[id]
+	if !ok || last.kind != cur.kind || // new series or value kind changed
+		(c.staleAfter > 0 && ts-last.ts > int64(c.staleAfter)) { // restarted series
+		c.series[id] = cur
+		return nil, false
+	}
+
+	if ts <= last.ts { // out-of-order or duplicated
+		return nil, false
+	}
+
+	// integer counters subtracted as integers to keep precision beyond float64,
+	// if the value decreased, the counter is reset, the value itself is the delta.
+	var delta any
+	switch cur.kind {
+	case numInt:
+		if cur.i >= last.i {
+			delta = cur.i - last.i
+		} else {
+			delta = cur.i
+		}
+	case numUint:
+		if cur.u >= last.u {
+			delta = cur.u - last.u
+		} else {
+			delta = cur.u
+		}
+	case numFloat:
+		if cur.f >= last.f {
+			delta = cur.f - last.f
+		} else {
+			delta = cur.f
+		}
+	case numNone: // unreachable
+	}
+
+	elapsed := time.Duration(ts - last.ts)
+	*last = *cur
+
+	if c.rate {
+		unit := f.Unit
+		if unit != "" {
+			unit += "/s"
+		}
+
+		var v float64
+		switch x := delta.(type) {
+		case int64:
+			v = float64(x)
+		case uint64:
+			v = float64(x)
+		case float64:
+			v = x
+		}
+
+		return NewKV(f.Key, v/elapsed.Seconds(), WithKVUnit(unit), WithKVType(RATE)), true
+	}
+
+	return NewKV(f.Key, delta, WithKVUnit(f.Unit), WithKVType(DELTA)), true
+}
+
+// Convert convert COUNT fields of pts in place. Points without any field left
+// are removed from the result.
+func (c *DeltaConverter) Convert(pts []*Point) []*Point {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	var res []*Point
+
+	for _, pt := range pts {
+		var (
+			kvs    = pt.pt.Fields
+			hash   = pt.hashstr() // measurement and tags
+			idx    = 0
+			nfield = 0
+		)
+
+		for _, f := range kvs {
+			if _, kind := aggValue(f); f.IsTag || f.Type != COUNT || kind == numNone {
+				if !f.IsTag {
+					nfield++
+				}
+
+				kvs[idx] = f
+				idx++
+				continue
+			}
+
+			// same as Point.TimeSeriesHash()
+			id := fmt.Sprintf("%x", md5.Sum(append(hash, []byte(f.Key)...))) //nolint:gosec
+
+			x, ok := c.convert(id, f, pt.pt.Time)
+
+			if defaultPTPool != nil {
+				defaultPTPool.PutKV(f)
+			}
+
+			if ok {
+				kvs[idx] = x
+				idx++
+				nfield++
+			}
+		}
+
+		for j := idx; j < len(kvs); j++ { // remove deleted elems
+			kvs[j] = nil
+		}
+		pt.pt.Fields = kvs[:idx]
+
+		if nfield > 0 {
+			res = append(res, pt)
+		}
+	}
+
+	return res
+}
+
+// Expire remove series not updated within stale duration before now,
+// return the number of removed series.
+func (c *DeltaConverter) Expire(now time.Time) (n int) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.staleAfter <= 0 { // never stale
+		return 0
+	}
+
+	for id, s := range c.series {
+		if now.UnixNano()-s.ts > int64(c.staleAfter) {
+			delete(c.series, id)
+			n++
+		}
+	}
+
+	return n
+}
+
+// Len get number of tracked series.
+func (c *DeltaConverter) Len() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return len(c.series)
+}
@@ -0,0 +1,135 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package point
+
+import (
+	T "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeltaConverter(t *T.T) {
+	base := time.Unix(1000, 0)
+
+	counter := func(sec int, host string, v any) *Point {
+		var kvs KVs
+		kvs = kvs.Add("reqs", v, WithKVType(COUNT), WithKVUnit("count")).
+			Add("cpu", 1.5, WithKVType(GAUGE)).
+			AddTag("host", host)
+
+		return NewPoint("abc", kvs, WithTime(base.Add(time.Duration(sec)*time.Second)))
+	}
+
+	t.Run(`delta`, func(t *T.T) {
+		c := NewDeltaConverter()
+
+		pts := c.Convert([]*Point{counter(0, "h1", 100), counter(0, "h2", 1000)})
+		require.Len(t, pts, 2)
+		for _, pt := range pts {
+			assert.Nil(t, pt.Get("reqs")) // first sample only recorded
+			assert.Equal(t, 1.5, pt.Get("cpu"))
+		}
+
+		pts = c.Convert([]*Point{counter(10, "h1", 150), counter(10, "h2", 1100)})
+		require.Len(t, pts, 2)
+
+		f := pts[0].Fields().Get("reqs")
+		assert.Equal(t, int64(50), f.GetI())
+		assert.Equal(t, DELTA, f.Type)
+		assert.Equal(t, "count", f.Unit)
+		assert.Equal(t, int64(100), pts[1].Get("reqs"))
+
+		// counter reset
+		pts = c.Convert([]*Point{counter(20, "h1", 30)})
+		require.Len(t, pts, 1)
+		assert.Equal(t, int64(30), pts[0].Get("reqs"))
+
+		// out-of-order sample removed
+		pts = c.Convert([]*Point{counter(15, "h1", 40)})
+		require.Len(t, pts, 1)
+		assert.Nil(t, pts[0].Get("reqs"))
+
+		assert.Equal(t, 2, c.Len())
+	})
+
+	t.Run(`convert-again`, func(t *T.T) {
+		c := NewDeltaConverter()
+
+		c.Convert([]*Point{counter(0, "h1", 100)})
+		pts := c.Convert([]*Point{counter(10, "h1", 150)})
+		require.Len(t, pts, 1)
+
+		// converted deltas not converted again
+		pts = c.Convert(pts)
+		require.Len(t, pts, 1)
+		assert.Equal(t, int64(50), pts[0].Get("reqs"))
+		assert.Equal(t, DELTA, pts[0].Fields().Get("reqs").Type)
+	})
+
+	t.Run(`integer-precision`, func(t *T.T) {
+		c := NewDeltaConverter()
+
+		const big = int64(1) << 60 // beyond float64 precision
+
+		c.Convert([]*Point{counter(0, "h1", big), counter(0, "h2", uint64(1)<<63)})
+		pts := c.Convert([]*Point{counter(10, "h1", big+1), counter(10, "h2", uint64(1)<<63+3)})
+		require.Len(t, pts, 2)
+
+		assert.Equal(t, int64(1), pts[0].Get("reqs"))
+		assert.Equal(t, uint64(3), pts[1].Get("reqs"))
+
+		// uint64 counter reset
+		pts = c.Convert([]*Point{counter(20, "h2", uint64(7))})
+		require.Len(t, pts, 1)
+		assert.Equal(t, uint64(7), pts[0].Get("reqs"))
+
+		// value kind changed: restarted
+		pts = c.Convert([]*Point{counter(30, "h2", 10.0)})
+		require.Len(t, pts, 1)
+		assert.Nil(t, pts[0].Get("reqs"))
+	})
+
+	t.Run(`rate`, func(t *T.T) {
+		c := NewDeltaConverter(WithDeltaRate(true))
+
+		c.Convert([]*Point{counter(0, "h1", 100.0)})
+		pts := c.Convert([]*Point{counter(10, "h1", 150.0)})
+		require.Len(t, pts, 1)
+
+		f := pts[0].Fields().Get("reqs")
+		assert.Equal(t, 5.0, f.GetF())
+		assert.Equal(t, RATE, f.Type)
+		assert.Equal(t, "count/s", f.Unit)
+	})
+
+	t.Run(`drop-empty-point`, func(t *T.T) {
+		c := NewDeltaConverter()
+
+		pt := NewPoint("abc", KVs{NewKV("reqs", 1, WithKVType(COUNT))}, WithTime(base))
+		assert.Empty(t, c.Convert([]*Point{pt}))
+
+		pt = NewPoint("abc", KVs{NewKV("reqs", 3, WithKVType(COUNT))}, WithTime(base.Add(time.Second)))
+		pts := c.Convert([]*Point{pt})
+		require.Len(t, pts, 1)
+		assert.Equal(t, int64(2), pts[0].Get("reqs"))
+	})
+
+	t.Run(`stale`, func(t *T.T) {
+		c := NewDeltaConverter(WithDeltaStaleAfter(time.Minute))
+
+		c.Convert([]*Point{counter(0, "h1", 100), counter(50, "h2", 100)})
+
+		// restarted after stale duration: re-recorded
+		pts := c.Convert([]*Point{counter(61, "h1", 200)})
+		require.Len(t, pts, 1)
+		assert.Nil(t, pts[0].Get("reqs"))
+
+		assert.Equal(t, 1, c.Expire(base.Add(111*time.Second))) // h2 expired
+		assert.Equal(t, 1, c.Len())
+	})
+}
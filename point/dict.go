@@ -0,0 +1,527 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package point
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/VictoriaMetrics/easyproto"
+	types "github.com/gogo/protobuf/types"
+)
+
+// dictStrs is the string table of DictPoints.
+type dictStrs struct {
+	strs []string
+	idx  map[string]uint32
+}
+
+func newDictStrs() *dictStrs {
+	return &dictStrs{
+		strs: []string{""},
+		idx:  map[string]uint32{"": 0},
+	}
+}
+
+func (d *dictStrs) index(s string) uint32 {
+	if i, ok := d.idx[s]; ok {
+		return i
+	}
+
+	i := uint32(len(d.strs))
+	d.strs = append(d.strs, s)
+	d.idx[s] = i
+	return i
+}
+
+// dictStr get string by index i within strs.
+func dictStr(strs []string, i uint32) (string, error) {
+	if int(i) >= len(strs) {
+		return "", fmt.Errorf("string index %d out of range(%d)", i, len(strs))
+	}
+	return strs[i], nil
+}
+
+// toDictPoints convert pts into DictPoints.
+func toDictPoints(pts []*Point) *DictPoints {
+	var (
+		strs = newDictStrs()
+		arr  = make([]*DictPoint, 0, len(pts))
+	)
+
+	for _, pt := range pts {
+		if pt == nil || pt.pt == nil {
+			continue
+		}
+
+		dpt := &DictPoint{
+			Name:   strs.index(pt.pt.Name),
+			Fields: make([]*DictField, 0, len(pt.pt.Fields)),
+			Time:   pt.pt.Time,
+			Warns:  pt.pt.Warns,
+			Debugs: pt.pt.Debugs,
+		}
+
+		for _, f := range pt.pt.Fields {
+			df := &DictField{
+				Key:   strs.index(f.Key),
+				IsTag: f.IsTag,
+				Type:  f.Type,
+				Unit:  strs.index(f.Unit),
+			}
+
+			switch x := f.Val.(type) {
+			case *Field_I:
+				df.Val = &DictField_I{I: x.I}
+			case *Field_U:
+				df.Val = &DictField_U{U: x.U}
+			case *Field_F:
+				df.Val = &DictField_F{F: x.F}
+			case *Field_B:
+				df.Val = &DictField_B{B: x.B}
+			case *Field_D:
+				df.Val = &DictField_D{D: x.D}
+			case *Field_S:
+				if f.IsTag {
+					df.Val = &DictField_Sidx{Sidx: strs.index(x.S)}
+				} else {
+					df.Val = &DictField_S{S: x.S}
+				}
+			case *Field_A:
+				df.Val = &DictField_A{A: x.A}
+			}
+
+			dpt.Fields = append(dpt.Fields, df)
+		}
+
+		arr = append(arr, dpt)
+	}
+
+	return &DictPoints{Strs: strs.strs, Arr: arr}
+}
+
+// fromDictPoints convert DictPoints into points.
+func fromDictPoints(dpts *DictPoints) ([]*Point, error) {
+	strs := dpts.Strs
+	pts := make([]*Point, 0, len(dpts.Arr))
+
+	for _, dpt := range dpts.Arr {
+		name, err := dictStr(strs, dpt.Name)
+		if err != nil {
+			return nil, fmt.Errorf("point name: %w", err)
+		}
+
+		pbpt := &PBPoint{
+			Name:   name,
+			Fields: make([]*Field, 0, len(dpt.Fields)),
+			Time:   dpt.Time,
+			Warns:  dpt.Warns,
+			Debugs: dpt.Debugs,
+		}
+
+		for _, df := range dpt.Fields {
+			f := &Field{IsTag: df.IsTag, Type: df.Type}
+
+			if f.Key, err = dictStr(strs, df.Key); err != nil {
+				return nil, fmt.Errorf("field key: %w", err)
+			}
+
+			if f.Unit, err = dictStr(strs, df.Unit); err != nil {
+				return nil, fmt.Errorf("field unit: %w", err)
+			}
+
+			switch x := df.Val.(type) {
+			case *DictField_I:
+				f.Val = &Field_I{I: x.I}
+			case *DictField_U:
+				f.Val = &Field_U{U: x.U}
+			case *DictField_F:
+				f.Val = &Field_F{F: x.F}
+			case *DictField_B:
+				f.Val = &Field_B{B: x.B}
+			case *DictField_D:
+				f.Val = &Field_D{D: x.D}
+			case *DictField_S:
+				f.Val = &Field_S{S: x.S}
+			case *DictField_Sidx:
+				s, err := dictStr(strs, x.Sidx)
+				if err != nil {
+					return nil, fmt.Errorf("value of field %q: %w", f.Key, err)
+				}
+				f.Val = &Field_S{S: s}
+			case *DictField_A:
+				f.Val = &Field_A{A: x.A}
+			}
+
+			pbpt.Fields = append(pbpt.Fields, f)
+		}
+
+		pt := &Point{pt: pbpt}
+		pt.SetFlag(Ppb)
+		pts = append(pts, pt)
+	}
+
+	return pts, nil
+}
+
+// dictMarshal encode pts into DictPoints with gogo.
+func dictMarshal(pts []*Point) ([]byte, error) {
+	return toDictPoints(pts).Marshal()
+}
+
+// dictUnmarshal decode DictPoints with gogo.
+func dictUnmarshal(data []byte) ([]*Point, error) {
+	var dpts DictPoints
+	if err := dpts.Unmarshal(data); err != nil {
+		return nil, err
+	}
+
+	return fromDictPoints(&dpts)
+}
+
+// marshalDictPoints encode pts into DictPoints with easyproto.
+func marshalDictPoints(pts []*Point, dst []byte) []byte {
+	var (
+		strs = newDictStrs()
+		m    = mp.Get()
+		mm   = m.MessageMarshaler()
+	)
+
+	// NOTE: string table must be marshaled before points, so we marshal
+	// points into a temporary marshaler first.
+	pm := mp.Get()
+	pmm := pm.MessageMarshaler()
+
+	for _, pt := range pts {
+		if pt == nil || pt.pt == nil {
+			continue
+		}
+
+		marshalDictPoint(pt, strs, pmm.AppendMessage(2))
+	}
+
+	for _, s := range strs.strs {
+		mm.AppendString(1, s)
+	}
+
+	dst = m.Marshal(dst)
+	dst = pm.Marshal(dst)
+
+	mp.Put(m)
+	mp.Put(pm)
+	return dst
+}
+
+func marshalDictPoint(pt *Point, strs *dictStrs, mm *easyproto.MessageMarshaler) {
+	mm.AppendUint32(1, strs.index(pt.pt.Name))
+
+	for _, f := range pt.pt.Fields {
+		marshalDictField(f, strs, mm.AppendMessage(2))
+	}
+
+	mm.AppendInt64(3, pt.pt.Time)
+
+	for _, w := range pt.pt.Warns {
+		w.marshalProtobuf(mm.AppendMessage(4))
+	}
+
+	for _, d := range pt.pt.Debugs {
+		d.marshalProtobuf(mm.AppendMessage(5))
+	}
+}
+
+func marshalDictField(f *Field, strs *dictStrs, mm *easyproto.MessageMarshaler) {
+	mm.AppendUint32(1, strs.index(f.Key))
+
+	switch x := f.Val.(type) {
+	case *Field_I:
+		mm.AppendInt64(2, x.I)
+	case *Field_U:
+		mm.AppendUint64(3, x.U)
+	case *Field_F:
+		mm.AppendDouble(4, x.F)
+	case *Field_B:
+		mm.AppendBool(5, x.B)
+	case *Field_D:
+		mm.AppendBytes(6, x.D)
+	case *Field_S:
+		if f.IsTag {
+			mm.AppendUint32(12, strs.index(x.S))
+		} else {
+			mm.AppendString(11, x.S)
+		}
+	case *Field_A:
+		if x.A != nil {
+			amm := mm.AppendMessage(7)
+			amm.AppendString(1, x.A.TypeUrl)
+			amm.AppendBytes(2, x.A.Value)
+		}
+	}
+
+	mm.AppendBool(8, f.IsTag)
+	mm.AppendInt32(9, int32(f.Type))
+	mm.AppendUint32(10, strs.index(f.Unit))
+}
+
+// unmarshalDictPoints decode DictPoints with easyproto.
+func unmarshalDictPoints(src []byte) ([]*Point, error) {
+	var (
+		fc   easyproto.FieldContext
+		strs []string
+		pts  []*Point
+		err  error
+	)
+
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return nil, fmt.Errorf("read next field for DictPoints failed: %w", err)
+		}
+
+		switch fc.FieldNum {
+		case 1:
+			x, ok := fc.String()
+			if !ok {
+				return nil, fmt.Errorf("cannot read string table for DictPoints")
+			}
+			strs = append(strs, x)
+
+		case 2:
+			data, ok := fc.MessageData()
+			if !ok {
+				return nil, fmt.Errorf("cannot read Arr for DictPoints")
+			}
+
+			pt, err := unmarshalDictPoint(data, strs)
+			if err != nil {
+				return nil, fmt.Errorf("unmarshal point failed: %w", err)
+			}
+			pts = append(pts, pt)
+		}
+	}
+
+	return pts, nil
+}
+
+func unmarshalDictPoint(src []byte, strs []string) (*Point, error) {
+	var (
+		fc     easyproto.FieldContext
+		kvs    KVs
+		warns  []*Warn
+		debugs []*Debug
+		name   string
+		ts     int64
+		err    error
+	)
+
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return nil, fmt.Errorf("read next field for DictPoint failed: %w", err)
+		}
+
+		switch fc.FieldNum {
+		case 1:
+			x, ok := fc.Uint32()
+			if !ok {
+				return nil, fmt.Errorf("cannot read DictPoint name")
+			}
+
+			if name, err = dictStr(strs, x); err != nil {
+				return nil, fmt.Errorf("point name: %w", err)
+			}
+
+		case 2:
+			data, ok := fc.MessageData()
+			if !ok {
+				return nil, fmt.Errorf("cannot read Fields for DictPoint")
+			}
+
+			kv, err := unmarshalDictField(data, strs)
+			if err != nil {
+				return nil, fmt.Errorf("cannot unmarshal field: %w", err)
+			}
+
+			kvs = kvs.AddKV(kv)
+
+		case 3:
+			x, ok := fc.Int64()
+			if !ok {
+				return nil, fmt.Errorf("cannot read DictPoint time")
+			}
+			ts = x
+
+		case 4: // Warns
+			data, ok := fc.MessageData()
+			if !ok {
+				return nil, fmt.Errorf("cannot read Warn for DictPoint")
+			}
+
+			if x, err := unmarshalWarn(data); err == nil {
+				warns = append(warns, x)
+			}
+
+		case 5: // Debugs
+			data, ok := fc.MessageData()
+			if !ok {
+				return nil, fmt.Errorf("cannot read Debug for DictPoint")
+			}
+
+			if x, err := unmarshalDebug(data); err == nil {
+				debugs = append(debugs, x)
+			}
+		}
+	}
+
+	pt := NewPoint(name, kvs, WithTime(time.Unix(0, ts)))
+	pt.pt.Warns = warns
+	pt.pt.Debugs = debugs
+
+	return pt, nil
+}
+
+func unmarshalDictField(src []byte, strs []string) (*Field, error) {
+	var (
+		fc         easyproto.FieldContext
+		key, unit  string
+		isTag      bool
+		val        any
+		metricType MetricType
+		err        error
+	)
+
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return nil, fmt.Errorf("read next field for DictField failed: %w", err)
+		}
+
+		switch fc.FieldNum {
+		case 1:
+			x, ok := fc.Uint32()
+			if !ok {
+				return nil, fmt.Errorf("cannot read DictField key")
+			}
+
+			if key, err = dictStr(strs, x); err != nil {
+				return nil, fmt.Errorf("field key: %w", err)
+			}
+
+		case 2:
+			if x, ok := fc.Int64(); ok {
+				val = x
+			}
+		case 3:
+			if x, ok := fc.Uint64(); ok {
+				val = x
+			}
+		case 4:
+			if x, ok := fc.Double(); ok {
+				val = x
+			}
+		case 5:
+			if x, ok := fc.Bool(); ok {
+				val = x
+			}
+		case 6:
+			if x, ok := fc.Bytes(); ok {
+				val = x
+			}
+		case 11:
+			if x, ok := fc.String(); ok {
+				val = x
+			}
+
+		case 12:
+			x, ok := fc.Uint32()
+			if !ok {
+				return nil, fmt.Errorf("cannot read DictField string index")
+			}
+
+			if val, err = dictStr(strs, x); err != nil {
+				return nil, fmt.Errorf("field value: %w", err)
+			}
+
+		case 7:
+			data, ok := fc.MessageData()
+			if !ok {
+				return nil, fmt.Errorf("cannot read DictField any value")
+			}
+
+			a, err := unmarshalAny(data)
+			if err != nil {
+				return nil, err
+			}
+			val = a
+
+		case 8:
+			x, ok := fc.Bool()
+			if !ok {
+				return nil, fmt.Errorf("cannot unmarshal is-tag for DictField")
+			}
+			isTag = x
+
+		case 9:
+			if x, ok := fc.Int32(); ok {
+				metricType = MetricType(x)
+			}
+
+		case 10:
+			x, ok := fc.Uint32()
+			if !ok {
+				return nil, fmt.Errorf("cannot read DictField unit")
+			}
+
+			if unit, err = dictStr(strs, x); err != nil {
+				return nil, fmt.Errorf("field unit: %w", err)
+			}
+		}
+	}
+
+	var f *Field
+	switch x := val.(type) {
+	case *types.Any:
+		f = &Field{Key: key, Val: &Field_A{A: x}}
+	case nil:
+		return nil, nil // value not set
+	default:
+		f = NewKV(key, x)
+	}
+
+	f.Unit = unit
+	f.Type = metricType
+	f.IsTag = isTag
+
+	return f, nil
+}
+
+func unmarshalAny(src []byte) (*types.Any, error) {
+	var (
+		fc  easyproto.FieldContext
+		a   types.Any
+		err error
+	)
+
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return nil, fmt.Errorf("read next field for Any failed: %w", err)
+		}
+
+		switch fc.FieldNum {
+		case 1:
+			if x, ok := fc.String(); ok {
+				a.TypeUrl = x
+			}
+		case 2:
+			if x, ok := fc.Bytes(); ok {
+				a.Value = x
+			}
+		}
+	}
+
+	return &a, nil
+}
@@ -0,0 +1,218 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package point
+
+import (
+	"bytes"
+	T "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dictTestPoints() []*Point {
+	var pts []*Point
+
+	for i := 0; i < 3; i++ {
+		var kvs KVs
+		kvs = kvs.Add("i", int64(i), WithKVUnit("byte"), WithKVType(GAUGE)).
+			Add("u", uint64(42)).
+			Add("f", 3.14, WithKVUnit("byte"), WithKVType(COUNT)).
+			Add("b", true).
+			Add("d", []byte("binary-data")).
+			Add("s", "text-data").
+			Add("arr", MustNewAnyArray(1, 2, 3)).
+			AddTag("host", "h1").
+			AddTag("service", "s1")
+
+		pt := NewPoint("cpu", kvs, WithTimestamp(int64(123+i)))
+		pt.pt.Warns = append(pt.pt.Warns, &Warn{Type: "w", Msg: "some warning"})
+		pts = append(pts, pt)
+	}
+
+	return pts
+}
+
+func TestDictEncoding(t *T.T) {
+	t.Run(`content-type`, func(t *T.T) {
+		assert.Equal(t, ProtobufDict, HTTPContentType(ProtobufDict.HTTPContentType()))
+		assert.Equal(t, ProtobufDict, EncodingStr(ProtobufDict.String()))
+	})
+
+	cases := []struct {
+		name             string
+		encEasy, decEasy bool
+	}{
+		{name: "gogo", encEasy: false, decEasy: false},
+		{name: "easyproto", encEasy: true, decEasy: true},
+		{name: "gogo-enc-easyproto-dec", encEasy: false, decEasy: true},
+		{name: "easyproto-enc-gogo-dec", encEasy: true, decEasy: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *T.T) {
+			pts := dictTestPoints()
+
+			enc := GetEncoder(WithEncEncoding(ProtobufDict), WithEncEasyproto(tc.encEasy))
+			defer PutEncoder(enc)
+
+			arr, err := enc.Encode(pts)
+			require.NoError(t, err)
+			require.Len(t, arr, 1)
+
+			dec := GetDecoder(WithDecEncoding(ProtobufDict), WithDecEasyproto(tc.decEasy))
+			defer PutDecoder(dec)
+
+			pts2, err := dec.Decode(arr[0])
+			require.NoError(t, err)
+			require.Len(t, pts2, len(pts))
+
+			for i := range pts {
+				assert.Equal(t, pts[i].Pretty(), pts2[i].Pretty())
+				assert.Equal(t, pts[i].pt.Warns, pts2[i].pt.Warns)
+			}
+		})
+	}
+
+	t.Run(`smaller-than-protobuf`, func(t *T.T) {
+		r := NewRander(WithFixedKeys(true), WithFixedTags(true), WithRandText(3))
+		pts := r.Rand(100)
+
+		enc := GetEncoder(WithEncEncoding(Protobuf))
+		pb, err := enc.Encode(pts)
+		require.NoError(t, err)
+		PutEncoder(enc)
+
+		enc = GetEncoder(WithEncEncoding(ProtobufDict))
+		dict, err := enc.Encode(pts)
+		require.NoError(t, err)
+		PutEncoder(enc)
+
+		t.Logf("protobuf: %d, protobuf-dict: %d", len(pb[0]), len(dict[0]))
+		assert.Less(t, len(dict[0]), len(pb[0]))
+	})
+
+	t.Run(`string-table-deduplicated`, func(t *T.T) {
+		dpts := toDictPoints(dictTestPoints())
+
+		// "", cpu, i, byte, u, f, b, d, s, arr, host, h1, service, s1
+		assert.Len(t, dpts.Strs, 14)
+		assert.Equal(t, "", dpts.Strs[0])
+	})
+
+	t.Run(`invalid-index`, func(t *T.T) {
+		dpts := &DictPoints{
+			Strs: []string{"", "cpu"},
+			Arr: []*DictPoint{
+				{
+					Name:   1,
+					Fields: []*DictField{{Key: 100, Val: &DictField_I{I: 1}}},
+				},
+			},
+		}
+
+		data, err := dpts.Marshal()
+		require.NoError(t, err)
+
+		_, err = dictUnmarshal(data)
+		assert.Error(t, err)
+
+		_, err = unmarshalDictPoints(data)
+		assert.Error(t, err)
+	})
+
+	t.Run(`decode-reader`, func(t *T.T) {
+		pts := dictTestPoints()
+
+		for _, easy := range []bool{false, true} {
+			var got []*Point
+
+			dec := GetDecoder(WithDecEncoding(ProtobufDict),
+				WithDecEasyproto(easy),
+				WithDecBatchSize(2),
+				WithDecFn(func(pts []*Point) error {
+					got = append(got, pts...)
+					return nil
+				}))
+
+			require.NoError(t, dec.DecodeReader(bytes.NewReader(marshalDictPoints(pts, nil))))
+			PutDecoder(dec)
+
+			require.Len(t, got, len(pts))
+			for i := range pts {
+				assert.Equal(t, pts[i].Pretty(), got[i].Pretty())
+			}
+		}
+	})
+}
+
+func BenchmarkDictEncoding(b *T.B) {
+	r := NewRander(WithFixedKeys(true), WithFixedTags(true), WithRandText(3))
+	pts := r.Rand(1000)
+
+	pbpts := &PBPoints{}
+	for _, pt := range pts {
+		pbpts.Arr = append(pbpts.Arr, pt.pt)
+	}
+
+	pb, err := pbpts.Marshal()
+	require.NoError(b, err)
+
+	dict, err := dictMarshal(pts)
+	require.NoError(b, err)
+
+	b.Run("protobuf-encode", func(b *T.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := pbpts.Marshal()
+			assert.NoError(b, err)
+		}
+		b.ReportMetric(float64(len(pb)), "bytes/batch")
+	})
+
+	b.Run("dict-gogo-encode", func(b *T.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := dictMarshal(pts)
+			assert.NoError(b, err)
+		}
+		b.ReportMetric(float64(len(dict)), "bytes/batch")
+	})
+
+	b.Run("dict-easyproto-encode", func(b *T.B) {
+		var dst []byte
+		for i := 0; i < b.N; i++ {
+			dst = marshalDictPoints(pts, dst[:0])
+		}
+		b.ReportMetric(float64(len(dst)), "bytes/batch")
+	})
+
+	b.Run("protobuf-decode", func(b *T.B) {
+		for i := 0; i < b.N; i++ {
+			var x PBPoints
+			assert.NoError(b, x.Unmarshal(pb))
+		}
+	})
+
+	b.Run("protobuf-easyproto-decode", func(b *T.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := unmarshalPoints(pb)
+			assert.NoError(b, err)
+		}
+	})
+
+	b.Run("dict-gogo-decode", func(b *T.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := dictUnmarshal(dict)
+			assert.NoError(b, err)
+		}
+	})
+
+	b.Run("dict-easyproto-decode", func(b *T.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := unmarshalDictPoints(dict)
+			assert.NoError(b, err)
+		}
+	})
+}
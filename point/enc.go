@@ -5,7 +5,10 @@
 
 package point
 
-import "strings"
+import (
+	"net/http"
+	"strings"
+)
 
 type Encoding int
 
@@ -14,14 +17,22 @@ const (
 	encProtobufAlias = "v2"
 	encJSON          = "json"
 	encPBJSON        = "pbjson"
+	encArrow         = "arrow"
+	encRemoteWrite   = "remote-write"
+	encProtobufDict  = "protobuf-dict"
 
 	encLineprotocolAlias = "v1"
 	encLineprotocol      = "line-protocol"
 
-	contentTypeJSON      = "application/json"
-	contentTypeProtobuf  = "application/protobuf; proto=com.guance.Point"
-	contentTypePBJSON    = "application/pbjson; proto=com.guance.Point"
-	contentTypeLineproto = "application/line-protocol"
+	contentTypeJSON        = "application/json"
+	contentTypeProtobuf    = "application/protobuf; proto=com.guance.Point"
+	contentTypePBJSON      = "application/pbjson; proto=com.guance.Point"
+	contentTypeLineproto   = "application/line-protocol"
+	contentTypeArrow       = "application/vnd.apache.arrow.stream"
+	contentTypeRemoteWrite = "application/x-protobuf"
+	contentTypeDict        = "application/protobuf; proto=com.guance.DictPoints"
+
+	headerRemoteWriteVersion = "X-Prometheus-Remote-Write-Version"
 )
 
 const (
@@ -29,6 +40,9 @@ const (
 	Protobuf                     // encoding in protobuf
 	JSON                         // encoding int simple JSON
 	PBJSON                       // encoding in protobuf structured JSON(with better field-type labeled)
+	Arrow                        // encoding in Apache Arrow IPC stream(columnar)
+	RemoteWrite                  // encoding in snappy-compressed Prometheus remote-write protobuf
+	ProtobufDict                 // encoding in protobuf with per-batch string table
 )
 
 // EncodingStr convert encoding-string in configure file to
@@ -46,6 +60,12 @@ func EncodingStr(s string) Encoding {
 		return JSON
 	case encPBJSON:
 		return PBJSON
+	case encArrow:
+		return Arrow
+	case encRemoteWrite:
+		return RemoteWrite
+	case encProtobufDict:
+		return ProtobufDict
 	case encLineprotocol, encLineprotocolAlias:
 		return LineProtocol
 	default:
@@ -54,6 +74,9 @@ func EncodingStr(s string) Encoding {
 }
 
 // HTTPContentType detect HTTP body content encoding according to header Content-Type.
+//
+// Content-Type of remote-write(application/x-protobuf) is too generic to
+// detect remote-write body, see HTTPHeaderEncoding.
 func HTTPContentType(ct string) Encoding {
 	switch ct {
 	case contentTypeJSON:
@@ -64,11 +87,31 @@ func HTTPContentType(ct string) Encoding {
 		return Protobuf
 	case contentTypeLineproto:
 		return LineProtocol
+	case contentTypeArrow:
+		return Arrow
+	case contentTypeDict:
+		return ProtobufDict
 	default: // default use line-protocol to be compatible with lagacy code
 		return LineProtocol
 	}
 }
 
+// HTTPHeaderEncoding detect HTTP body content encoding according to headers.
+// Remote-write body detected only if Content-Type is application/x-protobuf,
+// Content-Encoding is snappy and header X-Prometheus-Remote-Write-Version set,
+// other encodings are detected by Content-Type, see HTTPContentType.
+func HTTPHeaderEncoding(h http.Header) Encoding {
+	ct := h.Get("Content-Type")
+
+	if ct == contentTypeRemoteWrite &&
+		strings.EqualFold(h.Get("Content-Encoding"), "snappy") &&
+		h.Get(headerRemoteWriteVersion) != "" {
+		return RemoteWrite
+	}
+
+	return HTTPContentType(ct)
+}
+
 // HTTPContentType get correct HTTP Content-Type value on different body encoding.
 func (e Encoding) HTTPContentType() string {
 	switch e {
@@ -76,6 +119,12 @@ func (e Encoding) HTTPContentType() string {
 		return contentTypeJSON
 	case PBJSON:
 		return contentTypePBJSON
+	case Arrow:
+		return contentTypeArrow
+	case RemoteWrite:
+		return contentTypeRemoteWrite
+	case ProtobufDict:
+		return contentTypeDict
 	case Protobuf:
 		return contentTypeProtobuf
 	case LineProtocol:
@@ -91,6 +140,12 @@ func (e Encoding) String() string {
 		return encJSON
 	case PBJSON:
 		return encPBJSON
+	case Arrow:
+		return encArrow
+	case RemoteWrite:
+		return encRemoteWrite
+	case ProtobufDict:
+		return encProtobufDict
 	case Protobuf:
 		return encProtobuf
 	case LineProtocol:
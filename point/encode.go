@@ -30,6 +30,9 @@ func WithIgnoreLargePoint(on bool) EncoderOption { return func(e *Encoder) { e.i
 // for better performance under busy encoding conditions.
 func WithApproxSize(on bool) EncoderOption { return func(e *Encoder) { e.approxsize = on } }
 
+// WithEncEasyproto use easyproto to marshal ProtobufDict payload instead of gogo.
+func WithEncEasyproto(on bool) EncoderOption { return func(e *Encoder) { e.easyproto = on } }
+
 type Encoder struct {
 	pts []*Point
 
@@ -54,6 +57,7 @@ type Encoder struct {
 	// pt.Size() is faster(2X) than pt.PBSize(), but the later is more precise.
 	approxsize       bool
 	ignoreLargePoint bool
+	easyproto        bool
 }
 
 var encPool sync.Pool
@@ -102,6 +106,7 @@ func (e *Encoder) reset() {
 	e.lpPointBuf = e.lpPointBuf[:0]
 	e.ignoreLargePoint = false
 	e.approxsize = true
+	e.easyproto = false
 
 	e.totalBytes = 0
 }
@@ -135,6 +140,13 @@ func (e *Encoder) getPayload(pts []*Point) ([]byte, error) {
 			return nil, err
 		}
 
+	case ProtobufDict:
+		if e.easyproto {
+			payload = marshalDictPoints(pts, nil)
+		} else if payload, err = dictMarshal(pts); err != nil {
+			return nil, err
+		}
+
 	case LineProtocol:
 		lppart := []string{}
 		for _, pt := range pts {
@@ -152,6 +164,12 @@ func (e *Encoder) getPayload(pts []*Point) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
+
+	case Arrow:
+		if payload, err = arrowMarshal(pts); err != nil {
+			return nil, err
+		}
+
 	default:
 		return nil, fmt.Errorf("not support encode %s", e.enc)
 	}
@@ -167,6 +185,10 @@ func (e *Encoder) doEncode(pts []*Point) ([][]byte, error) {
 		return nil, nil
 	}
 
+	if e.enc == RemoteWrite { // remote-write split on it's own encoded bytes
+		return e.doEncodeRemoteWrite(pts)
+	}
+
 	var (
 		batches [][]byte
 		batch   []*Point
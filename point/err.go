@@ -42,4 +42,10 @@ const (
 	WarnNROrTailEscape  = "found_new_line_or_tail_espace"
 	WarnFieldB64Encoded = "field_base64_encoded"
 	WarnNilField        = "nil_field"
+
+	WarnFieldTypeConflict      = "field_type_conflict"
+	WarnSchemaTagFieldConflict = "schema_tag_field_conflict"
+	WarnSchemaMaxTagKeys       = "exceed_schema_max_tag_keys"
+	WarnSchemaMaxFieldKeys     = "exceed_schema_max_field_keys"
+	WarnSchemaMaxSeries        = "exceed_schema_max_series"
 )
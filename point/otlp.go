@@ -0,0 +1,779 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package point
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// Keys used during OTLP conversion.
+const (
+	otlpDefaultMeasurement = "otel"
+	otlpKeyServiceName     = "service.name"
+
+	otlpKeyLE       = "le"
+	otlpKeyQuantile = "quantile"
+
+	otlpKeyMessage  = "message"
+	otlpKeyTraceID  = "trace_id"
+	otlpKeySpanID   = "span_id"
+	otlpKeyParentID = "parent_id"
+
+	otlpKeyOperation  = "operation"
+	otlpKeyStart      = "start"    // in us
+	otlpKeyDuration   = "duration" // in us
+	otlpKeySpanKind   = "span_kind"
+	otlpKeyStatusMsg  = "status_message"
+	otlpKeyTraceState = "trace_state"
+)
+
+// OTLPOption used to control the conversion between OTLP and point.
+type OTLPOption func(o *otlpOpt)
+
+type otlpOpt struct {
+	measurement  string
+	resourceKeys []string
+	ptOpts       []Option
+}
+
+// WithOTLPMeasurement set a fixed measurement name on OTLP converted points.
+//
+// By default, measurement of metric points is the instrumentation scope name,
+// measurement of logging/tracing points is the resource attribute service.name.
+func WithOTLPMeasurement(name string) OTLPOption {
+	return func(o *otlpOpt) { o.measurement = name }
+}
+
+// WithOTLPResourceKeys set tag keys that exported as OTLP resource attributes,
+// other tags are exported as data point/log/span attributes. Default are
+// service.name and service_name.
+func WithOTLPResourceKeys(keys ...string) OTLPOption {
+	return func(o *otlpOpt) { o.resourceKeys = keys }
+}
+
+// WithOTLPPointOptions set extra options on OTLP converted points, these
+// options applied after the default options of the point's category.
+func WithOTLPPointOptions(opts ...Option) OTLPOption {
+	return func(o *otlpOpt) { o.ptOpts = append(o.ptOpts, opts...) }
+}
+
+func newOTLPOpt(opts ...OTLPOption) *otlpOpt {
+	o := &otlpOpt{
+		resourceKeys: []string{otlpKeyServiceName, "service_name"},
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+
+	return o
+}
+
+func (o *otlpOpt) isResourceKey(k string) bool {
+	for _, x := range o.resourceKeys {
+		if x == k {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *otlpOpt) newPoint(cat Category, name string, kvs KVs, ts uint64) *Point {
+	var opts []Option
+
+	switch cat { //nolint:exhaustive
+	case Metric:
+		opts = DefaultMetricOptions()
+	case Logging:
+		opts = DefaultLoggingOptions()
+	default:
+		opts = CommonLoggingOptions()
+	}
+
+	opts = append(opts, WithTimestamp(int64(ts)))
+	opts = append(opts, o.ptOpts...)
+
+	if o.measurement != "" {
+		name = o.measurement
+	}
+
+	return NewPoint(name, kvs, opts...)
+}
+
+// otlpRaw get Go value of v, array and kvlist are converted to []any and
+// map[string]any.
+func otlpRaw(v *commonpb.AnyValue) any {
+	switch x := v.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return x.StringValue
+	case *commonpb.AnyValue_BoolValue:
+		return x.BoolValue
+	case *commonpb.AnyValue_IntValue:
+		return x.IntValue
+	case *commonpb.AnyValue_DoubleValue:
+		return x.DoubleValue
+	case *commonpb.AnyValue_BytesValue:
+		return x.BytesValue
+	case *commonpb.AnyValue_ArrayValue:
+		arr := []any{}
+		for _, elem := range x.ArrayValue.GetValues() {
+			arr = append(arr, otlpRaw(elem))
+		}
+		return arr
+	case *commonpb.AnyValue_KvlistValue:
+		m := map[string]any{}
+		for _, kv := range x.KvlistValue.GetValues() {
+			m[kv.GetKey()] = otlpRaw(kv.GetValue())
+		}
+		return m
+	default:
+		return nil
+	}
+}
+
+// otlpString get string form of v, non-scalar values are JSON encoded.
+func otlpString(v *commonpb.AnyValue) string {
+	switch x := otlpRaw(v).(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case bool:
+		return strconv.FormatBool(x)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	default:
+		j, err := json.Marshal(x)
+		if err != nil {
+			return ""
+		}
+		return string(j)
+	}
+}
+
+// otlpAttrTags add attributes as tags.
+func otlpAttrTags(kvs KVs, attrs ...[]*commonpb.KeyValue) KVs {
+	for _, arr := range attrs {
+		for _, kv := range arr {
+			kvs = kvs.AddTag(kv.GetKey(), otlpString(kv.GetValue()))
+		}
+	}
+	return kvs
+}
+
+func otlpAttr(k string, v any) *commonpb.KeyValue {
+	kv := &commonpb.KeyValue{Key: k}
+
+	switch x := v.(type) {
+	case int64:
+		kv.Value = &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: x}}
+	case uint64:
+		kv.Value = &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(x)}}
+	case float64:
+		kv.Value = &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: x}}
+	case bool:
+		kv.Value = &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: x}}
+	case []byte:
+		kv.Value = &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: x}}
+	case string:
+		kv.Value = &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: x}}
+	default:
+		return nil
+	}
+
+	return kv
+}
+
+// otlpResource split point tags into resource attributes and other attributes.
+func (o *otlpOpt) otlpResource(pt *Point) (res, attrs []*commonpb.KeyValue) {
+	for _, kv := range pt.Tags() {
+		if o.isResourceKey(kv.Key) {
+			res = append(res, otlpAttr(kv.Key, kv.GetS()))
+		} else {
+			attrs = append(attrs, otlpAttr(kv.Key, kv.GetS()))
+		}
+	}
+
+	return res, attrs
+}
+
+// otlpResourceID get unique ID of resource attributes.
+func otlpResourceID(attrs []*commonpb.KeyValue) string {
+	arr := make([]string, 0, len(attrs))
+	for _, kv := range attrs {
+		arr = append(arr, kv.GetKey()+"="+otlpString(kv.GetValue()))
+	}
+	sort.Strings(arr)
+	return strings.Join(arr, "\n")
+}
+
+// otlpFieldAttrs get attributes from point fields except the skipped keys.
+func otlpFieldAttrs(pt *Point, skip ...string) (attrs []*commonpb.KeyValue) {
+next:
+	for _, kv := range pt.Fields() {
+		for _, k := range skip {
+			if kv.Key == k {
+				continue next
+			}
+		}
+
+		if x := otlpAttr(kv.Key, kv.Raw()); x != nil {
+			attrs = append(attrs, x)
+		}
+	}
+
+	return attrs
+}
+
+func hexID(id []byte) string {
+	if len(id) == 0 {
+		return ""
+	}
+	return hex.EncodeToString(id)
+}
+
+func unhexID(s string) []byte {
+	if x, err := hex.DecodeString(s); err == nil {
+		return x
+	}
+	return nil
+}
+
+func serviceName(res *resourcepb.Resource) string {
+	for _, kv := range res.GetAttributes() {
+		if kv.GetKey() == otlpKeyServiceName {
+			return otlpString(kv.GetValue())
+		}
+	}
+	return ""
+}
+
+func nonEmpty(arr ...string) string {
+	for _, x := range arr {
+		if x != "" {
+			return x
+		}
+	}
+	return ""
+}
+
+// FromOTLPMetrics convert OTLP metrics into Metric points.
+//
+// Each data point converted to a point named after the instrumentation scope,
+// the metric name is the field key. Resource, scope and data point attributes
+// are tags. Monotonic sums are COUNT(cumulative) or DELTA(delta temporality)
+// fields, other gauge and sums are GAUGE fields, so cumulative sums can be
+// converted to deltas with DeltaConverter. Histograms are converted to fields
+// <name>_count/<name>_sum(COUNT or DELTA, like sums) and <name>_min/<name>_max(GAUGE),
+// each bucket is a separate point with tag le and field <name>_bucket.
+// Summaries are converted like histograms, with quantile tag on each quantile
+// point. Exponential histograms only keep count and sum.
+func FromOTLPMetrics(rms []*metricspb.ResourceMetrics, opts ...OTLPOption) (pts []*Point) {
+	o := newOTLPOpt(opts...)
+
+	for _, rm := range rms {
+		for _, sm := range rm.GetScopeMetrics() {
+			name := nonEmpty(sm.GetScope().GetName(), otlpDefaultMeasurement)
+
+			newPoint := func(attrs []*commonpb.KeyValue, ts uint64, kvs ...*Field) {
+				tags := otlpAttrTags(nil, rm.GetResource().GetAttributes(), sm.GetScope().GetAttributes(), attrs)
+				pts = append(pts, o.newPoint(Metric, name, append(tags, kvs...), ts))
+			}
+
+			for _, m := range sm.GetMetrics() {
+				fromOTLPMetric(m, newPoint)
+			}
+		}
+	}
+
+	return pts
+}
+
+func fromOTLPMetric(m *metricspb.Metric,
+	newPoint func(attrs []*commonpb.KeyValue, ts uint64, kvs ...*Field),
+) {
+	key := m.GetName()
+	unit := m.GetUnit()
+
+	kv := func(k string, v any, t MetricType) *Field {
+		return NewKV(k, v, WithKVUnit(unit), WithKVType(t))
+	}
+
+	// counter type on aggregation temporality
+	counter := func(x metricspb.AggregationTemporality) MetricType {
+		if x == metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA {
+			return DELTA
+		}
+		return COUNT
+	}
+
+	numberValue := func(dp *metricspb.NumberDataPoint) any {
+		if x, ok := dp.GetValue().(*metricspb.NumberDataPoint_AsInt); ok {
+			return x.AsInt
+		}
+		return dp.GetAsDouble()
+	}
+
+	switch x := m.GetData().(type) {
+	case *metricspb.Metric_Gauge:
+		for _, dp := range x.Gauge.GetDataPoints() {
+			newPoint(dp.GetAttributes(), dp.GetTimeUnixNano(), kv(key, numberValue(dp), GAUGE))
+		}
+
+	case *metricspb.Metric_Sum:
+		t := GAUGE
+		if x.Sum.GetIsMonotonic() {
+			t = counter(x.Sum.GetAggregationTemporality())
+		}
+
+		for _, dp := range x.Sum.GetDataPoints() {
+			newPoint(dp.GetAttributes(), dp.GetTimeUnixNano(), kv(key, numberValue(dp), t))
+		}
+
+	case *metricspb.Metric_Histogram:
+		t := counter(x.Histogram.GetAggregationTemporality())
+		for _, dp := range x.Histogram.GetDataPoints() {
+			ts := dp.GetTimeUnixNano()
+			kvs := []*Field{
+				kv(key+"_count", dp.GetCount(), t),
+				kv(key+"_sum", dp.GetSum(), t),
+			}
+
+			if dp.Min != nil {
+				kvs = append(kvs, kv(key+"_min", dp.GetMin(), GAUGE))
+			}
+
+			if dp.Max != nil {
+				kvs = append(kvs, kv(key+"_max", dp.GetMax(), GAUGE))
+			}
+
+			newPoint(dp.GetAttributes(), ts, kvs...)
+
+			// bucket counts in OTLP are not cumulative
+			var cnt uint64
+			bounds := dp.GetExplicitBounds()
+			for i, n := range dp.GetBucketCounts() {
+				cnt += n
+
+				le := "+Inf"
+				if i < len(bounds) {
+					le = strconv.FormatFloat(bounds[i], 'f', -1, 64)
+				}
+
+				newPoint(append(dp.GetAttributes(), otlpAttr(otlpKeyLE, le)), ts, kv(key+"_bucket", cnt, t))
+			}
+		}
+
+	case *metricspb.Metric_ExponentialHistogram:
+		t := counter(x.ExponentialHistogram.GetAggregationTemporality())
+		for _, dp := range x.ExponentialHistogram.GetDataPoints() {
+			newPoint(dp.GetAttributes(), dp.GetTimeUnixNano(),
+				kv(key+"_count", dp.GetCount(), t),
+				kv(key+"_sum", dp.GetSum(), t))
+		}
+
+	case *metricspb.Metric_Summary:
+		for _, dp := range x.Summary.GetDataPoints() {
+			ts := dp.GetTimeUnixNano()
+			newPoint(dp.GetAttributes(), ts,
+				kv(key+"_count", dp.GetCount(), COUNT),
+				kv(key+"_sum", dp.GetSum(), COUNT))
+
+			for _, q := range dp.GetQuantileValues() {
+				newPoint(append(dp.GetAttributes(),
+					otlpAttr(otlpKeyQuantile, strconv.FormatFloat(q.GetQuantile(), 'f', -1, 64))),
+					ts, kv(key, q.GetValue(), GAUGE))
+			}
+		}
+	}
+}
+
+// ToOTLPMetrics convert Metric points to OTLP metrics.
+//
+// Each numeric field is exported as a metric named after the field key,
+// COUNT fields are exported as cumulative monotonic sums, DELTA fields as
+// delta monotonic sums, other fields are exported as gauges. The point measurement is the instrumentation scope
+// name, non-numeric fields are ignored.
+func ToOTLPMetrics(pts []*Point, opts ...OTLPOption) []*metricspb.ResourceMetrics {
+	var (
+		o      = newOTLPOpt(opts...)
+		res    []*metricspb.ResourceMetrics
+		rmIdx  = map[string]*metricspb.ResourceMetrics{}
+		smIdx  = map[*metricspb.ResourceMetrics]map[string]*metricspb.ScopeMetrics{}
+		metIdx = map[*metricspb.ScopeMetrics]map[string]*metricspb.Metric{}
+	)
+
+	for _, pt := range pts {
+		if pt == nil {
+			continue
+		}
+
+		resAttrs, attrs := o.otlpResource(pt)
+
+		rid := otlpResourceID(resAttrs)
+		rm, ok := rmIdx[rid]
+		if !ok {
+			rm = &metricspb.ResourceMetrics{Resource: &resourcepb.Resource{Attributes: resAttrs}}
+			rmIdx[rid] = rm
+			smIdx[rm] = map[string]*metricspb.ScopeMetrics{}
+			res = append(res, rm)
+		}
+
+		sm, ok := smIdx[rm][pt.Name()]
+		if !ok {
+			sm = &metricspb.ScopeMetrics{Scope: &commonpb.InstrumentationScope{Name: pt.Name()}}
+			smIdx[rm][pt.Name()] = sm
+			metIdx[sm] = map[string]*metricspb.Metric{}
+			rm.ScopeMetrics = append(rm.ScopeMetrics, sm)
+		}
+
+		for _, f := range pt.Fields() {
+			dp := &metricspb.NumberDataPoint{
+				Attributes:   attrs,
+				TimeUnixNano: uint64(pt.Time().UnixNano()),
+			}
+
+			switch x := f.Val.(type) {
+			case *Field_I:
+				dp.Value = &metricspb.NumberDataPoint_AsInt{AsInt: x.I}
+			case *Field_U:
+				if x.U > math.MaxInt64 {
+					dp.Value = &metricspb.NumberDataPoint_AsDouble{AsDouble: float64(x.U)}
+				} else {
+					dp.Value = &metricspb.NumberDataPoint_AsInt{AsInt: int64(x.U)}
+				}
+			case *Field_F:
+				dp.Value = &metricspb.NumberDataPoint_AsDouble{AsDouble: x.F}
+			case *Field_B:
+				if x.B {
+					dp.Value = &metricspb.NumberDataPoint_AsInt{AsInt: 1}
+				} else {
+					dp.Value = &metricspb.NumberDataPoint_AsInt{AsInt: 0}
+				}
+			default: // non-numeric field ignored
+				continue
+			}
+
+			mid := f.Key + "\n" + f.Unit + "\n" + f.Type.String()
+			m, ok := metIdx[sm][mid]
+			if !ok {
+				m = &metricspb.Metric{Name: f.Key, Unit: f.Unit}
+				switch f.Type { //nolint:exhaustive
+				case COUNT:
+					m.Data = &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+						IsMonotonic:            true,
+						AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+					}}
+				case DELTA:
+					m.Data = &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+						IsMonotonic:            true,
+						AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+					}}
+				default:
+					m.Data = &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{}}
+				}
+
+				metIdx[sm][mid] = m
+				sm.Metrics = append(sm.Metrics, m)
+			}
+
+			switch x := m.Data.(type) {
+			case *metricspb.Metric_Sum:
+				x.Sum.DataPoints = append(x.Sum.DataPoints, dp)
+			case *metricspb.Metric_Gauge:
+				x.Gauge.DataPoints = append(x.Gauge.DataPoints, dp)
+			}
+		}
+	}
+
+	return res
+}
+
+var (
+	otlpSeverityStatus = []struct {
+		max    logspb.SeverityNumber
+		status string
+	}{
+		{logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG + 3, "debug"},
+		{logspb.SeverityNumber_SEVERITY_NUMBER_INFO + 3, "info"},
+		{logspb.SeverityNumber_SEVERITY_NUMBER_WARN + 3, "warning"},
+		{logspb.SeverityNumber_SEVERITY_NUMBER_ERROR + 3, "error"},
+		{logspb.SeverityNumber_SEVERITY_NUMBER_FATAL + 3, "critical"},
+	}
+
+	otlpStatusSeverity = map[string]logspb.SeverityNumber{
+		"debug":    logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG,
+		"info":     logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+		"notice":   logspb.SeverityNumber_SEVERITY_NUMBER_INFO2,
+		"warn":     logspb.SeverityNumber_SEVERITY_NUMBER_WARN,
+		"warning":  logspb.SeverityNumber_SEVERITY_NUMBER_WARN,
+		"error":    logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+		"critical": logspb.SeverityNumber_SEVERITY_NUMBER_FATAL,
+		"fatal":    logspb.SeverityNumber_SEVERITY_NUMBER_FATAL,
+		"alert":    logspb.SeverityNumber_SEVERITY_NUMBER_FATAL2,
+		"emerg":    logspb.SeverityNumber_SEVERITY_NUMBER_FATAL3,
+	}
+)
+
+func otlpLogStatus(lr *logspb.LogRecord) string {
+	if n := lr.GetSeverityNumber(); n != logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED {
+		for _, x := range otlpSeverityStatus {
+			if n <= x.max {
+				return x.status
+			}
+		}
+	}
+
+	return nonEmpty(strings.ToLower(lr.GetSeverityText()), DefaultLoggingStatus)
+}
+
+// FromOTLPLogs convert OTLP logs into Logging points.
+//
+// The point measurement is the service.name of the resource, log body is the
+// field message, severity is the tag status. Resource, scope and log
+// attributes are tags.
+func FromOTLPLogs(rls []*logspb.ResourceLogs, opts ...OTLPOption) (pts []*Point) {
+	o := newOTLPOpt(opts...)
+
+	for _, rl := range rls {
+		name := nonEmpty(serviceName(rl.GetResource()), otlpDefaultMeasurement)
+
+		for _, sl := range rl.GetScopeLogs() {
+			for _, lr := range sl.GetLogRecords() {
+				kvs := otlpAttrTags(nil, rl.GetResource().GetAttributes(), sl.GetScope().GetAttributes(), lr.GetAttributes())
+				kvs = kvs.AddTag(KeyStatus.Key(), otlpLogStatus(lr))
+				kvs = kvs.Add(otlpKeyMessage, otlpString(lr.GetBody()))
+
+				if id := hexID(lr.GetTraceId()); id != "" {
+					kvs = kvs.Add(otlpKeyTraceID, id)
+				}
+
+				if id := hexID(lr.GetSpanId()); id != "" {
+					kvs = kvs.Add(otlpKeySpanID, id)
+				}
+
+				ts := lr.GetTimeUnixNano()
+				if ts == 0 {
+					ts = lr.GetObservedTimeUnixNano()
+				}
+
+				pts = append(pts, o.newPoint(Logging, name, kvs, ts))
+			}
+		}
+	}
+
+	return pts
+}
+
+// ToOTLPLogs convert Logging points to OTLP logs.
+func ToOTLPLogs(pts []*Point, opts ...OTLPOption) []*logspb.ResourceLogs {
+	var (
+		o     = newOTLPOpt(opts...)
+		res   []*logspb.ResourceLogs
+		rlIdx = map[string]*logspb.ResourceLogs{}
+	)
+
+	for _, pt := range pts {
+		if pt == nil {
+			continue
+		}
+
+		resAttrs, attrs := o.otlpResource(pt)
+		if serviceName(&resourcepb.Resource{Attributes: resAttrs}) == "" {
+			resAttrs = append(resAttrs, otlpAttr(otlpKeyServiceName, pt.Name()))
+		}
+
+		rid := otlpResourceID(resAttrs)
+		rl, ok := rlIdx[rid]
+		if !ok {
+			rl = &logspb.ResourceLogs{
+				Resource:  &resourcepb.Resource{Attributes: resAttrs},
+				ScopeLogs: []*logspb.ScopeLogs{{}},
+			}
+			rlIdx[rid] = rl
+			res = append(res, rl)
+		}
+
+		msg, _ := pt.Get(otlpKeyMessage).(string)
+		traceID, _ := pt.Get(otlpKeyTraceID).(string)
+		spanID, _ := pt.Get(otlpKeySpanID).(string)
+
+		lr := &logspb.LogRecord{
+			TimeUnixNano: uint64(pt.Time().UnixNano()),
+			Body:         &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: msg}},
+			TraceId:      unhexID(traceID),
+			SpanId:       unhexID(spanID),
+		}
+
+		for _, kv := range attrs {
+			if kv.GetKey() == KeyStatus.Key() {
+				lr.SeverityText = otlpString(kv.GetValue())
+				lr.SeverityNumber = otlpStatusSeverity[strings.ToLower(lr.SeverityText)]
+			} else {
+				lr.Attributes = append(lr.Attributes, kv)
+			}
+		}
+
+		lr.Attributes = append(lr.Attributes, otlpFieldAttrs(pt, otlpKeyMessage, otlpKeyTraceID, otlpKeySpanID)...)
+
+		rl.ScopeLogs[0].LogRecords = append(rl.ScopeLogs[0].LogRecords, lr)
+	}
+
+	return res
+}
+
+// otlpDuration get span duration in microsecond, 0 if end before start.
+func otlpDuration(start, end uint64) int64 {
+	if end < start {
+		return 0
+	}
+
+	return int64((end - start) / uint64(time.Microsecond))
+}
+
+func otlpSpanKind(k tracepb.Span_SpanKind) string {
+	return strings.ToLower(strings.TrimPrefix(k.String(), "SPAN_KIND_"))
+}
+
+// FromOTLPSpans convert OTLP spans into Tracing points.
+//
+// The point measurement is the service.name of the resource, point time is
+// the span start time. Span name is the field operation, field start and
+// duration are in microsecond. Span kind and status are tags, resource, scope
+// and span attributes are tags too. Span events and links are ignored.
+func FromOTLPSpans(rss []*tracepb.ResourceSpans, opts ...OTLPOption) (pts []*Point) {
+	o := newOTLPOpt(opts...)
+
+	for _, rs := range rss {
+		name := nonEmpty(serviceName(rs.GetResource()), otlpDefaultMeasurement)
+
+		for _, ss := range rs.GetScopeSpans() {
+			for _, span := range ss.GetSpans() {
+				kvs := otlpAttrTags(nil, rs.GetResource().GetAttributes(), ss.GetScope().GetAttributes(), span.GetAttributes())
+				kvs = kvs.AddTag(otlpKeySpanKind, otlpSpanKind(span.GetKind()))
+
+				status := "ok"
+				if span.GetStatus().GetCode() == tracepb.Status_STATUS_CODE_ERROR {
+					status = "error"
+				}
+				kvs = kvs.AddTag(KeyStatus.Key(), status)
+
+				start, end := span.GetStartTimeUnixNano(), span.GetEndTimeUnixNano()
+				kvs = kvs.Add(otlpKeyTraceID, hexID(span.GetTraceId())).
+					Add(otlpKeySpanID, hexID(span.GetSpanId())).
+					Add(otlpKeyParentID, nonEmpty(hexID(span.GetParentSpanId()), "0")).
+					Add(otlpKeyOperation, span.GetName()).
+					Add(otlpKeyStart, int64(start/uint64(time.Microsecond))).
+					Add(otlpKeyDuration, otlpDuration(start, end))
+
+				if msg := span.GetStatus().GetMessage(); msg != "" {
+					kvs = kvs.Add(otlpKeyStatusMsg, msg)
+				}
+
+				if x := span.GetTraceState(); x != "" {
+					kvs = kvs.Add(otlpKeyTraceState, x)
+				}
+
+				pts = append(pts, o.newPoint(Tracing, name, kvs, start))
+			}
+		}
+	}
+
+	return pts
+}
+
+// ToOTLPSpans convert Tracing points to OTLP spans.
+func ToOTLPSpans(pts []*Point, opts ...OTLPOption) []*tracepb.ResourceSpans {
+	var (
+		o     = newOTLPOpt(opts...)
+		res   []*tracepb.ResourceSpans
+		rsIdx = map[string]*tracepb.ResourceSpans{}
+	)
+
+	for _, pt := range pts {
+		if pt == nil {
+			continue
+		}
+
+		resAttrs, attrs := o.otlpResource(pt)
+		if serviceName(&resourcepb.Resource{Attributes: resAttrs}) == "" {
+			resAttrs = append(resAttrs, otlpAttr(otlpKeyServiceName, pt.Name()))
+		}
+
+		rid := otlpResourceID(resAttrs)
+		rs, ok := rsIdx[rid]
+		if !ok {
+			rs = &tracepb.ResourceSpans{
+				Resource:   &resourcepb.Resource{Attributes: resAttrs},
+				ScopeSpans: []*tracepb.ScopeSpans{{}},
+			}
+			rsIdx[rid] = rs
+			res = append(res, rs)
+		}
+
+		start := uint64(pt.Time().UnixNano())
+		span := &tracepb.Span{
+			StartTimeUnixNano: start,
+			Status:            &tracepb.Status{},
+		}
+
+		if x, ok := pt.Get(otlpKeyDuration).(int64); ok {
+			span.EndTimeUnixNano = start + uint64(x)*uint64(time.Microsecond)
+		}
+
+		str := func(k string) string {
+			x, _ := pt.Get(k).(string)
+			return x
+		}
+
+		span.TraceId = unhexID(str(otlpKeyTraceID))
+		span.SpanId = unhexID(str(otlpKeySpanID))
+		if x := str(otlpKeyParentID); x != "0" {
+			span.ParentSpanId = unhexID(x)
+		}
+		span.Name = str(otlpKeyOperation)
+		span.TraceState = str(otlpKeyTraceState)
+		span.Status.Message = str(otlpKeyStatusMsg)
+
+		for _, kv := range attrs {
+			switch kv.GetKey() {
+			case otlpKeySpanKind:
+				k := "SPAN_KIND_" + strings.ToUpper(otlpString(kv.GetValue()))
+				span.Kind = tracepb.Span_SpanKind(tracepb.Span_SpanKind_value[k])
+			case KeyStatus.Key():
+				if otlpString(kv.GetValue()) == "error" {
+					span.Status.Code = tracepb.Status_STATUS_CODE_ERROR
+				}
+			default:
+				span.Attributes = append(span.Attributes, kv)
+			}
+		}
+
+		span.Attributes = append(span.Attributes, otlpFieldAttrs(pt,
+			otlpKeyTraceID, otlpKeySpanID, otlpKeyParentID, otlpKeyOperation,
+			otlpKeyStart, otlpKeyDuration, otlpKeyStatusMsg, otlpKeyTraceState)...)
+
+		rs.ScopeSpans[0].Spans = append(rs.ScopeSpans[0].Spans, span)
+	}
+
+	return res
+}
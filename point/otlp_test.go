@@ -0,0 +1,337 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package point
+
+import (
+	T "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func otlpTestResource() *resourcepb.Resource {
+	return &resourcepb.Resource{Attributes: []*commonpb.KeyValue{
+		otlpAttr("service.name", "checkout"),
+	}}
+}
+
+func TestOTLPMetrics(t *T.T) {
+	ts := uint64(time.Unix(100, 0).UnixNano())
+	sum := 12.5
+
+	rms := []*metricspb.ResourceMetrics{{
+		Resource: otlpTestResource(),
+		ScopeMetrics: []*metricspb.ScopeMetrics{{
+			Scope: &commonpb.InstrumentationScope{Name: "http"},
+			Metrics: []*metricspb.Metric{
+				{
+					Name: "requests",
+					Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+						IsMonotonic: true,
+						DataPoints: []*metricspb.NumberDataPoint{{
+							Attributes:   []*commonpb.KeyValue{otlpAttr("method", "GET")},
+							TimeUnixNano: ts,
+							Value:        &metricspb.NumberDataPoint_AsInt{AsInt: 42},
+						}},
+					}},
+				},
+				{
+					Name: "inflight",
+					Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+						DataPoints: []*metricspb.NumberDataPoint{{
+							TimeUnixNano: ts,
+							Value:        &metricspb.NumberDataPoint_AsDouble{AsDouble: 3},
+						}},
+					}},
+				},
+				{
+					Name: "latency",
+					Unit: "ms",
+					Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+						DataPoints: []*metricspb.HistogramDataPoint{{
+							TimeUnixNano:   ts,
+							Count:          6,
+							Sum:            &sum,
+							ExplicitBounds: []float64{1, 5},
+							BucketCounts:   []uint64{1, 2, 3},
+						}},
+					}},
+				},
+				{
+					Name: "size",
+					Data: &metricspb.Metric_Summary{Summary: &metricspb.Summary{
+						DataPoints: []*metricspb.SummaryDataPoint{{
+							TimeUnixNano: ts,
+							Count:        2,
+							Sum:          10,
+							QuantileValues: []*metricspb.SummaryDataPoint_ValueAtQuantile{
+								{Quantile: 0.5, Value: 4},
+								{Quantile: 0.99, Value: 6},
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	}}
+
+	pts := FromOTLPMetrics(rms)
+	// sum + gauge + histogram(1 + 3 buckets) + summary(1 + 2 quantiles)
+	require.Len(t, pts, 9)
+
+	for _, pt := range pts {
+		assert.Equal(t, "http", pt.Name())
+		assert.Equal(t, "checkout", pt.GetTag("service.name"))
+		assert.Equal(t, int64(ts), pt.Time().UnixNano())
+	}
+
+	f := pts[0].Fields().Get("requests")
+	assert.Equal(t, int64(42), f.GetI())
+	assert.Equal(t, COUNT, f.Type)
+	assert.Equal(t, "GET", pts[0].GetTag("method"))
+
+	assert.Equal(t, GAUGE, pts[1].Fields().Get("inflight").Type)
+
+	f = pts[2].Fields().Get("latency_count")
+	assert.Equal(t, uint64(6), f.GetU())
+	assert.Equal(t, "ms", f.Unit)
+	assert.Equal(t, sum, pts[2].Get("latency_sum"))
+
+	var buckets []uint64
+	for _, pt := range pts[3:6] {
+		buckets = append(buckets, pt.Get("latency_bucket").(uint64))
+	}
+	assert.Equal(t, []uint64{1, 3, 6}, buckets) // cumulative
+	assert.Equal(t, "5", pts[4].GetTag("le"))
+	assert.Equal(t, "+Inf", pts[5].GetTag("le"))
+
+	assert.Equal(t, 10.0, pts[6].Get("size_sum"))
+	assert.Equal(t, "0.99", pts[8].GetTag("quantile"))
+	assert.Equal(t, 6.0, pts[8].Get("size"))
+
+	t.Run(`export`, func(t *T.T) {
+		res := ToOTLPMetrics(pts[:2])
+		require.Len(t, res, 1)
+		assert.Equal(t, "checkout", serviceName(res[0].GetResource()))
+
+		require.Len(t, res[0].ScopeMetrics, 1)
+		sm := res[0].ScopeMetrics[0]
+		assert.Equal(t, "http", sm.GetScope().GetName())
+		require.Len(t, sm.Metrics, 2)
+
+		requests := sm.Metrics[0]
+		assert.Equal(t, "requests", requests.GetName())
+		assert.True(t, requests.GetSum().GetIsMonotonic())
+		dp := requests.GetSum().GetDataPoints()[0]
+		assert.Equal(t, int64(42), dp.GetAsInt())
+		assert.Equal(t, ts, dp.GetTimeUnixNano())
+		assert.Equal(t, "method", dp.GetAttributes()[0].GetKey())
+
+		assert.Equal(t, 3.0, sm.Metrics[1].GetGauge().GetDataPoints()[0].GetAsDouble())
+
+		// round trip
+		back := FromOTLPMetrics(res)
+		require.Len(t, back, 2)
+		for i := range back {
+			eq, reason := pts[i].EqualWithReason(back[i])
+			assert.True(t, eq, "reason: %s", reason)
+		}
+	})
+
+	t.Run(`temporality`, func(t *T.T) {
+		delta := metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+		cumulative := metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+
+		sumOf := func(name string, at metricspb.AggregationTemporality) *metricspb.Metric {
+			return &metricspb.Metric{
+				Name: name,
+				Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+					IsMonotonic:            true,
+					AggregationTemporality: at,
+					DataPoints: []*metricspb.NumberDataPoint{{
+						TimeUnixNano: ts,
+						Value:        &metricspb.NumberDataPoint_AsInt{AsInt: 1},
+					}},
+				}},
+			}
+		}
+
+		pts := FromOTLPMetrics([]*metricspb.ResourceMetrics{{
+			ScopeMetrics: []*metricspb.ScopeMetrics{{
+				Metrics: []*metricspb.Metric{
+					sumOf("delta_sum", delta),
+					sumOf("cumulative_sum", cumulative),
+					{
+						Name: "delta_hist",
+						Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+							AggregationTemporality: delta,
+							DataPoints: []*metricspb.HistogramDataPoint{{
+								TimeUnixNano: ts,
+								Count:        1,
+								BucketCounts: []uint64{1},
+							}},
+						}},
+					},
+				},
+			}},
+		}})
+		require.Len(t, pts, 4)
+
+		assert.Equal(t, DELTA, pts[0].Fields().Get("delta_sum").Type)
+		assert.Equal(t, COUNT, pts[1].Fields().Get("cumulative_sum").Type)
+		assert.Equal(t, DELTA, pts[2].Fields().Get("delta_hist_count").Type)
+		assert.Equal(t, DELTA, pts[3].Fields().Get("delta_hist_bucket").Type)
+
+		res := ToOTLPMetrics(pts[:2])
+		require.Len(t, res, 1)
+		ms := res[0].ScopeMetrics[0].Metrics
+		require.Len(t, ms, 2)
+		assert.Equal(t, delta, ms[0].GetSum().GetAggregationTemporality())
+		assert.Equal(t, cumulative, ms[1].GetSum().GetAggregationTemporality())
+	})
+}
+
+func TestOTLPLogs(t *T.T) {
+	ts := uint64(time.Unix(100, 0).UnixNano())
+
+	rls := []*logspb.ResourceLogs{{
+		Resource: otlpTestResource(),
+		ScopeLogs: []*logspb.ScopeLogs{{
+			LogRecords: []*logspb.LogRecord{
+				{
+					TimeUnixNano:   ts,
+					SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_WARN2,
+					Body:           &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "disk almost full"}},
+					Attributes:     []*commonpb.KeyValue{otlpAttr("disk", "/dev/sda")},
+					TraceId:        []byte{1, 2, 3, 4},
+				},
+				{
+					ObservedTimeUnixNano: ts + 1,
+					Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "no severity"}},
+				},
+			},
+		}},
+	}}
+
+	pts := FromOTLPLogs(rls)
+	require.Len(t, pts, 2)
+
+	pt := pts[0]
+	assert.Equal(t, "checkout", pt.Name())
+	assert.Equal(t, "warning", pt.GetTag("status"))
+	assert.Equal(t, "/dev/sda", pt.GetTag("disk"))
+	assert.Equal(t, "checkout", pt.GetTag("service_name")) // no dot in logging keys
+	assert.Equal(t, "disk almost full", pt.Get("message"))
+	assert.Equal(t, "01020304", pt.Get("trace_id"))
+	assert.Equal(t, int64(ts), pt.Time().UnixNano())
+
+	assert.Equal(t, DefaultLoggingStatus, pts[1].GetTag("status"))
+	assert.Equal(t, int64(ts+1), pts[1].Time().UnixNano())
+
+	t.Run(`export`, func(t *T.T) {
+		res := ToOTLPLogs(pts)
+		require.Len(t, res, 1)
+
+		lrs := res[0].ScopeLogs[0].LogRecords
+		require.Len(t, lrs, 2)
+
+		assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_WARN, lrs[0].GetSeverityNumber())
+		assert.Equal(t, "disk almost full", lrs[0].GetBody().GetStringValue())
+		assert.Equal(t, []byte{1, 2, 3, 4}, lrs[0].GetTraceId())
+
+		back := FromOTLPLogs(res)
+		require.Len(t, back, 2)
+		for i := range back {
+			eq, reason := pts[i].EqualWithReason(back[i])
+			assert.True(t, eq, "reason: %s", reason)
+		}
+	})
+}
+
+func TestOTLPSpans(t *T.T) {
+	start := uint64(time.Unix(100, 0).UnixNano())
+
+	rss := []*tracepb.ResourceSpans{{
+		Resource: otlpTestResource(),
+		ScopeSpans: []*tracepb.ScopeSpans{{
+			Spans: []*tracepb.Span{{
+				TraceId:           []byte{0xab, 0xcd},
+				SpanId:            []byte{0x01},
+				ParentSpanId:      []byte{0x02},
+				Name:              "GET /cart",
+				Kind:              tracepb.Span_SPAN_KIND_SERVER,
+				StartTimeUnixNano: start,
+				EndTimeUnixNano:   start + uint64(1500*time.Microsecond),
+				Attributes: []*commonpb.KeyValue{
+					otlpAttr("http.status_code", int64(500)),
+				},
+				Status: &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR, Message: "internal error"},
+			}},
+		}},
+	}}
+
+	pts := FromOTLPSpans(rss)
+	require.Len(t, pts, 1)
+
+	pt := pts[0]
+	assert.Equal(t, "checkout", pt.Name())
+	assert.Equal(t, "server", pt.GetTag("span_kind"))
+	assert.Equal(t, "error", pt.GetTag("status"))
+	assert.Equal(t, "500", pt.GetTag("http_status_code"))
+	assert.Equal(t, "abcd", pt.Get("trace_id"))
+	assert.Equal(t, "02", pt.Get("parent_id"))
+	assert.Equal(t, "GET /cart", pt.Get("operation"))
+	assert.Equal(t, int64(1500), pt.Get("duration"))
+	assert.Equal(t, "internal error", pt.Get("status_message"))
+
+	t.Run(`end-before-start`, func(t *T.T) {
+		pts := FromOTLPSpans([]*tracepb.ResourceSpans{{
+			ScopeSpans: []*tracepb.ScopeSpans{{
+				Spans: []*tracepb.Span{{
+					Name:              "clock-skew",
+					StartTimeUnixNano: start,
+					EndTimeUnixNano:   start - 1,
+				}},
+			}},
+		}})
+		require.Len(t, pts, 1)
+		assert.Equal(t, int64(0), pts[0].Get("duration"))
+	})
+
+	t.Run(`export`, func(t *T.T) {
+		res := ToOTLPSpans(pts)
+		require.Len(t, res, 1)
+
+		spans := res[0].ScopeSpans[0].Spans
+		require.Len(t, spans, 1)
+
+		span := spans[0]
+		assert.Equal(t, []byte{0xab, 0xcd}, span.GetTraceId())
+		assert.Equal(t, []byte{0x02}, span.GetParentSpanId())
+		assert.Equal(t, tracepb.Span_SPAN_KIND_SERVER, span.GetKind())
+		assert.Equal(t, tracepb.Status_STATUS_CODE_ERROR, span.GetStatus().GetCode())
+		assert.Equal(t, start+uint64(1500*time.Microsecond), span.GetEndTimeUnixNano())
+
+		back := FromOTLPSpans(res)
+		require.Len(t, back, 1)
+		eq, reason := pt.EqualWithReason(back[0])
+		assert.True(t, eq, "reason: %s", reason)
+	})
+
+	t.Run(`with-measurement`, func(t *T.T) {
+		pts := FromOTLPSpans(rss, WithOTLPMeasurement("otel-trace"),
+			WithOTLPPointOptions(WithExtraTags(map[string]string{"source": "otlp"})))
+		require.Len(t, pts, 1)
+		assert.Equal(t, "otel-trace", pts[0].Name())
+		assert.Equal(t, "otlp", pts[0].GetTag("source"))
+	})
+}
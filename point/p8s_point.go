@@ -6,6 +6,9 @@
 package point
 
 import (
+	"fmt"
+	"math"
+	"strconv"
 	"strings"
 	"time"
 
@@ -57,53 +60,190 @@ func mergePts(pts []*Point) []*Point {
 	return res
 }
 
-func doGatherPoints(reg prometheus.Gatherer) ([]*Point, error) {
+// GatherOption used to control how prometheus metrics converted to points.
+type GatherOption func(*gatherOpt)
+
+type gatherOpt struct {
+	histogramArray bool
+}
+
+// WithGatherHistogramArray set histogram buckets as array fields: field
+// <name>_le hold all bucket upper bounds(without +Inf), and field
+// <name>_bucket hold the cumulative count of each bucket(the last one is for
+// +Inf). By default, each bucket is a separate point with tag le.
+func WithGatherHistogramArray(on bool) GatherOption {
+	return func(o *gatherOpt) { o.histogramArray = on }
+}
+
+// exemplarDebug get exemplar of field as point debug info, the format is like
+// OpenMetrics: <field> # {<labels>} <value> <timestamp-ms>.
+func exemplarDebug(field string, e *dto.Exemplar) *Debug {
+	var labels []string
+	for _, lp := range e.GetLabel() {
+		labels = append(labels, fmt.Sprintf("%s=%q", lp.GetName(), lp.GetValue()))
+	}
+
+	info := fmt.Sprintf("%s # {%s} %v", field, strings.Join(labels, ","), e.GetValue())
+	if e.Timestamp != nil {
+		info += fmt.Sprintf(" %d", e.GetTimestamp().AsTime().UnixMilli())
+	}
+
+	return &Debug{Info: info}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func doGatherPoints(reg prometheus.Gatherer, opts ...GatherOption) ([]*Point, error) {
 	mfs, err := reg.Gather()
 	if err != nil {
 		return nil, err
 	}
 
+	o := &gatherOpt{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+
 	// All gathered data should have the same timestamp, we enforce it.
 	now := time.Now()
 
 	var pts []*Point
 	for _, mf := range mfs {
-		arr := strings.SplitN(mf.GetName(), "_", 2)
-
-		name := arr[0]
-		fieldName := arr[1]
+		name, fieldName := mf.GetName(), mf.GetName()
+		if arr := strings.SplitN(mf.GetName(), "_", 2); len(arr) == 2 {
+			name, fieldName = arr[0], arr[1]
+		}
 
 		for _, m := range mf.GetMetric() {
-			var kvs KVs
+			var tags KVs
 			for _, label := range m.GetLabel() {
-				kvs = append(kvs, NewKV(label.GetName(), label.GetValue(), WithKVTagSet(true)))
+				tags = append(tags, NewKV(label.GetName(), label.GetValue(), WithKVTagSet(true)))
+			}
+
+			// TODO: according to specific tags, we should make them as logging.
+			ts := now
+			if m.TimestampMs != nil { // use metric time
+				ts = time.Unix(0, int64(time.Millisecond)*m.GetTimestampMs())
+			}
+
+			ptOpts := append(DefaultMetricOptions(), WithTime(ts))
+
+			// newPoint add point with tags of the metric and extra kvs.
+			newPoint := func(kvs KVs, debugs ...*Debug) {
+				pt := NewPoint(name, append(append(KVs{}, tags...), kvs...), ptOpts...)
+				for _, d := range debugs {
+					pt.AddDebug(d)
+				}
+				pts = append(pts, pt)
 			}
 
 			switch mf.GetType() {
 			case dto.MetricType_COUNTER:
-				kvs = append(kvs, NewKV(fieldName, m.GetCounter().GetValue()))
-			case dto.MetricType_SUMMARY:
-				avg := uint64(m.GetSummary().GetSampleSum()) / m.GetSummary().GetSampleCount()
-				kvs = append(kvs, NewKV(fieldName, avg))
+				var debugs []*Debug
+				if e := m.GetCounter().GetExemplar(); e != nil {
+					debugs = append(debugs, exemplarDebug(fieldName, e))
+				}
+
+				newPoint(KVs{NewKV(fieldName, m.GetCounter().GetValue(), WithKVType(COUNT))}, debugs...)
 
 			case dto.MetricType_GAUGE:
-				continue // TODO
-			case dto.MetricType_HISTOGRAM:
-				continue // TODO
+				newPoint(KVs{NewKV(fieldName, m.GetGauge().GetValue(), WithKVType(GAUGE))})
+
 			case dto.MetricType_UNTYPED:
-				continue // TODO
-			case dto.MetricType_GAUGE_HISTOGRAM:
-				continue // TODO
-			}
+				newPoint(KVs{NewKV(fieldName, m.GetUntyped().GetValue())})
 
-			// TODO: according to specific tags, we should make them as logging.
-			ts := now
-			if m.TimestampMs != nil { // use metric time
-				ts = time.Unix(0, int64(time.Millisecond)*m.GetTimestampMs())
-			}
+			case dto.MetricType_SUMMARY:
+				summary := m.GetSummary()
+				newPoint(KVs{
+					NewKV(fieldName+"_count", summary.GetSampleCount(), WithKVType(COUNT)),
+					NewKV(fieldName+"_sum", summary.GetSampleSum(), WithKVType(COUNT)),
+				})
+
+				for _, q := range summary.GetQuantile() {
+					newPoint(KVs{
+						NewKV("quantile", formatFloat(q.GetQuantile()), WithKVTagSet(true)),
+						NewKV(fieldName, q.GetValue(), WithKVType(GAUGE)),
+					})
+				}
+
+			case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+				t := COUNT
+				if mf.GetType() == dto.MetricType_GAUGE_HISTOGRAM {
+					t = GAUGE
+				}
+
+				hist := m.GetHistogram()
+
+				var (
+					les       []float64
+					counts    []uint64
+					exemplars []*Debug // exemplar of each bucket
+				)
+
+				for _, b := range hist.GetBucket() {
+					if math.IsInf(b.GetUpperBound(), 1) {
+						continue // +Inf added later
+					}
+
+					les = append(les, b.GetUpperBound())
+					counts = append(counts, b.GetCumulativeCount())
 
-			opts := append(DefaultMetricOptions(), WithTime(ts))
-			pts = append(pts, NewPoint(name, kvs, opts...))
+					var d *Debug
+					if e := b.GetExemplar(); e != nil {
+						d = exemplarDebug(fieldName+"_bucket", e)
+					}
+					exemplars = append(exemplars, d)
+				}
+
+				les = append(les, math.Inf(1))
+				counts = append(counts, hist.GetSampleCount())
+				exemplars = append(exemplars, nil)
+
+				kvs := KVs{
+					NewKV(fieldName+"_count", hist.GetSampleCount(), WithKVType(t)),
+					NewKV(fieldName+"_sum", hist.GetSampleSum(), WithKVType(t)),
+				}
+
+				if o.histogramArray {
+					kvs = append(kvs,
+						NewKV(fieldName+"_le", les[:len(les)-1]),
+						NewKV(fieldName+"_bucket", counts, WithKVType(t)))
+
+					var debugs []*Debug
+					for _, d := range exemplars {
+						if d != nil {
+							debugs = append(debugs, d)
+						}
+					}
+
+					newPoint(kvs, debugs...)
+					continue
+				}
+
+				newPoint(kvs)
+
+				for i, le := range les {
+					leStr := "+Inf"
+					if !math.IsInf(le, 1) {
+						leStr = formatFloat(le)
+					}
+
+					kvs := KVs{
+						NewKV("le", leStr, WithKVTagSet(true)),
+						NewKV(fieldName+"_bucket", counts[i], WithKVType(t)),
+					}
+
+					if exemplars[i] != nil {
+						newPoint(kvs, exemplars[i])
+					} else {
+						newPoint(kvs)
+					}
+				}
+			}
 		}
 	}
 
@@ -112,6 +252,11 @@ func doGatherPoints(reg prometheus.Gatherer) ([]*Point, error) {
 
 // GatherPoints gather all metrics in global registry, but convert these metrics
 // to Point.
-func GatherPoints(reg prometheus.Gatherer) ([]*Point, error) {
-	return doGatherPoints(reg)
+//
+// Counters and gauges are fields named after the metric, summaries are
+// converted to fields <name>_count/<name>_sum and points with quantile tag,
+// histograms are converted to fields <name>_count/<name>_sum and points with
+// le tag(see WithGatherHistogramArray). Exemplars are added as point debug info.
+func GatherPoints(reg prometheus.Gatherer, opts ...GatherOption) ([]*Point, error) {
+	return doGatherPoints(reg, opts...)
 }
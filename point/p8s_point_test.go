@@ -123,4 +123,127 @@ func TestGatherPoint(t *T.T) {
 			t.Logf("point: %s", pt.LineProto())
 		}
 	})
+
+	t.Run(`gauge-and-untyped`, func(t *T.T) {
+		reg := prometheus.NewRegistry()
+
+		g := prometheus.NewGauge(prometheus.GaugeOpts{Namespace: ns, Name: "inflight"})
+		g.Set(3)
+
+		u := prometheus.NewUntypedFunc(prometheus.UntypedOpts{Namespace: ns, Name: "untyped"},
+			func() float64 { return 1.5 })
+
+		noUnderscore := prometheus.NewGauge(prometheus.GaugeOpts{Name: "up"})
+		noUnderscore.Set(1)
+
+		reg.MustRegister(g, u, noUnderscore)
+
+		pts, err := GatherPoints(reg)
+		require.NoError(t, err)
+		require.Len(t, pts, 3)
+
+		f := pts[0].Fields().Get("inflight")
+		assert.Equal(t, 3.0, f.GetF())
+		assert.Equal(t, GAUGE, f.Type)
+
+		assert.Equal(t, 1.5, pts[1].Get("untyped"))
+
+		assert.Equal(t, "up", pts[2].Name())
+		assert.Equal(t, 1.0, pts[2].Get("up"))
+	})
+
+	t.Run(`summary`, func(t *T.T) {
+		reg := prometheus.NewRegistry()
+		s := prometheus.NewSummary(prometheus.SummaryOpts{
+			Namespace:  ns,
+			Name:       "latency",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01},
+		})
+		reg.MustRegister(s)
+
+		for i := 1; i <= 10; i++ {
+			s.Observe(float64(i))
+		}
+
+		pts, err := GatherPoints(reg)
+		require.NoError(t, err)
+		require.Len(t, pts, 3) // count/sum + 2 quantiles
+
+		assert.Equal(t, uint64(10), pts[0].Get("latency_count"))
+		assert.Equal(t, 55.0, pts[0].Get("latency_sum"))
+
+		assert.Equal(t, "0.5", pts[1].GetTag("quantile"))
+		assert.Equal(t, 5.0, pts[1].Get("latency"))
+		assert.Equal(t, "0.9", pts[2].GetTag("quantile"))
+		assert.Equal(t, 9.0, pts[2].Get("latency"))
+	})
+
+	t.Run(`histogram`, func(t *T.T) {
+		reg := prometheus.NewRegistry()
+		h := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: ns,
+			Name:      "size",
+			Buckets:   []float64{1, 10},
+		}, []string{"api"})
+		reg.MustRegister(h)
+
+		h.WithLabelValues("/v1/write").Observe(0.5)
+		h.WithLabelValues("/v1/write").(prometheus.ExemplarObserver).
+			ObserveWithExemplar(5, prometheus.Labels{"trace_id": "abc"})
+		h.WithLabelValues("/v1/write").Observe(100)
+
+		pts, err := GatherPoints(reg)
+		require.NoError(t, err)
+		require.Len(t, pts, 4) // count/sum + 3 buckets
+
+		for _, pt := range pts {
+			assert.Equal(t, "/v1/write", pt.GetTag("api"))
+		}
+
+		assert.Equal(t, uint64(3), pts[0].Get("size_count"))
+		assert.Equal(t, 105.5, pts[0].Get("size_sum"))
+		assert.Equal(t, COUNT, pts[0].Fields().Get("size_count").Type)
+
+		var (
+			les    []string
+			counts []uint64
+		)
+		for _, pt := range pts[1:] {
+			les = append(les, pt.GetTag("le"))
+			counts = append(counts, pt.Get("size_bucket").(uint64))
+		}
+		assert.Equal(t, []string{"1", "10", "+Inf"}, les)
+		assert.Equal(t, []uint64{1, 2, 3}, counts)
+
+		// exemplar on bucket le=10
+		require.Len(t, pts[2].pt.Debugs, 1)
+		assert.Contains(t, pts[2].pt.Debugs[0].Info, `size_bucket # {trace_id="abc"} 5`)
+
+		t.Run(`as-array`, func(t *T.T) {
+			pts, err := GatherPoints(reg, WithGatherHistogramArray(true))
+			require.NoError(t, err)
+			require.Len(t, pts, 1)
+
+			pt := pts[0]
+			assert.Equal(t, []float64{1, 10}, pt.Get("size_le"))
+			assert.Equal(t, []uint64{1, 2, 3}, pt.Get("size_bucket"))
+			assert.Len(t, pt.pt.Debugs, 1)
+		})
+	})
+
+	t.Run(`counter-exemplar`, func(t *T.T) {
+		reg := prometheus.NewRegistry()
+		cnt := prometheus.NewCounter(prometheus.CounterOpts{Namespace: ns, Name: "requests"})
+		reg.MustRegister(cnt)
+
+		cnt.(prometheus.ExemplarAdder).AddWithExemplar(2, prometheus.Labels{"trace_id": "xyz"})
+
+		pts, err := GatherPoints(reg)
+		require.NoError(t, err)
+		require.Len(t, pts, 1)
+
+		assert.Equal(t, COUNT, pts[0].Fields().Get("requests").Type)
+		require.Len(t, pts[0].pt.Debugs, 1)
+		assert.Contains(t, pts[0].pt.Debugs[0].Info, `requests # {trace_id="xyz"} 2`)
+	})
 }
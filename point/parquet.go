@@ -0,0 +1,877 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package point
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/apache/arrow/go/v12/parquet"
+	"github.com/apache/arrow/go/v12/parquet/compress"
+	"github.com/apache/arrow/go/v12/parquet/file"
+	"github.com/apache/arrow/go/v12/parquet/pqarrow"
+	proto "github.com/gogo/protobuf/proto"
+	types "github.com/gogo/protobuf/types"
+)
+
+// Parquet file hold points of a single measurement: column time is the
+// point's timestamp(in nanosecond), other columns are tags and fields typed
+// by their KeyType. Any arrays and maps are nested list<element> and
+// map<string, element> columns, the element is a struct of nullable
+// i/u/f/b/d/s members(same as BasicTypes). Column info(key type, tag, unit and
+// metric type) are the same as Arrow encoding, stored in the Arrow schema
+// within the file.
+const (
+	parquetMetaCategory = "category"
+	parquetMetaAnyType  = "any_type"
+
+	defaultParquetRowGroupSize = 64 * 1024
+	defaultParquetBatchSize    = 1024
+)
+
+var parquetElemType = arrow.StructOf(
+	arrow.Field{Name: "i", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+	arrow.Field{Name: "u", Type: arrow.PrimitiveTypes.Uint64, Nullable: true},
+	arrow.Field{Name: "f", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+	arrow.Field{Name: "b", Type: arrow.FixedWidthTypes.Boolean, Nullable: true},
+	arrow.Field{Name: "d", Type: arrow.BinaryTypes.Binary, Nullable: true},
+	arrow.Field{Name: "s", Type: arrow.BinaryTypes.String, Nullable: true},
+)
+
+// ParquetOption used to configure the ParquetWriter and ParquetReader.
+type ParquetOption func(*parquetOption)
+
+type parquetOption struct {
+	rowGroupSize int
+	batchSize    int
+	compression  compress.Compression
+	category     Category
+	measurement  string
+	columns      KVs
+}
+
+// WithParquetRowGroupSize set max rows of each row group on writing, default 64K.
+func WithParquetRowGroupSize(n int) ParquetOption {
+	return func(o *parquetOption) { o.rowGroupSize = n }
+}
+
+// WithParquetCompression set compression codec on writing, default snappy.
+func WithParquetCompression(c compress.Compression) ParquetOption {
+	return func(o *parquetOption) { o.compression = c }
+}
+
+// WithParquetCategory set category of points on writing, the category
+// recorded in the file.
+func WithParquetCategory(c Category) ParquetOption {
+	return func(o *parquetOption) { o.category = c }
+}
+
+// WithParquetColumns declare columns of the file up front on writing, the key
+// type, tag, unit and metric type of each column are from kvs(values are
+// ignored). By default, columns are from points of the first row group.
+func WithParquetColumns(kvs KVs) ParquetOption {
+	return func(o *parquetOption) { o.columns = kvs }
+}
+
+// WithParquetBatchSize set max points passed to the callback on reading, default 1024.
+func WithParquetBatchSize(n int) ParquetOption {
+	return func(o *parquetOption) { o.batchSize = n }
+}
+
+// WithParquetMeasurement set measurement name on reading file that not
+// written by ParquetWriter(no measurement recorded).
+func WithParquetMeasurement(name string) ParquetOption {
+	return func(o *parquetOption) { o.measurement = name }
+}
+
+func newParquetOption(opts ...ParquetOption) *parquetOption {
+	o := &parquetOption{
+		rowGroupSize: defaultParquetRowGroupSize,
+		batchSize:    defaultParquetBatchSize,
+		compression:  compress.Codecs.Snappy,
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+
+	return o
+}
+
+// parquetColumn is a tag or field column within the file. Different from
+// Arrow encoding, columns are identified by key only, the key type, unit and
+// metric type are from the first observed value.
+type parquetColumn struct {
+	arrowColumn
+	anyType string // type URL of Any value
+}
+
+func newParquetColumn(kv *Field) *parquetColumn {
+	c := &parquetColumn{arrowColumn: newArrowColumn(kv)}
+	if a := kv.GetA(); a != nil {
+		c.anyType = a.TypeUrl
+	}
+	return c
+}
+
+func (c *parquetColumn) dataType() arrow.DataType {
+	switch c.t { //nolint:exhaustive
+	case S:
+		return arrow.BinaryTypes.String
+	case A:
+		switch c.anyType {
+		case ArrayFieldType:
+			return arrow.ListOf(parquetElemType)
+		case DictFieldType:
+			return arrow.MapOf(arrow.BinaryTypes.String, parquetElemType)
+		default: // marshaled Any
+			return arrow.BinaryTypes.Binary
+		}
+	default:
+		return c.arrowColumn.dataType()
+	}
+}
+
+func (c *parquetColumn) field() arrow.Field {
+	f := c.arrowColumn.field()
+	f.Type = c.dataType()
+
+	if c.anyType != "" {
+		f.Metadata = arrow.NewMetadata(
+			append(f.Metadata.Keys(), parquetMetaAnyType),
+			append(f.Metadata.Values(), c.anyType))
+	}
+
+	return f
+}
+
+// match test if kv's value can be stored in c without conversion.
+func (c *parquetColumn) match(kv *Field) bool {
+	if t := PBType(kv.Val); t != c.t {
+		return false
+	}
+
+	if a := kv.GetA(); a != nil {
+		return a.TypeUrl == c.anyType
+	}
+
+	return true
+}
+
+// ParquetSchemaError returned by ParquetWriter.Write() on point with keys not
+// within the schema of the file. Points before it are written and the writer
+// still usable, so the caller can Close() the file and write the point(and the
+// rest points) into a new file.
+type ParquetSchemaError struct {
+	Index int      // index of the point within points of Write()
+	Keys  []string // keys not within the schema
+}
+
+func (e *ParquetSchemaError) Error() string {
+	return fmt.Sprintf("point #%d: keys %q not within the parquet schema", e.Index, e.Keys)
+}
+
+// ParquetWriter write points of a single measurement into Parquet file.
+//
+// A Parquet file has a single schema, which is fixed before the first row
+// group written: columns are declared by WithParquetColumns, or collected
+// from points of the first row group(so the first row group of points are
+// held until it's full or Close(), and should not be modified(or put back to
+// the point pool) until then). After that, points are appended to the row
+// group being built and each full row group is flushed to the file, so the
+// memory is bounded by the row group size.
+//
+// Points with keys not within the fixed schema are not written, Write() fail
+// with *ParquetSchemaError. Values type conflicts with the column(the first
+// observed value) and not convertable are dropped, see Dropped().
+type ParquetWriter struct {
+	w   io.Writer
+	opt *parquetOption
+
+	name    string
+	npts    int
+	pts     []*Point // points before the schema fixed
+	cols    []*parquetColumn
+	idx     map[string]int
+	dropped int
+	closed  bool
+	err     error // the writer is unusable on error
+
+	// available after the schema fixed
+	fw    *pqarrow.FileWriter
+	rb    *array.RecordBuilder
+	row   []*Field
+	nrows int // rows within rb
+}
+
+// NewParquetWriter create a ParquetWriter on w.
+func NewParquetWriter(w io.Writer, opts ...ParquetOption) *ParquetWriter {
+	pw := &ParquetWriter{
+		w:   w,
+		opt: newParquetOption(opts...),
+		idx: map[string]int{},
+	}
+
+	for _, kv := range pw.opt.columns {
+		pw.addColumn(kv)
+	}
+
+	return pw
+}
+
+// parquetIgnored test if kv can not be a column.
+func parquetIgnored(kv *Field) bool {
+	t := PBType(kv.Val)
+	return t == X || t == NIL || kv.Key == arrowColTime
+}
+
+// addColumn add column of kv if not exist.
+func (pw *ParquetWriter) addColumn(kv *Field) {
+	if _, ok := pw.idx[kv.Key]; ok || parquetIgnored(kv) {
+		return
+	}
+
+	pw.idx[kv.Key] = len(pw.cols)
+	pw.cols = append(pw.cols, newParquetColumn(kv))
+}
+
+// Write add points to the writer. All points should be the same measurement.
+func (pw *ParquetWriter) Write(pts ...*Point) error {
+	if pw.err != nil {
+		return pw.err
+	}
+
+	if pw.closed {
+		return fmt.Errorf("parquet writer closed")
+	}
+
+	for i, pt := range pts {
+		if pt == nil {
+			continue
+		}
+
+		if pw.npts == 0 {
+			pw.name = pt.pt.Name
+		} else if pt.pt.Name != pw.name {
+			return fmt.Errorf("measurement %q not match %q", pt.pt.Name, pw.name)
+		}
+
+		if pw.fw != nil || pw.opt.columns != nil { // schema fixed
+			if keys := pw.unknownKeys(pt); len(keys) > 0 {
+				return &ParquetSchemaError{Index: i, Keys: keys}
+			}
+		}
+		pw.npts++
+
+		if pw.fw != nil {
+			if err := pw.append(pt); err != nil {
+				return pw.fail(err)
+			}
+			continue
+		}
+
+		if pw.opt.columns == nil {
+			for _, kv := range pt.pt.Fields {
+				pw.addColumn(kv)
+			}
+		}
+
+		pw.pts = append(pw.pts, pt)
+
+		if pw.opt.columns != nil || len(pw.pts) >= pw.opt.rowGroupSize {
+			if err := pw.open(); err != nil {
+				return pw.fail(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Dropped get count of values dropped on type conflicts.
+func (pw *ParquetWriter) Dropped() int {
+	return pw.dropped
+}
+
+// unknownKeys get keys of pt not within the schema.
+func (pw *ParquetWriter) unknownKeys(pt *Point) (keys []string) {
+	for _, kv := range pt.pt.Fields {
+		if _, ok := pw.idx[kv.Key]; !ok && !parquetIgnored(kv) {
+			keys = append(keys, kv.Key)
+		}
+	}
+
+	return keys
+}
+
+func (pw *ParquetWriter) schema() *arrow.Schema {
+	fields := []arrow.Field{
+		{Name: arrowColTime, Type: arrow.FixedWidthTypes.Timestamp_ns},
+	}
+
+	for _, c := range pw.cols {
+		fields = append(fields, c.field())
+	}
+
+	keys := []string{arrowMetaMeasurement}
+	vals := []string{pw.name}
+
+	if pw.opt.category != UnknownCategory {
+		keys = append(keys, parquetMetaCategory)
+		vals = append(vals, pw.opt.category.String())
+	}
+
+	md := arrow.NewMetadata(keys, vals)
+	return arrow.NewSchema(fields, &md)
+}
+
+// open fix the schema and start the file, points held before are appended.
+func (pw *ParquetWriter) open() error {
+	var (
+		mem    = memory.NewGoAllocator()
+		schema = pw.schema()
+		props  = parquet.NewWriterProperties(
+			parquet.WithAllocator(mem),
+			parquet.WithCompression(pw.opt.compression),
+			parquet.WithMaxRowGroupLength(int64(pw.opt.rowGroupSize)),
+		)
+	)
+
+	fw, err := pqarrow.NewFileWriter(schema, pw.w, props,
+		pqarrow.NewArrowWriterProperties(pqarrow.WithAllocator(mem), pqarrow.WithStoreSchema()))
+	if err != nil {
+		return err
+	}
+
+	pw.fw = fw
+	pw.rb = array.NewRecordBuilder(mem, schema)
+	pw.row = make([]*Field, len(pw.cols))
+
+	pts := pw.pts
+	pw.pts = nil
+
+	for _, pt := range pts {
+		if err := pw.append(pt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fail release the file on error, the error returned on later calls.
+func (pw *ParquetWriter) fail(err error) error {
+	pw.err = err
+
+	if pw.fw != nil {
+		pw.fw.Close() //nolint:errcheck,gosec
+		pw.rb.Release()
+	}
+
+	return err
+}
+
+// Close flush the last row group and close the file.
+func (pw *ParquetWriter) Close() error {
+	if pw.err != nil || pw.closed {
+		return pw.err
+	}
+	pw.closed = true
+
+	if pw.fw == nil {
+		if err := pw.open(); err != nil {
+			return pw.fail(err)
+		}
+	}
+
+	if pw.nrows > 0 {
+		if err := pw.flush(); err != nil {
+			return pw.fail(err)
+		}
+	}
+
+	pw.rb.Release()
+	return pw.fw.Close()
+}
+
+// flush write rows within the record builder as a row group.
+func (pw *ParquetWriter) flush() error {
+	rec := pw.rb.NewRecord()
+	defer rec.Release()
+
+	pw.nrows = 0
+	return pw.fw.Write(rec)
+}
+
+// append append pt as a row, the row group flushed if full.
+func (pw *ParquetWriter) append(pt *Point) error {
+	row := pw.row
+	for i := range row {
+		row[i] = nil
+	}
+
+	for _, kv := range pt.pt.Fields { // keys not within the schema checked on Write()
+		if i, ok := pw.idx[kv.Key]; ok && row[i] == nil {
+			row[i] = kv
+		}
+	}
+
+	pw.rb.Field(0).(*array.TimestampBuilder).Append(arrow.Timestamp(pt.pt.Time))
+
+	for i, kv := range row {
+		c := pw.cols[i]
+
+		if kv != nil && !c.match(kv) {
+			kv = pw.coerce(c, kv)
+		}
+
+		if err := parquetAppend(pw.rb.Field(i+1), kv); err != nil {
+			return fmt.Errorf("column %q: %w", c.key, err)
+		}
+	}
+
+	if pw.nrows++; pw.nrows >= pw.opt.rowGroupSize {
+		return pw.flush()
+	}
+
+	return nil
+}
+
+// coerce convert kv to the column's type, nil returned if not convertable.
+func (pw *ParquetWriter) coerce(c *parquetColumn, kv *Field) *Field {
+	if PBType(kv.Val) == X {
+		return nil
+	}
+
+	if c.t != A {
+		if v, ok := coerceFieldValue(kv, c.t); ok {
+			return NewKV(kv.Key, v)
+		}
+	}
+
+	pw.dropped++
+	return nil
+}
+
+func parquetAppend(b array.Builder, kv *Field) error {
+	if kv == nil {
+		b.AppendNull()
+		return nil
+	}
+
+	x, ok := kv.Val.(*Field_A)
+	if !ok {
+		return arrowAppend(b, kv)
+	}
+
+	switch x.A.TypeUrl {
+	case ArrayFieldType:
+		var arr Array
+		if err := proto.Unmarshal(x.A.Value, &arr); err != nil {
+			return err
+		}
+
+		lb := b.(*array.ListBuilder)
+		lb.Append(true)
+
+		sb := lb.ValueBuilder().(*array.StructBuilder)
+		for _, v := range arr.Arr {
+			parquetAppendElem(sb, v)
+		}
+
+	case DictFieldType:
+		var m Map
+		if err := proto.Unmarshal(x.A.Value, &m); err != nil {
+			return err
+		}
+
+		keys := make([]string, 0, len(m.Map))
+		for k := range m.Map {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		mb := b.(*array.MapBuilder)
+		mb.Append(true)
+
+		kb := mb.KeyBuilder().(*array.StringBuilder)
+		sb := mb.ItemBuilder().(*array.StructBuilder)
+		for _, k := range keys {
+			kb.Append(k)
+			parquetAppendElem(sb, m.Map[k])
+		}
+
+	default:
+		return arrowAppend(b, kv)
+	}
+
+	return nil
+}
+
+func parquetAppendElem(sb *array.StructBuilder, v *BasicTypes) {
+	sb.Append(true)
+
+	for i := 0; i < sb.NumField(); i++ {
+		fb := sb.FieldBuilder(i)
+
+		switch x := v.GetX().(type) {
+		case *BasicTypes_I:
+			if i == 0 {
+				fb.(*array.Int64Builder).Append(x.I)
+				continue
+			}
+		case *BasicTypes_U:
+			if i == 1 {
+				fb.(*array.Uint64Builder).Append(x.U)
+				continue
+			}
+		case *BasicTypes_F:
+			if i == 2 {
+				fb.(*array.Float64Builder).Append(x.F)
+				continue
+			}
+		case *BasicTypes_B:
+			if i == 3 {
+				fb.(*array.BooleanBuilder).Append(x.B)
+				continue
+			}
+		case *BasicTypes_D:
+			if i == 4 {
+				fb.(*array.BinaryBuilder).Append(x.D)
+				continue
+			}
+		case *BasicTypes_S:
+			if i == 5 {
+				fb.(*array.StringBuilder).Append(x.S)
+				continue
+			}
+		}
+
+		fb.AppendNull()
+	}
+}
+
+// ParquetReader read points from Parquet file.
+//
+// Columns are restored to tags and fields by column info written by
+// ParquetWriter. For columns without column info(such as columns of files
+// written by other tools), the key type is inferred from the column type, and
+// unsupported columns are ignored. Null values are ignored, so files written
+// with different schemas(such as new fields appeared) can be read in the same
+// way.
+type ParquetReader struct {
+	pf  *file.Reader
+	fr  *pqarrow.FileReader
+	opt *parquetOption
+
+	name    string
+	cat     Category
+	timeCol int
+	cols    []*parquetColumn // nil for time and ignored columns
+}
+
+// NewParquetReader create a ParquetReader on r.
+func NewParquetReader(r parquet.ReaderAtSeeker, opts ...ParquetOption) (*ParquetReader, error) {
+	opt := newParquetOption(opts...)
+	mem := memory.NewGoAllocator()
+
+	pf, err := file.NewParquetReader(r, file.WithReadProps(parquet.NewReaderProperties(mem)))
+	if err != nil {
+		return nil, err
+	}
+
+	fr, err := pqarrow.NewFileReader(pf,
+		pqarrow.ArrowReadProperties{BatchSize: int64(opt.batchSize)}, mem)
+	if err != nil {
+		pf.Close() //nolint:errcheck,gosec
+		return nil, err
+	}
+
+	pr := &ParquetReader{pf: pf, fr: fr, opt: opt, timeCol: -1}
+	if err := pr.init(); err != nil {
+		pf.Close() //nolint:errcheck,gosec
+		return nil, err
+	}
+
+	return pr, nil
+}
+
+func (pr *ParquetReader) init() error {
+	schema, err := pr.fr.Schema()
+	if err != nil {
+		return err
+	}
+
+	md := schema.Metadata()
+
+	if x, ok := md.GetValue(arrowMetaMeasurement); ok {
+		pr.name = x
+	} else {
+		pr.name = pr.opt.measurement
+	}
+
+	if pr.name == "" {
+		return fmt.Errorf("measurement not found")
+	}
+
+	if x, ok := md.GetValue(parquetMetaCategory); ok {
+		pr.cat = CatString(x)
+	}
+
+	pr.cols = make([]*parquetColumn, len(schema.Fields()))
+
+	for i, f := range schema.Fields() {
+		if _, ok := f.Type.(*arrow.TimestampType); ok && f.Name == arrowColTime {
+			pr.timeCol = i
+			continue
+		}
+
+		if _, ok := f.Metadata.GetValue(arrowMetaKeyType); ok {
+			c, err := arrowColumnOf(&f)
+			if err != nil {
+				return err
+			}
+
+			pr.cols[i] = &parquetColumn{arrowColumn: *c}
+			pr.cols[i].anyType, _ = f.Metadata.GetValue(parquetMetaAnyType)
+			continue
+		}
+
+		pr.cols[i] = parquetInferColumn(&f)
+	}
+
+	if pr.timeCol < 0 {
+		return fmt.Errorf("measurement %q: missing column %s", pr.name, arrowColTime)
+	}
+
+	return nil
+}
+
+// parquetInferColumn get column info from column type, nil if not supported.
+func parquetInferColumn(f *arrow.Field) *parquetColumn {
+	c := &parquetColumn{arrowColumn: arrowColumn{key: f.Name}}
+
+	switch f.Type.ID() { //nolint:exhaustive
+	case arrow.INT8, arrow.INT16, arrow.INT32, arrow.INT64:
+		c.t = I
+	case arrow.UINT8, arrow.UINT16, arrow.UINT32, arrow.UINT64:
+		c.t = U
+	case arrow.FLOAT32, arrow.FLOAT64:
+		c.t = F
+	case arrow.BOOL:
+		c.t = B
+	case arrow.STRING, arrow.LARGE_STRING:
+		c.t = S
+	case arrow.BINARY, arrow.LARGE_BINARY:
+		c.t = D
+	default:
+		return nil
+	}
+
+	return c
+}
+
+// Measurement get measurement name of the file.
+func (pr *ParquetReader) Measurement() string {
+	return pr.name
+}
+
+// Category get category recorded in the file.
+func (pr *ParquetReader) Category() Category {
+	return pr.cat
+}
+
+// NumRows get total points within the file.
+func (pr *ParquetReader) NumRows() int64 {
+	return pr.pf.NumRows()
+}
+
+// Read read all points in batches(see WithParquetBatchSize), if fn return
+// error, the read terminated.
+func (pr *ParquetReader) Read(fn func(pts []*Point) error) error {
+	rr, err := pr.fr.GetRecordReader(context.Background(), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer rr.Release()
+
+	for rr.Next() {
+		rec := rr.Record()
+
+		pts, err := pr.recordPoints(rec)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(pts); err != nil {
+			return err
+		}
+	}
+
+	if err := rr.Err(); err != nil && err != io.EOF { //nolint:errorlint
+		return err
+	}
+
+	return nil
+}
+
+// Close close the underlying file reader.
+func (pr *ParquetReader) Close() error {
+	return pr.pf.Close()
+}
+
+func (pr *ParquetReader) recordPoints(rec arrow.Record) ([]*Point, error) {
+	tscol, ok := rec.Column(pr.timeCol).(*array.Timestamp)
+	if !ok {
+		return nil, fmt.Errorf("measurement %q: invalid column %s type %s",
+			pr.name, arrowColTime, rec.Column(pr.timeCol).DataType())
+	}
+
+	toTime, err := tscol.DataType().(*arrow.TimestampType).GetToTimeFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	pts := make([]*Point, 0, rec.NumRows())
+
+	for i := 0; i < int(rec.NumRows()); i++ {
+		pbpt := &PBPoint{Name: pr.name, Time: toTime(tscol.Value(i)).UnixNano()}
+
+		for j, c := range pr.cols {
+			if c == nil {
+				continue
+			}
+
+			val, err := parquetValue(rec.Column(j), i, c)
+			if err != nil {
+				return nil, fmt.Errorf("measurement %q: column %q: %w", pr.name, c.key, err)
+			}
+
+			if val == nil {
+				continue
+			}
+
+			pbpt.Fields = append(pbpt.Fields, &Field{
+				Key:   c.key,
+				Val:   val,
+				IsTag: c.isTag,
+				Type:  c.mtype,
+				Unit:  c.unit,
+			})
+		}
+
+		pt := &Point{pt: pbpt}
+		pt.SetFlag(Ppb)
+		pts = append(pts, pt)
+	}
+
+	return pts, nil
+}
+
+func parquetValue(col arrow.Array, i int, c *parquetColumn) (isField_Val, error) {
+	if col.IsNull(i) {
+		return nil, nil
+	}
+
+	switch x := col.(type) {
+	case *array.Int8:
+		return &Field_I{I: int64(x.Value(i))}, nil
+	case *array.Int16:
+		return &Field_I{I: int64(x.Value(i))}, nil
+	case *array.Int32:
+		return &Field_I{I: int64(x.Value(i))}, nil
+	case *array.Uint8:
+		return &Field_U{U: uint64(x.Value(i))}, nil
+	case *array.Uint16:
+		return &Field_U{U: uint64(x.Value(i))}, nil
+	case *array.Uint32:
+		return &Field_U{U: uint64(x.Value(i))}, nil
+	case *array.Float32:
+		return &Field_F{F: float64(x.Value(i))}, nil
+	case *array.LargeString:
+		return &Field_S{S: string([]byte(x.Value(i)))}, nil // copy out of arrow buffer
+	case *array.LargeBinary:
+		return &Field_D{D: append([]byte(nil), x.Value(i)...)}, nil
+
+	case *array.Map: // Map before List: Map is a List
+		start, end := x.ValueOffsets(i)
+		keys, ok := x.Keys().(*array.String)
+		if !ok {
+			return nil, fmt.Errorf("invalid map key type %s", x.Keys().DataType())
+		}
+
+		items, ok := x.Items().(*array.Struct)
+		if !ok {
+			return nil, fmt.Errorf("invalid map item type %s", x.Items().DataType())
+		}
+
+		m := &Map{Map: make(map[string]*BasicTypes, end-start)}
+		for j := start; j < end; j++ {
+			m.Map[string([]byte(keys.Value(int(j))))] = parquetElem(items, int(j))
+		}
+
+		a, err := types.MarshalAny(m)
+		if err != nil {
+			return nil, err
+		}
+		return &Field_A{A: a}, nil
+
+	case *array.List:
+		start, end := x.ValueOffsets(i)
+		elems, ok := x.ListValues().(*array.Struct)
+		if !ok {
+			return nil, fmt.Errorf("invalid list element type %s", x.ListValues().DataType())
+		}
+
+		arr := &Array{Arr: make([]*BasicTypes, 0, end-start)}
+		for j := start; j < end; j++ {
+			arr.Arr = append(arr.Arr, parquetElem(elems, int(j)))
+		}
+
+		a, err := types.MarshalAny(arr)
+		if err != nil {
+			return nil, err
+		}
+		return &Field_A{A: a}, nil
+
+	default:
+		return arrowValue(col, i, &c.arrowColumn)
+	}
+}
+
+// parquetElem get i-th element of the element struct array.
+func parquetElem(st *array.Struct, i int) *BasicTypes {
+	for j := 0; j < st.NumField(); j++ {
+		f := st.Field(j)
+		if f.IsNull(i) {
+			continue
+		}
+
+		switch x := f.(type) {
+		case *array.Int64:
+			return &BasicTypes{X: &BasicTypes_I{I: x.Value(i)}}
+		case *array.Uint64:
+			return &BasicTypes{X: &BasicTypes_U{U: x.Value(i)}}
+		case *array.Float64:
+			return &BasicTypes{X: &BasicTypes_F{F: x.Value(i)}}
+		case *array.Boolean:
+			return &BasicTypes{X: &BasicTypes_B{B: x.Value(i)}}
+		case *array.Binary:
+			return &BasicTypes{X: &BasicTypes_D{D: append([]byte(nil), x.Value(i)...)}}
+		case *array.String:
+			return &BasicTypes{X: &BasicTypes_S{S: string([]byte(x.Value(i)))}}
+		}
+	}
+
+	return &BasicTypes{}
+}
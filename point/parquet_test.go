@@ -0,0 +1,244 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package point
+
+import (
+	"bytes"
+	T "testing"
+	"time"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/apache/arrow/go/v12/parquet/pqarrow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parquetReadAll(t *T.T, data []byte, opts ...ParquetOption) (*ParquetReader, []*Point) {
+	t.Helper()
+
+	pr, err := NewParquetReader(bytes.NewReader(data), opts...)
+	require.NoError(t, err)
+
+	var pts []*Point
+	require.NoError(t, pr.Read(func(x []*Point) error {
+		pts = append(pts, x...)
+		return nil
+	}))
+
+	return pr, pts
+}
+
+func TestParquet(t *T.T) {
+	t.Run(`basic`, func(t *T.T) {
+		EnableDictField = true
+		defer func() { EnableDictField = false }()
+
+		var pts []*Point
+		for i := 0; i < 10; i++ {
+			var kvs KVs
+			kvs = kvs.Add("i", int64(i), WithKVUnit("byte"), WithKVType(GAUGE)).
+				Add("u", uint64(i)).
+				Add("f", float64(i)/10, WithKVType(COUNT)).
+				Add("b", i%2 == 0).
+				Add("d", []byte("binary-data")).
+				Add("s", "text-data").
+				Add("arr", MustNewAnyArray(1, 2, 3)).
+				Add("map", MustNewAny(MustNewMap(map[string]any{"a": int64(1), "b": "x", "c": 3.14}))).
+				AddTag("host", "h1").
+				AddTag("service", "s1")
+
+			pts = append(pts, NewPoint("cpu", kvs, WithTime(time.Unix(0, int64(i)+1))))
+		}
+
+		var buf bytes.Buffer
+		pw := NewParquetWriter(&buf, WithParquetCategory(Metric), WithParquetRowGroupSize(3))
+		require.NoError(t, pw.Write(pts[:5]...))
+		require.NoError(t, pw.Write(pts[5:]...))
+		require.NoError(t, pw.Close())
+
+		pr, got := parquetReadAll(t, buf.Bytes(), WithParquetBatchSize(4))
+		defer pr.Close() //nolint:errcheck
+
+		assert.Equal(t, "cpu", pr.Measurement())
+		assert.Equal(t, Metric, pr.Category())
+		assert.Equal(t, int64(10), pr.NumRows())
+		assert.Equal(t, 4, pr.pf.NumRowGroups())
+
+		require.Len(t, got, len(pts))
+		for i := range pts {
+			// marshaled map are not ordered, compare them on raw value
+			assert.Equal(t, pts[i].Get("map"), got[i].Get("map"))
+
+			pts[i].Del("map")
+			got[i].Del("map")
+			assert.Equal(t, pts[i].Pretty(), got[i].Pretty())
+		}
+	})
+
+	t.Run(`schema-evolution`, func(t *T.T) {
+		pts := []*Point{
+			NewPoint("cpu", NewTags(map[string]string{"host": "h1"}).Add("f1", 1), WithTimestamp(1)),
+			NewPoint("cpu", NewTags(map[string]string{"host": "h2", "region": "r1"}).Add("f2", "abc"), WithTimestamp(2)),
+			NewPoint("cpu", KVs{}.Add("f1", 3.5).Add("f3", true), WithTimestamp(3)), // f1 conflict: float -> int
+			NewPoint("cpu", KVs{}.Add("f1", "not-a-number"), WithTimestamp(4)),      // f1 conflict: dropped
+		}
+
+		var buf bytes.Buffer
+		pw := NewParquetWriter(&buf)
+		require.NoError(t, pw.Write(pts...))
+		require.NoError(t, pw.Close())
+		assert.Equal(t, 1, pw.Dropped())
+
+		pr, got := parquetReadAll(t, buf.Bytes())
+		defer pr.Close() //nolint:errcheck
+
+		require.Len(t, got, 4)
+
+		assert.Equal(t, pts[0].Pretty(), got[0].Pretty())
+		assert.Equal(t, pts[1].Pretty(), got[1].Pretty())
+
+		assert.Equal(t, int64(3), got[2].Get("f1"))
+		assert.Equal(t, true, got[2].Get("f3"))
+		assert.Nil(t, got[2].Get("host"))
+
+		assert.Nil(t, got[3].Get("f1"))
+		assert.Len(t, got[3].KVs(), 0)
+	})
+
+	t.Run(`streaming`, func(t *T.T) {
+		var buf bytes.Buffer
+		pw := NewParquetWriter(&buf, WithParquetRowGroupSize(2))
+
+		require.NoError(t, pw.Write(
+			NewPoint("cpu", KVs{}.Add("f1", 1).AddTag("host", "h1"), WithTimestamp(1)),
+			NewPoint("cpu", KVs{}.Add("f1", 2), WithTimestamp(2)),
+		))
+
+		// schema fixed and the first row group flushed, no points held
+		assert.Empty(t, pw.pts)
+		assert.NotZero(t, buf.Len())
+
+		for i := 3; i <= 6; i++ {
+			require.NoError(t, pw.Write(NewPoint("cpu", KVs{}.Add("f1", i), WithTimestamp(int64(i)))))
+			assert.Empty(t, pw.pts)
+			assert.LessOrEqual(t, pw.nrows, 1)
+		}
+
+		// f2 not within the schema, the point not written
+		pts := []*Point{
+			NewPoint("cpu", KVs{}.Add("f1", 7), WithTimestamp(7)),
+			NewPoint("cpu", KVs{}.Add("f1", 8).Add("f2", "new-key"), WithTimestamp(8)),
+		}
+
+		err := pw.Write(pts...)
+		var serr *ParquetSchemaError
+		require.ErrorAs(t, err, &serr)
+		assert.Equal(t, 1, serr.Index)
+		assert.Equal(t, []string{"f2"}, serr.Keys)
+
+		require.NoError(t, pw.Close())
+		assert.Equal(t, 0, pw.Dropped())
+
+		pr, got := parquetReadAll(t, buf.Bytes())
+		defer pr.Close() //nolint:errcheck
+
+		assert.Equal(t, 4, pr.pf.NumRowGroups())
+		require.Len(t, got, 7)
+		for i, pt := range got {
+			assert.Equal(t, int64(i+1), pt.Get("f1"))
+		}
+		assert.Equal(t, "h1", got[0].GetTag("host"))
+
+		// rest points written into a new file with the new key
+		var next bytes.Buffer
+		pw = NewParquetWriter(&next)
+		require.NoError(t, pw.Write(pts[serr.Index:]...))
+		require.NoError(t, pw.Close())
+
+		pr2, got := parquetReadAll(t, next.Bytes())
+		defer pr2.Close() //nolint:errcheck
+
+		require.Len(t, got, 1)
+		assert.Equal(t, "new-key", got[0].Get("f2"))
+	})
+
+	t.Run(`declared-columns`, func(t *T.T) {
+		var buf bytes.Buffer
+		pw := NewParquetWriter(&buf, WithParquetColumns(KVs{}.
+			AddTag("host", "").
+			Add("f1", int64(0), WithKVUnit("ms"), WithKVType(GAUGE)).
+			Add("f2", "")))
+
+		var serr *ParquetSchemaError
+		require.ErrorAs(t, pw.Write(NewPoint("cpu", KVs{}.Add("f3", 1.0), WithTimestamp(1))), &serr)
+		assert.Equal(t, []string{"f3"}, serr.Keys) // f3 not declared
+
+		require.NoError(t, pw.Write(NewPoint("cpu", KVs{}.Add("f1", 2).Add("f2", "abc").AddTag("host", "h1"), WithTimestamp(2))))
+		assert.Empty(t, pw.pts) // schema declared, not held
+		require.NoError(t, pw.Close())
+		assert.Equal(t, 0, pw.Dropped())
+
+		pr, got := parquetReadAll(t, buf.Bytes())
+		defer pr.Close() //nolint:errcheck
+
+		require.Len(t, got, 1)
+
+		f1 := got[0].Fields().Get("f1")
+		assert.Equal(t, int64(2), f1.GetI())
+		assert.Equal(t, "ms", f1.Unit)
+		assert.Equal(t, GAUGE, f1.Type)
+		assert.Equal(t, "abc", got[0].Get("f2"))
+		assert.Equal(t, "h1", got[0].GetTag("host"))
+	})
+
+	t.Run(`mixed-measurement`, func(t *T.T) {
+		pw := NewParquetWriter(&bytes.Buffer{})
+		require.NoError(t, pw.Write(NewPoint("m1", KVs{}.Add("f", 1))))
+		assert.Error(t, pw.Write(NewPoint("m2", KVs{}.Add("f", 1))))
+	})
+
+	t.Run(`read-foreign-file`, func(t *T.T) {
+		mem := memory.NewGoAllocator()
+		schema := arrow.NewSchema([]arrow.Field{
+			{Name: "time", Type: arrow.FixedWidthTypes.Timestamp_ms},
+			{Name: "v", Type: arrow.PrimitiveTypes.Int32, Nullable: true},
+			{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true},
+			{Name: "ignored", Type: arrow.FixedWidthTypes.Date32, Nullable: true},
+		}, nil)
+
+		rb := array.NewRecordBuilder(mem, schema)
+		defer rb.Release()
+
+		rb.Field(0).(*array.TimestampBuilder).Append(arrow.Timestamp(1000))
+		rb.Field(1).(*array.Int32Builder).Append(42)
+		rb.Field(2).(*array.StringBuilder).Append("abc")
+		rb.Field(3).(*array.Date32Builder).Append(1)
+
+		rec := rb.NewRecord()
+		defer rec.Release()
+
+		var buf bytes.Buffer
+		fw, err := pqarrow.NewFileWriter(schema, &buf, nil, pqarrow.DefaultWriterProps())
+		require.NoError(t, err)
+		require.NoError(t, fw.Write(rec))
+		require.NoError(t, fw.Close())
+
+		_, err = NewParquetReader(bytes.NewReader(buf.Bytes()))
+		assert.Error(t, err) // measurement not set
+
+		pr, got := parquetReadAll(t, buf.Bytes(), WithParquetMeasurement("foreign"))
+		defer pr.Close() //nolint:errcheck
+
+		require.Len(t, got, 1)
+		assert.Equal(t, "foreign", got[0].Name())
+		assert.Equal(t, time.Unix(1, 0), got[0].Time())
+		assert.Equal(t, int64(42), got[0].Get("v"))
+		assert.Equal(t, "abc", got[0].Get("name"))
+		assert.Nil(t, got[0].Get("ignored"))
+	})
+}
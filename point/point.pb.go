@@ -78,6 +78,7 @@ const (
 	COUNT       MetricType = 1
 	RATE        MetricType = 2
 	GAUGE       MetricType = 3
+	DELTA       MetricType = 4
 )
 
 var MetricType_name = map[int32]string{
@@ -85,6 +86,7 @@ var MetricType_name = map[int32]string{
 	1: "COUNT",
 	2: "RATE",
 	3: "GAUGE",
+	4: "DELTA",
 }
 
 var MetricType_value = map[string]int32{
@@ -92,6 +94,7 @@ var MetricType_value = map[string]int32{
 	"COUNT":       1,
 	"RATE":        2,
 	"GAUGE":       3,
+	"DELTA":       4,
 }
 
 func (MetricType) EnumDescriptor() ([]byte, []int) {
@@ -787,50 +790,50 @@ func init() {
 func init() { proto.RegisterFile("point.proto", fileDescriptor_dbb1a16d5866e018) }
 
 var fileDescriptor_dbb1a16d5866e018 = []byte{
-	// 681 bytes of a gzipped FileDescriptorProto
+	// 684 bytes of a gzipped FileDescriptorProto
 	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x53, 0x41, 0x6f, 0xd3, 0x4a,
-	0x10, 0xf6, 0x66, 0xed, 0x38, 0x99, 0x54, 0x7d, 0xfb, 0xf6, 0x55, 0x4f, 0xfb, 0xfa, 0x84, 0x65,
-	0x4c, 0x2b, 0xa2, 0xaa, 0x4a, 0x11, 0x5c, 0x10, 0x88, 0x43, 0xd2, 0x24, 0x25, 0x40, 0x4b, 0xe4,
-	0x36, 0x02, 0x71, 0x41, 0x1b, 0xb2, 0x89, 0x2c, 0x12, 0x27, 0xd8, 0x49, 0x8b, 0x6f, 0xfc, 0x04,
-	0x7e, 0x02, 0x47, 0x7e, 0x0a, 0xc7, 0x1e, 0x7b, 0xa4, 0xe9, 0x85, 0x63, 0xaf, 0xdc, 0xd0, 0xac,
-	0xdd, 0x14, 0xa2, 0x1e, 0xac, 0x99, 0x6f, 0xbf, 0x99, 0xd9, 0x99, 0x6f, 0xc7, 0x50, 0x9a, 0x8c,
-	0x83, 0x70, 0x5a, 0x99, 0x44, 0xe3, 0xe9, 0x98, 0x5b, 0x1a, 0xac, 0xff, 0x37, 0x18, 0x8f, 0x07,
-	0x43, 0xb5, 0xa3, 0x0f, 0xbb, 0xb3, 0xfe, 0x8e, 0x0c, 0x93, 0x34, 0xc2, 0xfb, 0x1f, 0xac, 0xba,
-	0xea, 0xce, 0x06, 0x9c, 0x83, 0x19, 0x84, 0xfd, 0xb1, 0x20, 0x2e, 0x29, 0x17, 0x7d, 0xed, 0x7b,
-	0xb7, 0xc0, 0xae, 0x86, 0x49, 0x5d, 0x8d, 0xc6, 0x48, 0xf7, 0xd4, 0x68, 0x41, 0xa3, 0xef, 0x7d,
-	0x00, 0xa8, 0xc9, 0x38, 0x78, 0x77, 0x94, 0x4c, 0x54, 0xcc, 0x57, 0x81, 0x04, 0x9a, 0xa6, 0x4f,
-	0x0d, 0x9f, 0x04, 0x88, 0x67, 0x22, 0xe7, 0x92, 0xb2, 0x89, 0x78, 0x86, 0xb8, 0x2f, 0xa8, 0x4b,
-	0xca, 0x04, 0x71, 0x1f, 0x71, 0x57, 0x98, 0x2e, 0x29, 0x17, 0x10, 0x77, 0x11, 0xf7, 0x84, 0xe5,
-	0x92, 0xf2, 0x0a, 0xe2, 0x1e, 0xe2, 0x58, 0xe4, 0xf1, 0x3a, 0xc4, 0x71, 0x8d, 0x02, 0xf9, 0xe8,
-	0x6d, 0x83, 0x55, 0x8d, 0x22, 0x99, 0xf0, 0x3b, 0x40, 0x65, 0x14, 0x09, 0xe2, 0xd2, 0x72, 0xe9,
-	0xfe, 0xdf, 0x95, 0x74, 0xe8, 0xeb, 0x6e, 0x7c, 0x64, 0xbd, 0x13, 0xa0, 0xfb, 0x72, 0xc2, 0x37,
-	0x81, 0x8e, 0xe4, 0x24, 0x8b, 0xfd, 0x27, 0x8b, 0xdd, 0x97, 0x13, 0xfc, 0x1a, 0xe1, 0x34, 0x4a,
-	0x7c, 0xe4, 0xd7, 0x5b, 0x50, 0xb8, 0x3a, 0xe0, 0x0c, 0xe8, 0x7b, 0x95, 0x64, 0xd3, 0xa2, 0xcb,
-	0xef, 0x82, 0x75, 0x2c, 0x87, 0x33, 0xa5, 0x47, 0xba, 0xf1, 0xca, 0x94, 0x7f, 0x94, 0x7b, 0x48,
-	0xbc, 0x9f, 0x04, 0xac, 0x66, 0xa0, 0x86, 0xbd, 0x1b, 0x0a, 0x69, 0x9d, 0x72, 0x4b, 0x3a, 0xd1,
-	0x25, 0x9d, 0xcc, 0x25, 0x9d, 0xac, 0x25, 0x9d, 0xf2, 0x4b, 0x3a, 0x95, 0x16, 0x3a, 0xf1, 0x0d,
-	0x20, 0x52, 0xd8, 0xba, 0xc9, 0xb5, 0x4a, 0xfa, 0xf0, 0x95, 0xab, 0x87, 0xaf, 0x54, 0xc3, 0x04,
-	0xa3, 0x24, 0xff, 0x17, 0xf2, 0x41, 0xfc, 0x76, 0x2a, 0x07, 0xa2, 0x80, 0xa5, 0xfd, 0x0c, 0xf1,
-	0x4d, 0x30, 0xa7, 0xc9, 0x44, 0x89, 0xa2, 0x4b, 0xca, 0xab, 0x8b, 0x29, 0xf7, 0xd5, 0x34, 0x4a,
-	0xc7, 0xf4, 0x35, 0x8d, 0xeb, 0x30, 0x0b, 0x83, 0xa9, 0x80, 0x74, 0x1d, 0xd0, 0xaf, 0x59, 0x40,
-	0x8f, 0xe5, 0xd0, 0xbb, 0x07, 0xe6, 0x2b, 0x19, 0x85, 0x18, 0xa2, 0x2b, 0x65, 0x1b, 0xa3, 0xd3,
-	0xd6, 0x80, 0x8e, 0xe2, 0x81, 0x9e, 0xbe, 0xe8, 0xdb, 0x23, 0x15, 0xc7, 0x72, 0xa0, 0xbc, 0x2f,
-	0x04, 0xec, 0x76, 0xad, 0x8d, 0x37, 0x61, 0x56, 0x28, 0x47, 0x8b, 0x2c, 0xf4, 0xf9, 0x06, 0xe4,
-	0xfb, 0x28, 0x66, 0x2c, 0x72, 0xfa, 0x09, 0x57, 0xb2, 0xae, 0xb4, 0xc2, 0x7e, 0xc6, 0xe9, 0xfb,
-	0x82, 0x91, 0xd2, 0x52, 0x52, 0x5f, 0xfb, 0xfc, 0x36, 0x58, 0x27, 0x32, 0x0a, 0x63, 0x61, 0xea,
-	0xc4, 0x52, 0x96, 0x88, 0xfd, 0xf9, 0x29, 0x83, 0xc5, 0x7b, 0xf8, 0x03, 0xc4, 0xc2, 0xfa, 0xa3,
-	0xb8, 0xfe, 0x2b, 0xfc, 0x8c, 0xf3, 0xb6, 0xa1, 0x90, 0x75, 0x18, 0x73, 0xf7, 0xf7, 0xd5, 0x5b,
-	0xcd, 0xc2, 0x33, 0x56, 0xef, 0xdd, 0xd6, 0x33, 0xb0, 0x9f, 0xab, 0x04, 0xe5, 0xe2, 0x16, 0x90,
-	0xd7, 0xcc, 0x40, 0xd3, 0x62, 0x04, 0x4d, 0x87, 0xe5, 0xd0, 0x34, 0x19, 0x45, 0x53, 0x63, 0x26,
-	0x9a, 0x3a, 0xb3, 0xb8, 0x0d, 0xf4, 0xa0, 0xf5, 0x82, 0xe5, 0x11, 0x1f, 0x32, 0x1b, 0x4d, 0x95,
-	0x15, 0xb6, 0x9e, 0x00, 0x5c, 0xab, 0xcf, 0xff, 0x82, 0x52, 0xe7, 0xe0, 0xb0, 0xdd, 0xd8, 0x6d,
-	0x35, 0x5b, 0x8d, 0x3a, 0x33, 0x78, 0x11, 0xac, 0xdd, 0x97, 0x9d, 0x83, 0x23, 0x46, 0x78, 0x01,
-	0x4c, 0xbf, 0x7a, 0xd4, 0x60, 0x39, 0x3c, 0xdc, 0xab, 0x76, 0xf6, 0x1a, 0x8c, 0xd6, 0x9a, 0xa7,
-	0xe7, 0x8e, 0x71, 0x76, 0xee, 0x18, 0x97, 0xe7, 0x0e, 0xf9, 0x34, 0x77, 0xc8, 0xd7, 0xb9, 0x43,
-	0xbe, 0xcd, 0x1d, 0x72, 0x3a, 0x77, 0xc8, 0xf7, 0xb9, 0x43, 0x7e, 0xcc, 0x1d, 0xe3, 0x72, 0xee,
-	0x90, 0xcf, 0x17, 0x8e, 0x71, 0x7a, 0xe1, 0x18, 0x67, 0x17, 0x8e, 0x51, 0xb3, 0xf4, 0x30, 0x6d,
-	0xf2, 0xc6, 0xde, 0x79, 0xac, 0xe7, 0xeb, 0xe6, 0xf5, 0x0a, 0x3d, 0xf8, 0x15, 0x00, 0x00, 0xff,
-	0xff, 0x90, 0x42, 0xde, 0x4f, 0x5f, 0x04, 0x00, 0x00,
+	0x10, 0xf6, 0x66, 0xed, 0x38, 0x99, 0x54, 0x7d, 0xfb, 0xf6, 0x55, 0x4f, 0x4b, 0x11, 0x96, 0x31,
+	0xad, 0x88, 0xaa, 0x2a, 0x45, 0x70, 0x41, 0x70, 0x4a, 0x9a, 0xa4, 0x0d, 0xb4, 0x25, 0x72, 0x13,
+	0x81, 0xb8, 0xa0, 0x0d, 0xd9, 0x44, 0x16, 0x89, 0x13, 0xec, 0xa4, 0xc5, 0x37, 0x7e, 0x02, 0x3f,
+	0x81, 0x23, 0x3f, 0x85, 0x63, 0x8f, 0x3d, 0xd2, 0xf4, 0xc2, 0xb1, 0x57, 0x6e, 0x68, 0x36, 0x6e,
+	0x0a, 0x51, 0x0f, 0xd6, 0xcc, 0xb7, 0xdf, 0xcc, 0xec, 0xcc, 0xb7, 0x63, 0x28, 0x8c, 0x47, 0x41,
+	0x38, 0x29, 0x8d, 0xa3, 0xd1, 0x64, 0xc4, 0x2d, 0x0d, 0xd6, 0xef, 0xf4, 0x47, 0xa3, 0xfe, 0x40,
+	0xed, 0xe8, 0xc3, 0xce, 0xb4, 0xb7, 0x23, 0xc3, 0x64, 0x1e, 0xe1, 0xdd, 0x05, 0xab, 0xaa, 0x3a,
+	0xd3, 0x3e, 0xe7, 0x60, 0x06, 0x61, 0x6f, 0x24, 0x88, 0x4b, 0x8a, 0x79, 0x5f, 0xfb, 0xde, 0x3d,
+	0xb0, 0xcb, 0x61, 0x52, 0x55, 0xc3, 0x11, 0xd2, 0x5d, 0x35, 0x5c, 0xd0, 0xe8, 0x7b, 0x1f, 0x01,
+	0x2a, 0x32, 0x0e, 0xde, 0xb7, 0x92, 0xb1, 0x8a, 0xf9, 0x2a, 0x90, 0x40, 0xd3, 0x74, 0xdf, 0xf0,
+	0x49, 0x80, 0x78, 0x2a, 0x32, 0x2e, 0x29, 0x9a, 0x88, 0xa7, 0x88, 0x7b, 0x82, 0xba, 0xa4, 0x48,
+	0x10, 0xf7, 0x10, 0x77, 0x84, 0xe9, 0x92, 0x62, 0x0e, 0x71, 0x07, 0x71, 0x57, 0x58, 0x2e, 0x29,
+	0xae, 0x20, 0xee, 0x22, 0x8e, 0x45, 0x16, 0xaf, 0x43, 0x1c, 0x57, 0x28, 0x90, 0x4f, 0xde, 0x36,
+	0x58, 0xe5, 0x28, 0x92, 0x09, 0x7f, 0x00, 0x54, 0x46, 0x91, 0x20, 0x2e, 0x2d, 0x16, 0x1e, 0xff,
+	0x5b, 0x9a, 0x0f, 0x7d, 0xd3, 0x8d, 0x8f, 0xac, 0x77, 0x0a, 0xf4, 0x50, 0x8e, 0xf9, 0x26, 0xd0,
+	0xa1, 0x1c, 0xa7, 0xb1, 0xff, 0xa5, 0xb1, 0x87, 0x72, 0x8c, 0x5f, 0x2d, 0x9c, 0x44, 0x89, 0x8f,
+	0xfc, 0x7a, 0x03, 0x72, 0xd7, 0x07, 0x9c, 0x01, 0xfd, 0xa0, 0x92, 0x74, 0x5a, 0x74, 0xf9, 0x43,
+	0xb0, 0x4e, 0xe4, 0x60, 0xaa, 0xf4, 0x48, 0xb7, 0x5e, 0x39, 0xe7, 0x9f, 0x65, 0x9e, 0x12, 0xef,
+	0x17, 0x01, 0xab, 0x1e, 0xa8, 0x41, 0xf7, 0x96, 0x42, 0x5a, 0xa7, 0xcc, 0x92, 0x4e, 0x74, 0x49,
+	0x27, 0x73, 0x49, 0x27, 0x6b, 0x49, 0xa7, 0xec, 0x92, 0x4e, 0x85, 0x85, 0x4e, 0x7c, 0x03, 0x88,
+	0x14, 0xb6, 0x6e, 0x72, 0xad, 0x34, 0x7f, 0xf8, 0xd2, 0xf5, 0xc3, 0x97, 0xca, 0x61, 0x82, 0x51,
+	0x92, 0xff, 0x0f, 0xd9, 0x20, 0x7e, 0x37, 0x91, 0x7d, 0x91, 0xc3, 0xd2, 0x7e, 0x8a, 0xf8, 0x26,
+	0x98, 0x93, 0x64, 0xac, 0x44, 0xde, 0x25, 0xc5, 0xd5, 0xc5, 0x94, 0x87, 0x6a, 0x12, 0xcd, 0xc7,
+	0xf4, 0x35, 0x8d, 0xeb, 0x30, 0x0d, 0x83, 0x89, 0x80, 0xf9, 0x3a, 0xa0, 0x5f, 0xb1, 0x80, 0x9e,
+	0xc8, 0x81, 0xf7, 0x08, 0xcc, 0xd7, 0x32, 0x0a, 0x31, 0x44, 0x57, 0x4a, 0x37, 0x46, 0xa7, 0xad,
+	0x01, 0x1d, 0xc6, 0x7d, 0x3d, 0x7d, 0xde, 0xb7, 0x87, 0x2a, 0x8e, 0x65, 0x5f, 0x79, 0x5f, 0x09,
+	0xd8, 0xcd, 0x4a, 0x13, 0x6f, 0xc2, 0xac, 0x50, 0x0e, 0x17, 0x59, 0xe8, 0xf3, 0x0d, 0xc8, 0xf6,
+	0x50, 0xcc, 0x58, 0x64, 0xf4, 0x13, 0xae, 0xa4, 0x5d, 0x69, 0x85, 0xfd, 0x94, 0xd3, 0xf7, 0x05,
+	0x43, 0xa5, 0xa5, 0xa4, 0xbe, 0xf6, 0xf9, 0x7d, 0xb0, 0x4e, 0x65, 0x14, 0xc6, 0xc2, 0xd4, 0x89,
+	0x85, 0x34, 0x11, 0xfb, 0xf3, 0xe7, 0x0c, 0x16, 0xef, 0xe2, 0x0f, 0x10, 0x0b, 0xeb, 0xaf, 0xe2,
+	0xfa, 0xaf, 0xf0, 0x53, 0xce, 0xdb, 0x86, 0x5c, 0xda, 0x61, 0xcc, 0xdd, 0x3f, 0x57, 0x6f, 0x35,
+	0x0d, 0x4f, 0x59, 0xbd, 0x77, 0x5b, 0x2f, 0xc0, 0x7e, 0xa9, 0x12, 0x94, 0x8b, 0x5b, 0x40, 0xde,
+	0x30, 0x03, 0x4d, 0x83, 0x11, 0x34, 0x6d, 0x96, 0x41, 0x53, 0x67, 0x14, 0x4d, 0x85, 0x99, 0x68,
+	0xaa, 0xcc, 0xe2, 0x36, 0xd0, 0xa3, 0xc6, 0x01, 0xcb, 0x22, 0x3e, 0x66, 0x36, 0x9a, 0x32, 0xcb,
+	0x6d, 0xed, 0x03, 0xdc, 0xa8, 0xcf, 0xff, 0x81, 0x42, 0xfb, 0xe8, 0xb8, 0x59, 0xdb, 0x6d, 0xd4,
+	0x1b, 0xb5, 0x2a, 0x33, 0x78, 0x1e, 0xac, 0xdd, 0x57, 0xed, 0xa3, 0x16, 0x23, 0x3c, 0x07, 0xa6,
+	0x5f, 0x6e, 0xd5, 0x58, 0x06, 0x0f, 0xf7, 0xca, 0xed, 0xbd, 0x1a, 0xa3, 0xe8, 0x56, 0x6b, 0x07,
+	0xad, 0x32, 0x33, 0x2b, 0xf5, 0xb3, 0x0b, 0xc7, 0x38, 0xbf, 0x70, 0x8c, 0xab, 0x0b, 0x87, 0x7c,
+	0x9e, 0x39, 0xe4, 0xdb, 0xcc, 0x21, 0xdf, 0x67, 0x0e, 0x39, 0x9b, 0x39, 0xe4, 0xc7, 0xcc, 0x21,
+	0x3f, 0x67, 0x8e, 0x71, 0x35, 0x73, 0xc8, 0x97, 0x4b, 0xc7, 0x38, 0xbb, 0x74, 0x8c, 0xf3, 0x4b,
+	0xc7, 0xa8, 0x58, 0x7a, 0xae, 0x26, 0x79, 0x6b, 0xef, 0x3c, 0xd7, 0xa3, 0x76, 0xb2, 0x7a, 0x9b,
+	0x9e, 0xfc, 0x1e, 0x00, 0x73, 0xc5, 0xfc, 0xec, 0x6a, 0x04, 0x00, 0x00,
 }
 
 func (x KeyType) String() string {
@@ -0,0 +1,2160 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: point_dict.proto
+
+package point
+
+import (
+	bytes "bytes"
+	encoding_binary "encoding/binary"
+	fmt "fmt"
+	proto "github.com/gogo/protobuf/proto"
+	types "github.com/gogo/protobuf/types"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+	reflect "reflect"
+	strings "strings"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
+
+type DictField struct {
+	Key uint32 `protobuf:"varint,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Types that are valid to be assigned to Val:
+	//	*DictField_I
+	//	*DictField_U
+	//	*DictField_F
+	//	*DictField_B
+	//	*DictField_D
+	//	*DictField_S
+	//	*DictField_A
+	//	*DictField_Sidx
+	Val   isDictField_Val `protobuf_oneof:"val"`
+	IsTag bool            `protobuf:"varint,8,opt,name=is_tag,proto3" json:"is_tag,omitempty"`
+	Type  MetricType      `protobuf:"varint,9,opt,name=type,proto3,enum=point.MetricType" json:"type,omitempty"`
+	Unit  uint32          `protobuf:"varint,10,opt,name=unit,proto3" json:"unit,omitempty"`
+}
+
+func (m *DictField) Reset()      { *m = DictField{} }
+func (*DictField) ProtoMessage() {}
+func (*DictField) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e0fdf62e8374c152, []int{0}
+}
+func (m *DictField) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *DictField) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_DictField.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *DictField) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DictField.Merge(m, src)
+}
+func (m *DictField) XXX_Size() int {
+	return m.Size()
+}
+func (m *DictField) XXX_DiscardUnknown() {
+	xxx_messageInfo_DictField.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DictField proto.InternalMessageInfo
+
+type isDictField_Val interface {
+	isDictField_Val()
+	Equal(interface{}) bool
+	MarshalTo([]byte) (int, error)
+	Size() int
+}
+
+type DictField_I struct {
+	I int64 `protobuf:"varint,2,opt,name=i,proto3,oneof" json:"i,omitempty"`
+}
+type DictField_U struct {
+	U uint64 `protobuf:"varint,3,opt,name=u,proto3,oneof" json:"u,omitempty"`
+}
+type DictField_F struct {
+	F float64 `protobuf:"fixed64,4,opt,name=f,proto3,oneof" json:"f,omitempty"`
+}
+type DictField_B struct {
+	B bool `protobuf:"varint,5,opt,name=b,proto3,oneof" json:"b,omitempty"`
+}
+type DictField_D struct {
+	D []byte `protobuf:"bytes,6,opt,name=d,proto3,oneof" json:"d,omitempty"`
+}
+type DictField_S struct {
+	S string `protobuf:"bytes,11,opt,name=s,proto3,oneof" json:"s,omitempty"`
+}
+type DictField_A struct {
+	A *types.Any `protobuf:"bytes,7,opt,name=a,proto3,oneof" json:"a,omitempty"`
+}
+type DictField_Sidx struct {
+	Sidx uint32 `protobuf:"varint,12,opt,name=sidx,proto3,oneof" json:"sidx,omitempty"`
+}
+
+func (*DictField_I) isDictField_Val()    {}
+func (*DictField_U) isDictField_Val()    {}
+func (*DictField_F) isDictField_Val()    {}
+func (*DictField_B) isDictField_Val()    {}
+func (*DictField_D) isDictField_Val()    {}
+func (*DictField_S) isDictField_Val()    {}
+func (*DictField_A) isDictField_Val()    {}
+func (*DictField_Sidx) isDictField_Val() {}
+
+func (m *DictField) GetVal() isDictField_Val {
+	if m != nil {
+		return m.Val
+	}
+	return nil
+}
+
+func (m *DictField) GetKey() uint32 {
+	if m != nil {
+		return m.Key
+	}
+	return 0
+}
+
+func (m *DictField) GetI() int64 {
+	if x, ok := m.GetVal().(*DictField_I); ok {
+		return x.I
+	}
+	return 0
+}
+
+func (m *DictField) GetU() uint64 {
+	if x, ok := m.GetVal().(*DictField_U); ok {
+		return x.U
+	}
+	return 0
+}
+
+func (m *DictField) GetF() float64 {
+	if x, ok := m.GetVal().(*DictField_F); ok {
+		return x.F
+	}
+	return 0
+}
+
+func (m *DictField) GetB() bool {
+	if x, ok := m.GetVal().(*DictField_B); ok {
+		return x.B
+	}
+	return false
+}
+
+func (m *DictField) GetD() []byte {
+	if x, ok := m.GetVal().(*DictField_D); ok {
+		return x.D
+	}
+	return nil
+}
+
+func (m *DictField) GetS() string {
+	if x, ok := m.GetVal().(*DictField_S); ok {
+		return x.S
+	}
+	return ""
+}
+
+func (m *DictField) GetA() *types.Any {
+	if x, ok := m.GetVal().(*DictField_A); ok {
+		return x.A
+	}
+	return nil
+}
+
+func (m *DictField) GetSidx() uint32 {
+	if x, ok := m.GetVal().(*DictField_Sidx); ok {
+		return x.Sidx
+	}
+	return 0
+}
+
+func (m *DictField) GetIsTag() bool {
+	if m != nil {
+		return m.IsTag
+	}
+	return false
+}
+
+func (m *DictField) GetType() MetricType {
+	if m != nil {
+		return m.Type
+	}
+	return UNSPECIFIED
+}
+
+func (m *DictField) GetUnit() uint32 {
+	if m != nil {
+		return m.Unit
+	}
+	return 0
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*DictField) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*DictField_I)(nil),
+		(*DictField_U)(nil),
+		(*DictField_F)(nil),
+		(*DictField_B)(nil),
+		(*DictField_D)(nil),
+		(*DictField_S)(nil),
+		(*DictField_A)(nil),
+		(*DictField_Sidx)(nil),
+	}
+}
+
+type DictPoint struct {
+	Name   uint32       `protobuf:"varint,1,opt,name=name,proto3" json:"name,omitempty"`
+	Fields []*DictField `protobuf:"bytes,2,rep,name=fields,proto3" json:"fields,omitempty"`
+	Time   int64        `protobuf:"varint,3,opt,name=time,proto3" json:"time,omitempty"`
+	Warns  []*Warn      `protobuf:"bytes,4,rep,name=warns,proto3" json:"warns,omitempty"`
+	Debugs []*Debug     `protobuf:"bytes,5,rep,name=debugs,proto3" json:"debugs,omitempty"`
+}
+
+func (m *DictPoint) Reset()      { *m = DictPoint{} }
+func (*DictPoint) ProtoMessage() {}
+func (*DictPoint) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e0fdf62e8374c152, []int{1}
+}
+func (m *DictPoint) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *DictPoint) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_DictPoint.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *DictPoint) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DictPoint.Merge(m, src)
+}
+func (m *DictPoint) XXX_Size() int {
+	return m.Size()
+}
+func (m *DictPoint) XXX_DiscardUnknown() {
+	xxx_messageInfo_DictPoint.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DictPoint proto.InternalMessageInfo
+
+func (m *DictPoint) GetName() uint32 {
+	if m != nil {
+		return m.Name
+	}
+	return 0
+}
+
+func (m *DictPoint) GetFields() []*DictField {
+	if m != nil {
+		return m.Fields
+	}
+	return nil
+}
+
+func (m *DictPoint) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+func (m *DictPoint) GetWarns() []*Warn {
+	if m != nil {
+		return m.Warns
+	}
+	return nil
+}
+
+func (m *DictPoint) GetDebugs() []*Debug {
+	if m != nil {
+		return m.Debugs
+	}
+	return nil
+}
+
+// batch of DictPoint.
+type DictPoints struct {
+	Strs []string     `protobuf:"bytes,1,rep,name=strs,proto3" json:"strs,omitempty"`
+	Arr  []*DictPoint `protobuf:"bytes,2,rep,name=arr,proto3" json:"arr,omitempty"`
+}
+
+func (m *DictPoints) Reset()      { *m = DictPoints{} }
+func (*DictPoints) ProtoMessage() {}
+func (*DictPoints) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e0fdf62e8374c152, []int{2}
+}
+func (m *DictPoints) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *DictPoints) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_DictPoints.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *DictPoints) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DictPoints.Merge(m, src)
+}
+func (m *DictPoints) XXX_Size() int {
+	return m.Size()
+}
+func (m *DictPoints) XXX_DiscardUnknown() {
+	xxx_messageInfo_DictPoints.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DictPoints proto.InternalMessageInfo
+
+func (m *DictPoints) GetStrs() []string {
+	if m != nil {
+		return m.Strs
+	}
+	return nil
+}
+
+func (m *DictPoints) GetArr() []*DictPoint {
+	if m != nil {
+		return m.Arr
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*DictField)(nil), "point.DictField")
+	proto.RegisterType((*DictPoint)(nil), "point.DictPoint")
+	proto.RegisterType((*DictPoints)(nil), "point.DictPoints")
+}
+
+func init() { proto.RegisterFile("point_dict.proto", fileDescriptor_e0fdf62e8374c152) }
+
+var fileDescriptor_e0fdf62e8374c152 = []byte{
+	// 456 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x91, 0x41, 0x8b, 0xd3, 0x40,
+	0x14, 0xc7, 0xf3, 0x9a, 0x34, 0xbb, 0x9d, 0xd4, 0xa5, 0x0e, 0x8b, 0x8c, 0x7b, 0x18, 0xc6, 0xb2,
+	0xc2, 0x9c, 0x52, 0xa8, 0x47, 0x4f, 0x96, 0x45, 0x16, 0x41, 0x58, 0x06, 0x41, 0xf0, 0xb2, 0x24,
+	0x4d, 0x5a, 0x06, 0xbb, 0x49, 0xc9, 0x4c, 0xd4, 0xdc, 0xfc, 0x08, 0x82, 0xdf, 0x41, 0xfc, 0x28,
+	0x1e, 0x7b, 0xdc, 0xa3, 0x4d, 0x2f, 0x1e, 0xf7, 0x23, 0xc8, 0x9b, 0x64, 0xd7, 0x8b, 0xb7, 0xf7,
+	0x7b, 0xef, 0xfd, 0xf3, 0xfe, 0xf3, 0x0f, 0x99, 0x6c, 0x4b, 0x5d, 0xd8, 0xeb, 0x4c, 0x2f, 0x6d,
+	0xbc, 0xad, 0x4a, 0x5b, 0xd2, 0xa1, 0xeb, 0x9c, 0x3d, 0x5d, 0x97, 0xe5, 0x7a, 0x93, 0xcf, 0x5c,
+	0x33, 0xad, 0x57, 0xb3, 0xa4, 0x68, 0xba, 0x8d, 0xb3, 0xc8, 0x6d, 0x74, 0x30, 0xfd, 0x3e, 0x20,
+	0xa3, 0x0b, 0xbd, 0xb4, 0xaf, 0x75, 0xbe, 0xc9, 0xe8, 0x84, 0xf8, 0x1f, 0xf3, 0x86, 0x81, 0x00,
+	0xf9, 0x48, 0x61, 0x49, 0x4f, 0x08, 0x68, 0x36, 0x10, 0x20, 0xfd, 0x4b, 0x4f, 0x81, 0x46, 0xae,
+	0x99, 0x2f, 0x40, 0x06, 0xc8, 0x35, 0xf2, 0x8a, 0x05, 0x02, 0x24, 0x20, 0xaf, 0x90, 0x53, 0x36,
+	0x14, 0x20, 0x8f, 0x91, 0x53, 0xe4, 0x8c, 0x85, 0x02, 0xe4, 0x18, 0x39, 0x43, 0x36, 0x2c, 0x12,
+	0x20, 0x47, 0xc8, 0x86, 0x9e, 0x13, 0x48, 0xd8, 0x91, 0x00, 0x19, 0xcd, 0x4f, 0xe3, 0xce, 0x73,
+	0x7c, 0xef, 0x39, 0x7e, 0x55, 0x34, 0xb8, 0x95, 0xd0, 0x53, 0x12, 0x18, 0x9d, 0x7d, 0x61, 0x63,
+	0x34, 0x76, 0xe9, 0x29, 0x47, 0xf4, 0x09, 0x09, 0xb5, 0xb9, 0xb6, 0xc9, 0x9a, 0x1d, 0xe3, 0x41,
+	0xd5, 0x13, 0x7d, 0x4e, 0x02, 0xdb, 0x6c, 0x73, 0x36, 0x12, 0x20, 0x4f, 0xe6, 0x8f, 0xe3, 0xee,
+	0xbd, 0x6f, 0x73, 0x5b, 0xe9, 0xe5, 0xbb, 0x66, 0x9b, 0x2b, 0x37, 0xa6, 0x94, 0x04, 0x75, 0xa1,
+	0x2d, 0x23, 0xee, 0xb5, 0xae, 0x5e, 0x0c, 0x89, 0xff, 0x29, 0xd9, 0x4c, 0x7f, 0x40, 0x97, 0xca,
+	0x15, 0x2a, 0x71, 0xb1, 0x48, 0x6e, 0xf2, 0x3e, 0x16, 0x57, 0x53, 0x49, 0xc2, 0x15, 0x46, 0x66,
+	0xd8, 0x40, 0xf8, 0x32, 0x9a, 0x4f, 0xfa, 0x2b, 0x0f, 0x59, 0xaa, 0x7e, 0x8e, 0x6a, 0xab, 0x6f,
+	0x72, 0x17, 0x9a, 0xaf, 0x5c, 0x4d, 0x9f, 0x91, 0xe1, 0xe7, 0xa4, 0x2a, 0x0c, 0x0b, 0x9c, 0x38,
+	0xea, 0xc5, 0xef, 0x93, 0xaa, 0x50, 0xdd, 0x84, 0x9e, 0x93, 0x30, 0xcb, 0xd3, 0x7a, 0x6d, 0xd8,
+	0xd0, 0xed, 0x8c, 0xef, 0x0f, 0x60, 0x53, 0xf5, 0xb3, 0xe9, 0x05, 0x21, 0x0f, 0x3e, 0xdd, 0x29,
+	0x63, 0x2b, 0xc3, 0x40, 0xf8, 0x72, 0xa4, 0x5c, 0x4d, 0xa7, 0xc4, 0x4f, 0xaa, 0xea, 0x3f, 0x2e,
+	0x9d, 0x46, 0xe1, 0x70, 0xf1, 0x66, 0xb7, 0xe7, 0xde, 0xed, 0x9e, 0x7b, 0x77, 0x7b, 0x0e, 0x5f,
+	0x5b, 0x0e, 0x3f, 0x5b, 0x0e, 0xbf, 0x5a, 0x0e, 0xbb, 0x96, 0xc3, 0xef, 0x96, 0xc3, 0x9f, 0x96,
+	0x7b, 0x77, 0x2d, 0x87, 0x6f, 0x07, 0xee, 0xed, 0x0e, 0xdc, 0xbb, 0x3d, 0x70, 0x6f, 0xf1, 0x2f,
+	0xa1, 0x2b, 0xf8, 0x70, 0x34, 0x7b, 0xe9, 0x3e, 0x9d, 0x86, 0xee, 0xef, 0xbd, 0xf8, 0x3b, 0x00,
+	0xa3, 0x5f, 0x7c, 0xa8, 0x9a, 0x02, 0x00, 0x00,
+}
+
+func (this *DictField) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*DictField)
+	if !ok {
+		that2, ok := that.(DictField)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Key != that1.Key {
+		return false
+	}
+	if that1.Val == nil {
+		if this.Val != nil {
+			return false
+		}
+	} else if this.Val == nil {
+		return false
+	} else if !this.Val.Equal(that1.Val) {
+		return false
+	}
+	if this.IsTag != that1.IsTag {
+		return false
+	}
+	if this.Type != that1.Type {
+		return false
+	}
+	if this.Unit != that1.Unit {
+		return false
+	}
+	return true
+}
+func (this *DictField_I) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*DictField_I)
+	if !ok {
+		that2, ok := that.(DictField_I)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.I != that1.I {
+		return false
+	}
+	return true
+}
+func (this *DictField_U) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*DictField_U)
+	if !ok {
+		that2, ok := that.(DictField_U)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.U != that1.U {
+		return false
+	}
+	return true
+}
+func (this *DictField_F) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*DictField_F)
+	if !ok {
+		that2, ok := that.(DictField_F)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.F != that1.F {
+		return false
+	}
+	return true
+}
+func (this *DictField_B) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*DictField_B)
+	if !ok {
+		that2, ok := that.(DictField_B)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.B != that1.B {
+		return false
+	}
+	return true
+}
+func (this *DictField_D) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*DictField_D)
+	if !ok {
+		that2, ok := that.(DictField_D)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !bytes.Equal(this.D, that1.D) {
+		return false
+	}
+	return true
+}
+func (this *DictField_S) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*DictField_S)
+	if !ok {
+		that2, ok := that.(DictField_S)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.S != that1.S {
+		return false
+	}
+	return true
+}
+func (this *DictField_A) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*DictField_A)
+	if !ok {
+		that2, ok := that.(DictField_A)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !this.A.Equal(that1.A) {
+		return false
+	}
+	return true
+}
+func (this *DictField_Sidx) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*DictField_Sidx)
+	if !ok {
+		that2, ok := that.(DictField_Sidx)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Sidx != that1.Sidx {
+		return false
+	}
+	return true
+}
+func (this *DictPoint) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*DictPoint)
+	if !ok {
+		that2, ok := that.(DictPoint)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Name != that1.Name {
+		return false
+	}
+	if len(this.Fields) != len(that1.Fields) {
+		return false
+	}
+	for i := range this.Fields {
+		if !this.Fields[i].Equal(that1.Fields[i]) {
+			return false
+		}
+	}
+	if this.Time != that1.Time {
+		return false
+	}
+	if len(this.Warns) != len(that1.Warns) {
+		return false
+	}
+	for i := range this.Warns {
+		if !this.Warns[i].Equal(that1.Warns[i]) {
+			return false
+		}
+	}
+	if len(this.Debugs) != len(that1.Debugs) {
+		return false
+	}
+	for i := range this.Debugs {
+		if !this.Debugs[i].Equal(that1.Debugs[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *DictPoints) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*DictPoints)
+	if !ok {
+		that2, ok := that.(DictPoints)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Strs) != len(that1.Strs) {
+		return false
+	}
+	for i := range this.Strs {
+		if this.Strs[i] != that1.Strs[i] {
+			return false
+		}
+	}
+	if len(this.Arr) != len(that1.Arr) {
+		return false
+	}
+	for i := range this.Arr {
+		if !this.Arr[i].Equal(that1.Arr[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *DictField) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 16)
+	s = append(s, "&point.DictField{")
+	s = append(s, "Key: "+fmt.Sprintf("%#v", this.Key)+",\n")
+	if this.Val != nil {
+		s = append(s, "Val: "+fmt.Sprintf("%#v", this.Val)+",\n")
+	}
+	s = append(s, "IsTag: "+fmt.Sprintf("%#v", this.IsTag)+",\n")
+	s = append(s, "Type: "+fmt.Sprintf("%#v", this.Type)+",\n")
+	s = append(s, "Unit: "+fmt.Sprintf("%#v", this.Unit)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *DictField_I) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&point.DictField_I{` +
+		`I:` + fmt.Sprintf("%#v", this.I) + `}`}, ", ")
+	return s
+}
+func (this *DictField_U) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&point.DictField_U{` +
+		`U:` + fmt.Sprintf("%#v", this.U) + `}`}, ", ")
+	return s
+}
+func (this *DictField_F) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&point.DictField_F{` +
+		`F:` + fmt.Sprintf("%#v", this.F) + `}`}, ", ")
+	return s
+}
+func (this *DictField_B) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&point.DictField_B{` +
+		`B:` + fmt.Sprintf("%#v", this.B) + `}`}, ", ")
+	return s
+}
+func (this *DictField_D) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&point.DictField_D{` +
+		`D:` + fmt.Sprintf("%#v", this.D) + `}`}, ", ")
+	return s
+}
+func (this *DictField_S) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&point.DictField_S{` +
+		`S:` + fmt.Sprintf("%#v", this.S) + `}`}, ", ")
+	return s
+}
+func (this *DictField_A) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&point.DictField_A{` +
+		`A:` + fmt.Sprintf("%#v", this.A) + `}`}, ", ")
+	return s
+}
+func (this *DictField_Sidx) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&point.DictField_Sidx{` +
+		`Sidx:` + fmt.Sprintf("%#v", this.Sidx) + `}`}, ", ")
+	return s
+}
+func (this *DictPoint) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 9)
+	s = append(s, "&point.DictPoint{")
+	s = append(s, "Name: "+fmt.Sprintf("%#v", this.Name)+",\n")
+	if this.Fields != nil {
+		s = append(s, "Fields: "+fmt.Sprintf("%#v", this.Fields)+",\n")
+	}
+	s = append(s, "Time: "+fmt.Sprintf("%#v", this.Time)+",\n")
+	if this.Warns != nil {
+		s = append(s, "Warns: "+fmt.Sprintf("%#v", this.Warns)+",\n")
+	}
+	if this.Debugs != nil {
+		s = append(s, "Debugs: "+fmt.Sprintf("%#v", this.Debugs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *DictPoints) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&point.DictPoints{")
+	s = append(s, "Strs: "+fmt.Sprintf("%#v", this.Strs)+",\n")
+	if this.Arr != nil {
+		s = append(s, "Arr: "+fmt.Sprintf("%#v", this.Arr)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func valueToGoStringPointDict(v interface{}, typ string) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("func(v %v) *%v { return &v } ( %#v )", typ, typ, pv)
+}
+func (m *DictField) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *DictField) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *DictField) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Val != nil {
+		{
+			size := m.Val.Size()
+			i -= size
+			if _, err := m.Val.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if m.Unit != 0 {
+		i = encodeVarintPointDict(dAtA, i, uint64(m.Unit))
+		i--
+		dAtA[i] = 0x50
+	}
+	if m.Type != 0 {
+		i = encodeVarintPointDict(dAtA, i, uint64(m.Type))
+		i--
+		dAtA[i] = 0x48
+	}
+	if m.IsTag {
+		i--
+		if m.IsTag {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x40
+	}
+	if m.Key != 0 {
+		i = encodeVarintPointDict(dAtA, i, uint64(m.Key))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *DictField_I) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *DictField_I) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	i = encodeVarintPointDict(dAtA, i, uint64(m.I))
+	i--
+	dAtA[i] = 0x10
+	return len(dAtA) - i, nil
+}
+func (m *DictField_U) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *DictField_U) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	i = encodeVarintPointDict(dAtA, i, uint64(m.U))
+	i--
+	dAtA[i] = 0x18
+	return len(dAtA) - i, nil
+}
+func (m *DictField_F) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *DictField_F) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	i -= 8
+	encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.F))))
+	i--
+	dAtA[i] = 0x21
+	return len(dAtA) - i, nil
+}
+func (m *DictField_B) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *DictField_B) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	i--
+	if m.B {
+		dAtA[i] = 1
+	} else {
+		dAtA[i] = 0
+	}
+	i--
+	dAtA[i] = 0x28
+	return len(dAtA) - i, nil
+}
+func (m *DictField_D) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *DictField_D) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.D != nil {
+		i -= len(m.D)
+		copy(dAtA[i:], m.D)
+		i = encodeVarintPointDict(dAtA, i, uint64(len(m.D)))
+		i--
+		dAtA[i] = 0x32
+	}
+	return len(dAtA) - i, nil
+}
+func (m *DictField_A) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *DictField_A) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.A != nil {
+		{
+			size, err := m.A.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintPointDict(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x3a
+	}
+	return len(dAtA) - i, nil
+}
+func (m *DictField_S) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *DictField_S) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	i -= len(m.S)
+	copy(dAtA[i:], m.S)
+	i = encodeVarintPointDict(dAtA, i, uint64(len(m.S)))
+	i--
+	dAtA[i] = 0x5a
+	return len(dAtA) - i, nil
+}
+func (m *DictField_Sidx) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *DictField_Sidx) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	i = encodeVarintPointDict(dAtA, i, uint64(m.Sidx))
+	i--
+	dAtA[i] = 0x60
+	return len(dAtA) - i, nil
+}
+func (m *DictPoint) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *DictPoint) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *DictPoint) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Debugs) > 0 {
+		for iNdEx := len(m.Debugs) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Debugs[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintPointDict(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x2a
+		}
+	}
+	if len(m.Warns) > 0 {
+		for iNdEx := len(m.Warns) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Warns[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintPointDict(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x22
+		}
+	}
+	if m.Time != 0 {
+		i = encodeVarintPointDict(dAtA, i, uint64(m.Time))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Fields) > 0 {
+		for iNdEx := len(m.Fields) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Fields[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintPointDict(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.Name != 0 {
+		i = encodeVarintPointDict(dAtA, i, uint64(m.Name))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *DictPoints) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *DictPoints) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *DictPoints) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Arr) > 0 {
+		for iNdEx := len(m.Arr) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Arr[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintPointDict(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Strs) > 0 {
+		for iNdEx := len(m.Strs) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Strs[iNdEx])
+			copy(dAtA[i:], m.Strs[iNdEx])
+			i = encodeVarintPointDict(dAtA, i, uint64(len(m.Strs[iNdEx])))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintPointDict(dAtA []byte, offset int, v uint64) int {
+	offset -= sovPointDict(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *DictField) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Key != 0 {
+		n += 1 + sovPointDict(uint64(m.Key))
+	}
+	if m.Val != nil {
+		n += m.Val.Size()
+	}
+	if m.IsTag {
+		n += 2
+	}
+	if m.Type != 0 {
+		n += 1 + sovPointDict(uint64(m.Type))
+	}
+	if m.Unit != 0 {
+		n += 1 + sovPointDict(uint64(m.Unit))
+	}
+	return n
+}
+
+func (m *DictField_I) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	n += 1 + sovPointDict(uint64(m.I))
+	return n
+}
+func (m *DictField_U) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	n += 1 + sovPointDict(uint64(m.U))
+	return n
+}
+func (m *DictField_F) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	n += 9
+	return n
+}
+func (m *DictField_B) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	n += 2
+	return n
+}
+func (m *DictField_D) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.D != nil {
+		l = len(m.D)
+		n += 1 + l + sovPointDict(uint64(l))
+	}
+	return n
+}
+func (m *DictField_A) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.A != nil {
+		l = m.A.Size()
+		n += 1 + l + sovPointDict(uint64(l))
+	}
+	return n
+}
+func (m *DictField_S) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.S)
+	n += 1 + l + sovPointDict(uint64(l))
+	return n
+}
+func (m *DictField_Sidx) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	n += 1 + sovPointDict(uint64(m.Sidx))
+	return n
+}
+func (m *DictPoint) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Name != 0 {
+		n += 1 + sovPointDict(uint64(m.Name))
+	}
+	if len(m.Fields) > 0 {
+		for _, e := range m.Fields {
+			l = e.Size()
+			n += 1 + l + sovPointDict(uint64(l))
+		}
+	}
+	if m.Time != 0 {
+		n += 1 + sovPointDict(uint64(m.Time))
+	}
+	if len(m.Warns) > 0 {
+		for _, e := range m.Warns {
+			l = e.Size()
+			n += 1 + l + sovPointDict(uint64(l))
+		}
+	}
+	if len(m.Debugs) > 0 {
+		for _, e := range m.Debugs {
+			l = e.Size()
+			n += 1 + l + sovPointDict(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *DictPoints) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Strs) > 0 {
+		for _, s := range m.Strs {
+			l = len(s)
+			n += 1 + l + sovPointDict(uint64(l))
+		}
+	}
+	if len(m.Arr) > 0 {
+		for _, e := range m.Arr {
+			l = e.Size()
+			n += 1 + l + sovPointDict(uint64(l))
+		}
+	}
+	return n
+}
+
+func sovPointDict(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozPointDict(x uint64) (n int) {
+	return sovPointDict(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (this *DictField) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&DictField{`,
+		`Key:` + fmt.Sprintf("%v", this.Key) + `,`,
+		`Val:` + fmt.Sprintf("%v", this.Val) + `,`,
+		`IsTag:` + fmt.Sprintf("%v", this.IsTag) + `,`,
+		`Type:` + fmt.Sprintf("%v", this.Type) + `,`,
+		`Unit:` + fmt.Sprintf("%v", this.Unit) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *DictField_I) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&DictField_I{`,
+		`I:` + fmt.Sprintf("%v", this.I) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *DictField_U) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&DictField_U{`,
+		`U:` + fmt.Sprintf("%v", this.U) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *DictField_F) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&DictField_F{`,
+		`F:` + fmt.Sprintf("%v", this.F) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *DictField_B) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&DictField_B{`,
+		`B:` + fmt.Sprintf("%v", this.B) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *DictField_D) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&DictField_D{`,
+		`D:` + fmt.Sprintf("%v", this.D) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *DictField_A) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&DictField_A{`,
+		`A:` + strings.Replace(fmt.Sprintf("%v", this.A), "Any", "types.Any", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *DictField_S) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&DictField_S{`,
+		`S:` + fmt.Sprintf("%v", this.S) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *DictField_Sidx) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&DictField_Sidx{`,
+		`Sidx:` + fmt.Sprintf("%v", this.Sidx) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *DictPoint) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForFields := "[]*DictField{"
+	for _, f := range this.Fields {
+		repeatedStringForFields += strings.Replace(f.String(), "DictField", "DictField", 1) + ","
+	}
+	repeatedStringForFields += "}"
+	repeatedStringForWarns := "[]*Warn{"
+	for _, f := range this.Warns {
+		repeatedStringForWarns += strings.Replace(fmt.Sprintf("%v", f), "Warn", "Warn", 1) + ","
+	}
+	repeatedStringForWarns += "}"
+	repeatedStringForDebugs := "[]*Debug{"
+	for _, f := range this.Debugs {
+		repeatedStringForDebugs += strings.Replace(fmt.Sprintf("%v", f), "Debug", "Debug", 1) + ","
+	}
+	repeatedStringForDebugs += "}"
+	s := strings.Join([]string{`&DictPoint{`,
+		`Name:` + fmt.Sprintf("%v", this.Name) + `,`,
+		`Fields:` + repeatedStringForFields + `,`,
+		`Time:` + fmt.Sprintf("%v", this.Time) + `,`,
+		`Warns:` + repeatedStringForWarns + `,`,
+		`Debugs:` + repeatedStringForDebugs + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *DictPoints) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForArr := "[]*DictPoint{"
+	for _, f := range this.Arr {
+		repeatedStringForArr += strings.Replace(f.String(), "DictPoint", "DictPoint", 1) + ","
+	}
+	repeatedStringForArr += "}"
+	s := strings.Join([]string{`&DictPoints{`,
+		`Strs:` + fmt.Sprintf("%v", this.Strs) + `,`,
+		`Arr:` + repeatedStringForArr + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func valueToStringPointDict(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("*%v", pv)
+}
+func (m *DictField) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPointDict
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: DictField: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: DictField: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			m.Key = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPointDict
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Key |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field I", wireType)
+			}
+			var v int64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPointDict
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Val = &DictField_I{v}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field U", wireType)
+			}
+			var v uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPointDict
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Val = &DictField_U{v}
+		case 4:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field F", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.Val = &DictField_F{float64(math.Float64frombits(v))}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field B", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPointDict
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			b := bool(v != 0)
+			m.Val = &DictField_B{b}
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field D", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPointDict
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthPointDict
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPointDict
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := make([]byte, postIndex-iNdEx)
+			copy(v, dAtA[iNdEx:postIndex])
+			m.Val = &DictField_D{v}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field A", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPointDict
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthPointDict
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthPointDict
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &types.Any{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Val = &DictField_A{v}
+			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IsTag", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPointDict
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.IsTag = bool(v != 0)
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			m.Type = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPointDict
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Type |= MetricType(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Unit", wireType)
+			}
+			m.Unit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPointDict
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Unit |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field S", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPointDict
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPointDict
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPointDict
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Val = &DictField_S{string(dAtA[iNdEx:postIndex])}
+			iNdEx = postIndex
+		case 12:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Sidx", wireType)
+			}
+			var v uint32
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPointDict
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Val = &DictField_Sidx{v}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPointDict(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthPointDict
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *DictPoint) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPointDict
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: DictPoint: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: DictPoint: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			m.Name = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPointDict
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Name |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Fields", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPointDict
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthPointDict
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthPointDict
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Fields = append(m.Fields, &DictField{})
+			if err := m.Fields[len(m.Fields)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Time", wireType)
+			}
+			m.Time = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPointDict
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Time |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Warns", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPointDict
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthPointDict
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthPointDict
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Warns = append(m.Warns, &Warn{})
+			if err := m.Warns[len(m.Warns)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Debugs", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPointDict
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthPointDict
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthPointDict
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Debugs = append(m.Debugs, &Debug{})
+			if err := m.Debugs[len(m.Debugs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPointDict(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthPointDict
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *DictPoints) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPointDict
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: DictPoints: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: DictPoints: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Strs", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPointDict
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPointDict
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPointDict
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Strs = append(m.Strs, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Arr", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPointDict
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthPointDict
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthPointDict
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Arr = append(m.Arr, &DictPoint{})
+			if err := m.Arr[len(m.Arr)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPointDict(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthPointDict
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipPointDict(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	depth := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowPointDict
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowPointDict
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+		case 1:
+			iNdEx += 8
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowPointDict
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthPointDict
+			}
+			iNdEx += length
+		case 3:
+			depth++
+		case 4:
+			if depth == 0 {
+				return 0, ErrUnexpectedEndOfGroupPointDict
+			}
+			depth--
+		case 5:
+			iNdEx += 4
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+		if iNdEx < 0 {
+			return 0, ErrInvalidLengthPointDict
+		}
+		if depth == 0 {
+			return iNdEx, nil
+		}
+	}
+	return 0, io.ErrUnexpectedEOF
+}
+
+var (
+	ErrInvalidLengthPointDict        = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowPointDict          = fmt.Errorf("proto: integer overflow")
+	ErrUnexpectedEndOfGroupPointDict = fmt.Errorf("proto: unexpected end of group")
+)
@@ -0,0 +1,294 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package point
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+const (
+	labelMetricName = "__name__"
+
+	// label of the raw measurement name, so the measurement(which may contain
+	// '_') can be split from __name__ on decoding.
+	labelMeasurement = "__measurement__"
+
+	// max decoded bytes of a remote-write payload, same as the decode limit
+	// of Prometheus.
+	maxRemoteWriteBytes = 32 * 1024 * 1024
+)
+
+// promName convert s to valid prometheus metric/label name, invalid
+// characters are replaced with '_'.
+func promName(s string) string {
+	valid := func(i int, c rune) bool {
+		return c == '_' || c == ':' ||
+			(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			(i > 0 && c >= '0' && c <= '9')
+	}
+
+	i := -1
+	return strings.Map(func(c rune) rune {
+		i++
+		if valid(i, c) {
+			return c
+		}
+		return '_'
+	}, s)
+}
+
+// promValue get sample value of numeric field.
+func promValue(f *Field) (float64, bool) {
+	switch x := f.Val.(type) {
+	case *Field_I:
+		return float64(x.I), true
+	case *Field_U:
+		return float64(x.U), true
+	case *Field_F:
+		return x.F, true
+	case *Field_B:
+		if x.B {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// promTimeSeries convert pt into prometheus time series. Each numeric field is
+// a single series named <measurement>_<field-key>, the measurement kept in label
+// __measurement__ and tags are labels. Non-numeric fields are ignored.
+//
+// Tags that conflict on label name(after invalid characters replaced) with
+// other tags or reserved labels fail the point.
+func promTimeSeries(pt *Point) (arr []prompb.TimeSeries, err error) {
+	labels := []prompb.Label{{Name: labelMeasurement, Value: pt.Name()}}
+	names := map[string]string{labelMetricName: labelMetricName, labelMeasurement: labelMeasurement}
+
+	for _, kv := range pt.Tags() {
+		name := promName(kv.Key)
+		if x, ok := names[name]; ok {
+			return nil, fmt.Errorf("tag %q conflict with %q on label name %q", kv.Key, x, name)
+		}
+
+		names[name] = kv.Key
+		labels = append(labels, prompb.Label{Name: name, Value: kv.GetS()})
+	}
+
+	ts := pt.Time().UnixNano() / int64(time.Millisecond)
+
+	for _, f := range pt.Fields() {
+		v, ok := promValue(f)
+		if !ok {
+			continue
+		}
+
+		series := prompb.TimeSeries{
+			Labels:  make([]prompb.Label, 0, len(labels)+1),
+			Samples: []prompb.Sample{{Value: v, Timestamp: ts}},
+		}
+
+		series.Labels = append(series.Labels, prompb.Label{
+			Name:  labelMetricName,
+			Value: promName(pt.Name() + "_" + f.Key),
+		})
+		series.Labels = append(series.Labels, labels...)
+
+		// remote-write requires labels sorted by name
+		sort.Slice(series.Labels, func(i, j int) bool {
+			return series.Labels[i].Name < series.Labels[j].Name
+		})
+
+		arr = append(arr, series)
+	}
+
+	return arr, nil
+}
+
+// remoteWriteSeriesSize get bytes of series within the WriteRequest.
+func remoteWriteSeriesSize(series []prompb.TimeSeries) (n int) {
+	for i := range series {
+		l := series[i].Size()
+		n += 1 + l + (bits.Len64(uint64(l)|1)+6)/7
+	}
+	return n
+}
+
+func marshalRemoteWrite(series []prompb.TimeSeries) ([]byte, error) {
+	req := &prompb.WriteRequest{Timeseries: series}
+	data, err := req.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	return snappy.Encode(nil, data), nil
+}
+
+// doEncodeRemoteWrite encode pts into snappy-compressed remote-write payloads.
+// If batch bytes set, the uncompressed WriteRequest of each payload do not
+// exceed batch bytes(unless a single point exceeded), or split on batch size.
+func (e *Encoder) doEncodeRemoteWrite(pts []*Point) ([][]byte, error) {
+	var (
+		batches [][]byte
+		series  []prompb.TimeSeries
+		npts    int
+		size    int
+	)
+
+	flush := func() error {
+		if npts == 0 {
+			return nil
+		}
+
+		payload, err := marshalRemoteWrite(series)
+		if err != nil {
+			return err
+		}
+
+		if e.fn != nil {
+			if err := e.fn(npts, payload); err != nil {
+				return err
+			}
+		}
+
+		batches = append(batches, payload)
+		series, npts, size = nil, 0, 0
+		return nil
+	}
+
+	for _, pt := range pts {
+		if pt == nil {
+			continue
+		}
+
+		arr, err := promTimeSeries(pt)
+		if err != nil {
+			return nil, err
+		}
+
+		n := remoteWriteSeriesSize(arr)
+
+		if (e.bytesSize > 0 && size+n > e.bytesSize) ||
+			(e.bytesSize <= 0 && e.batchSize > 0 && npts >= e.batchSize) {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+
+		series = append(series, arr...)
+		size += n
+		npts++
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return batches, nil
+}
+
+// decodeRemoteWrite decode snappy-compressed remote-write payload into points.
+//
+// The measurement got from label __measurement__ and the field key is the
+// rest of __name__. Without __measurement__(i.e., sent by Prometheus), __name__
+// split into measurement and field key on the first '_'(same as GatherPoints).
+// Other labels are tags, duplicated label names fail the decoding. Samples on
+// the same measurement, tags and time are merged into a single point.
+func decodeRemoteWrite(data []byte) ([]*Point, error) {
+	// check the decoded length before snappy allocate for it
+	n, err := snappy.DecodedLen(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if n > maxRemoteWriteBytes {
+		return nil, fmt.Errorf("remote-write payload too large: %d bytes decoded, exceed %d", n, maxRemoteWriteBytes)
+	}
+
+	raw, err := snappy.Decode(nil, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var req prompb.WriteRequest
+	if err := req.Unmarshal(raw); err != nil {
+		return nil, err
+	}
+
+	var (
+		pts []*Point
+		idx = map[string]*Point{}
+	)
+
+	for i := range req.Timeseries {
+		series := &req.Timeseries[i]
+
+		var (
+			name, measurement string
+			tags              KVs
+			id                strings.Builder
+		)
+
+		for _, l := range series.Labels {
+			switch l.Name {
+			case labelMetricName:
+				name = l.Value
+				continue
+			case labelMeasurement:
+				measurement = l.Value
+				continue
+			}
+
+			if tags.Get(l.Name) != nil {
+				return nil, fmt.Errorf("duplicated label %q of series %q", l.Name, name)
+			}
+
+			tags = append(tags, NewKV(l.Name, l.Value, WithKVTagSet(true)))
+			id.WriteString(l.Name + "=" + l.Value + "\n")
+		}
+
+		key := name
+		if measurement != "" {
+			key = strings.TrimPrefix(name, promName(measurement)+"_")
+		} else {
+			measurement = name
+			if arr := strings.SplitN(name, "_", 2); len(arr) == 2 {
+				measurement, key = arr[0], arr[1]
+			}
+		}
+
+		for _, s := range series.Samples {
+			ptID := measurement + "\n" + id.String() + strconv.FormatInt(s.Timestamp, 10)
+
+			if pt, ok := idx[ptID]; ok {
+				pt.pt.Fields = append(pt.pt.Fields, NewKV(key, s.Value))
+				continue
+			}
+
+			kvs := append(append(KVs{}, tags...), NewKV(key, s.Value))
+			pt := &Point{pt: &PBPoint{
+				Name:   measurement,
+				Fields: kvs,
+				Time:   s.Timestamp * int64(time.Millisecond),
+			}}
+			pt.SetFlag(Ppb)
+
+			idx[ptID] = pt
+			pts = append(pts, pt)
+		}
+	}
+
+	return pts, nil
+}
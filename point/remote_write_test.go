@@ -0,0 +1,271 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package point
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	T "testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func remoteWriteRequest(t *T.T, payload []byte) *prompb.WriteRequest {
+	t.Helper()
+
+	raw, err := snappy.Decode(nil, payload)
+	require.NoError(t, err)
+
+	var req prompb.WriteRequest
+	require.NoError(t, req.Unmarshal(raw))
+	return &req
+}
+
+func TestRemoteWriteEncoding(t *T.T) {
+	t.Run(`content-type`, func(t *T.T) {
+		assert.Equal(t, RemoteWrite, EncodingStr(RemoteWrite.String()))
+		assert.Equal(t, "application/x-protobuf", RemoteWrite.HTTPContentType())
+
+		// generic protobuf content type not detected as remote-write
+		assert.Equal(t, LineProtocol, HTTPContentType(RemoteWrite.HTTPContentType()))
+
+		h := http.Header{}
+		h.Set("Content-Type", RemoteWrite.HTTPContentType())
+		assert.Equal(t, LineProtocol, HTTPHeaderEncoding(h))
+
+		h.Set("Content-Encoding", "snappy")
+		h.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+		assert.Equal(t, RemoteWrite, HTTPHeaderEncoding(h))
+
+		h = http.Header{}
+		h.Set("Content-Type", Arrow.HTTPContentType())
+		assert.Equal(t, Arrow, HTTPHeaderEncoding(h))
+	})
+
+	t.Run(`encode`, func(t *T.T) {
+		var kvs KVs
+		kvs = kvs.Add("usage", 3.14).
+			Add("total", int64(100)).
+			Add("ok", true).
+			Add("message", "not a sample").
+			AddTag("host", "h1").
+			AddTag("cpu.id", "0")
+
+		pt := NewPoint("cpu", kvs, WithTime(time.Unix(1, int64(2*time.Millisecond))))
+
+		enc := GetEncoder(WithEncEncoding(RemoteWrite))
+		defer PutEncoder(enc)
+
+		arr, err := enc.Encode([]*Point{pt})
+		require.NoError(t, err)
+		require.Len(t, arr, 1)
+
+		req := remoteWriteRequest(t, arr[0])
+		require.Len(t, req.Timeseries, 3) // string field ignored
+
+		ts := req.Timeseries[0]
+		assert.Equal(t, []prompb.Label{
+			{Name: "__measurement__", Value: "cpu"},
+			{Name: "__name__", Value: "cpu_usage"},
+			{Name: "cpu_id", Value: "0"}, // sanitized and sorted
+			{Name: "host", Value: "h1"},
+		}, ts.Labels)
+		assert.Equal(t, []prompb.Sample{{Value: 3.14, Timestamp: 1002}}, ts.Samples)
+
+		assert.Equal(t, 100.0, req.Timeseries[1].Samples[0].Value)
+		assert.Equal(t, 1.0, req.Timeseries[2].Samples[0].Value)
+	})
+
+	t.Run(`round-trip`, func(t *T.T) {
+		var pts []*Point
+		for i := 0; i < 10; i++ {
+			var kvs KVs
+			kvs = kvs.Add("f1", float64(i)).
+				Add("f2", float64(i)*2).
+				AddTag("host", fmt.Sprintf("host-%d", i%2)).
+				AddTag("region", "cn")
+
+			pts = append(pts, NewPoint(fmt.Sprintf("m%d", i%3), kvs,
+				WithTime(time.Unix(int64(i+1), 0))))
+		}
+
+		enc := GetEncoder(WithEncEncoding(RemoteWrite))
+		defer PutEncoder(enc)
+
+		arr, err := enc.Encode(pts)
+		require.NoError(t, err)
+		require.Len(t, arr, 1)
+
+		dec := GetDecoder(WithDecEncoding(RemoteWrite))
+		defer PutDecoder(dec)
+
+		res, err := dec.Decode(arr[0])
+		require.NoError(t, err)
+		require.Len(t, res, len(pts))
+
+		for i := range pts {
+			eq, reason := pts[i].EqualWithReason(res[i])
+			assert.True(t, eq, "reason: %s\n%s\n%s", reason, pts[i].Pretty(), res[i].Pretty())
+		}
+	})
+
+	t.Run(`measurement-with-underscore`, func(t *T.T) {
+		var kvs KVs
+		kvs = kvs.Add("used_percent", 1.0).AddTag("host", "h1")
+		pt := NewPoint("disk_io", kvs, WithTime(time.Unix(1, 0)))
+
+		enc := GetEncoder(WithEncEncoding(RemoteWrite))
+		defer PutEncoder(enc)
+
+		arr, err := enc.Encode([]*Point{pt})
+		require.NoError(t, err)
+		require.Len(t, arr, 1)
+
+		dec := GetDecoder(WithDecEncoding(RemoteWrite))
+		defer PutDecoder(dec)
+
+		res, err := dec.Decode(arr[0])
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+
+		eq, reason := pt.EqualWithReason(res[0])
+		assert.True(t, eq, "reason: %s\n%s\n%s", reason, pt.Pretty(), res[0].Pretty())
+	})
+
+	t.Run(`decode-prometheus`, func(t *T.T) {
+		// sent by Prometheus, no __measurement__ label
+		req := &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{{
+			Labels: []prompb.Label{
+				{Name: "__name__", Value: "node_load1"},
+				{Name: "host", Value: "h1"},
+			},
+			Samples: []prompb.Sample{{Value: 0.5, Timestamp: 1000}},
+		}}}
+
+		raw, err := req.Marshal()
+		require.NoError(t, err)
+
+		dec := GetDecoder(WithDecEncoding(RemoteWrite))
+		defer PutDecoder(dec)
+
+		res, err := dec.Decode(snappy.Encode(nil, raw))
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+
+		assert.Equal(t, "node", res[0].Name())
+		assert.Equal(t, 0.5, res[0].Get("load1"))
+		assert.Equal(t, "h1", res[0].GetTag("host"))
+
+		// duplicated labels
+		req.Timeseries[0].Labels = append(req.Timeseries[0].Labels, prompb.Label{Name: "host", Value: "h2"})
+		raw, err = req.Marshal()
+		require.NoError(t, err)
+
+		_, err = dec.Decode(snappy.Encode(nil, raw))
+		assert.ErrorContains(t, err, "duplicated label")
+	})
+
+	t.Run(`label-conflict`, func(t *T.T) {
+		enc := GetEncoder(WithEncEncoding(RemoteWrite))
+		defer PutEncoder(enc)
+
+		for _, tags := range []map[string]string{
+			{"cpu.id": "0", "cpu_id": "1"}, // both sanitized to cpu_id
+			{"__name__": "x"},
+			{"__measurement__": "x"},
+		} {
+			var kvs KVs
+			kvs = kvs.Add("f1", 1.0)
+			for k, v := range tags {
+				kvs = kvs.AddTag(k, v)
+			}
+
+			_, err := enc.Encode([]*Point{NewPoint("abc", kvs, WithTime(time.Unix(1, 0)))})
+			assert.ErrorContains(t, err, "conflict", "tags: %v", tags)
+		}
+	})
+
+	t.Run(`batch-bytes`, func(t *T.T) {
+		var pts []*Point
+		for i := 0; i < 100; i++ {
+			var kvs KVs
+			kvs = kvs.Add("f1", float64(i)).
+				Add("f2", int64(i)).
+				AddTag("host", fmt.Sprintf("host-%d", i))
+
+			pts = append(pts, NewPoint("abc", kvs, WithTime(time.Unix(int64(i+1), 0))))
+		}
+
+		const batchBytes = 512
+
+		var npts int
+		fn := func(n int, payload []byte) error {
+			npts += n
+			return nil
+		}
+
+		enc := GetEncoder(WithEncEncoding(RemoteWrite), WithEncBatchBytes(batchBytes), WithEncFn(fn))
+		defer PutEncoder(enc)
+
+		arr, err := enc.Encode(pts)
+		require.NoError(t, err)
+		require.True(t, len(arr) > 1)
+		assert.Equal(t, len(pts), npts)
+
+		dec := GetDecoder(WithDecEncoding(RemoteWrite))
+		defer PutDecoder(dec)
+
+		var total int
+		for _, payload := range arr {
+			req := remoteWriteRequest(t, payload)
+			assert.LessOrEqual(t, req.Size(), batchBytes)
+
+			res, err := dec.Decode(payload)
+			require.NoError(t, err)
+			total += len(res)
+		}
+
+		assert.Equal(t, len(pts), total)
+	})
+
+	t.Run(`batch-size`, func(t *T.T) {
+		var pts []*Point
+		for i := 0; i < 10; i++ {
+			pts = append(pts, NewPoint("abc", NewKVs(map[string]any{"f1": i}),
+				WithTime(time.Unix(int64(i+1), 0))))
+		}
+
+		enc := GetEncoder(WithEncEncoding(RemoteWrite), WithEncBatchSize(3))
+		defer PutEncoder(enc)
+
+		arr, err := enc.Encode(pts)
+		require.NoError(t, err)
+		assert.Len(t, arr, 4)
+	})
+
+	t.Run(`invalid-payload`, func(t *T.T) {
+		dec := GetDecoder(WithDecEncoding(RemoteWrite))
+		defer PutDecoder(dec)
+
+		_, err := dec.Decode([]byte("not a remote-write payload"))
+		assert.Error(t, err)
+
+		// decoded length(varint header) exceed the limit
+		huge := binary.AppendUvarint(nil, maxRemoteWriteBytes+1)
+		_, err = dec.Decode(append(huge, 0))
+		assert.ErrorContains(t, err, "too large")
+
+		sdec := GetDecoder(WithDecEncoding(RemoteWrite), WithDecFn(func([]*Point) error { return nil }))
+		defer PutDecoder(sdec)
+		assert.ErrorContains(t, sdec.DecodeReader(bytes.NewReader(append(huge, 0))), "too large")
+	})
+}
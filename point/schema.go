@@ -0,0 +1,397 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package point
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// SchemaOption used to configure the SchemaRegistry.
+type SchemaOption func(*SchemaRegistry)
+
+// WithSchemaCoerce enable coercing conflicted field value to the learned
+// type. The field is dropped if the value can not be coerced. By default,
+// type conflicts only flagged with warnings.
+func WithSchemaCoerce(on bool) SchemaOption {
+	return func(r *SchemaRegistry) { r.coerce = on }
+}
+
+// WithSchemaMaxTagKeys set max tag keys of each measurement, new tag keys
+// exceed the limit are dropped.
+func WithSchemaMaxTagKeys(n int) SchemaOption {
+	return func(r *SchemaRegistry) { r.maxTagKeys = n }
+}
+
+// WithSchemaMaxFieldKeys set max field keys of each measurement, new field
+// keys exceed the limit are dropped.
+func WithSchemaMaxFieldKeys(n int) SchemaOption {
+	return func(r *SchemaRegistry) { r.maxFieldKeys = n }
+}
+
+// WithSchemaMaxSeries set max time series(distinct tag values) of each
+// measurement, points of new series exceed the limit are flagged with
+// warnings.
+func WithSchemaMaxSeries(n int) SchemaOption {
+	return func(r *SchemaRegistry) { r.maxSeries = n }
+}
+
+type measurementSchema struct {
+	tags   map[string]struct{}
+	fields map[string]KeyType
+	series map[string]struct{}
+}
+
+func newMeasurementSchema() *measurementSchema {
+	return &measurementSchema{
+		tags:   map[string]struct{}{},
+		fields: map[string]KeyType{},
+		series: map[string]struct{}{},
+	}
+}
+
+// MeasurementSchema is the exported schema of a measurement, field types
+// are KeyType names(I/U/F/B/D/S/A).
+type MeasurementSchema struct {
+	Tags   []string          `json:"tags,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// SchemaRegistry learn measurement schema(tag keys and field types) from
+// observed points, and detect type conflicts across points(and batches).
+//
+// The first observed type of a field wins, later points with different type
+// on the field are flagged(or coerced, see WithSchemaCoerce) with warnings.
+type SchemaRegistry struct {
+	mtx          sync.Mutex
+	measurements map[string]*measurementSchema
+
+	coerce       bool
+	maxTagKeys   int
+	maxFieldKeys int
+	maxSeries    int
+}
+
+// NewSchemaRegistry create a empty SchemaRegistry.
+func NewSchemaRegistry(opts ...SchemaOption) *SchemaRegistry {
+	r := &SchemaRegistry{
+		measurements: map[string]*measurementSchema{},
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(r)
+		}
+	}
+
+	return r
+}
+
+func (r *SchemaRegistry) getMeasurement(name string) *measurementSchema {
+	ms, ok := r.measurements[name]
+	if !ok {
+		ms = newMeasurementSchema()
+		r.measurements[name] = ms
+	}
+	return ms
+}
+
+// Check learn schema from pt and check pt against the learned schema. Any
+// conflicts are added to pt as warnings.
+func (r *SchemaRegistry) Check(pt *Point) *Point {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	ms := r.getMeasurement(pt.pt.Name)
+
+	addWarn := func(t, msg string) {
+		pt.pt.Warns = append(pt.pt.Warns, &Warn{Type: t, Msg: msg})
+	}
+
+	drop := func(kv *Field) {
+		if defaultPTPool != nil {
+			defaultPTPool.PutKV(kv)
+		}
+	}
+
+	kvs := pt.pt.Fields
+	idx := 0
+
+	for _, kv := range kvs {
+		if kv.IsTag {
+			if _, ok := ms.fields[kv.Key]; ok {
+				addWarn(WarnSchemaTagFieldConflict,
+					fmt.Sprintf("tag %q is field in measurement %q", kv.Key, pt.pt.Name))
+			}
+
+			if _, ok := ms.tags[kv.Key]; !ok {
+				if r.maxTagKeys > 0 && len(ms.tags) >= r.maxTagKeys {
+					addWarn(WarnSchemaMaxTagKeys,
+						fmt.Sprintf("exceed max tag keys(%d) of measurement %q, tag %q dropped",
+							r.maxTagKeys, pt.pt.Name, kv.Key))
+					drop(kv)
+					continue
+				}
+
+				ms.tags[kv.Key] = struct{}{}
+			}
+
+			kvs[idx] = kv
+			idx++
+			continue
+		}
+
+		t := PBType(kv.Val)
+		if t == X { // nil field do not affect the schema
+			kvs[idx] = kv
+			idx++
+			continue
+		}
+
+		if _, ok := ms.tags[kv.Key]; ok {
+			addWarn(WarnSchemaTagFieldConflict,
+				fmt.Sprintf("field %q is tag in measurement %q", kv.Key, pt.pt.Name))
+		}
+
+		learned, ok := ms.fields[kv.Key]
+		switch {
+		case !ok:
+			if r.maxFieldKeys > 0 && len(ms.fields) >= r.maxFieldKeys {
+				addWarn(WarnSchemaMaxFieldKeys,
+					fmt.Sprintf("exceed max field keys(%d) of measurement %q, field %q dropped",
+						r.maxFieldKeys, pt.pt.Name, kv.Key))
+				drop(kv)
+				continue
+			}
+
+			ms.fields[kv.Key] = t
+
+		case learned != t:
+			if !r.coerce {
+				addWarn(WarnFieldTypeConflict,
+					fmt.Sprintf("field %q of measurement %q expect type %s, got %s",
+						kv.Key, pt.pt.Name, learned, t))
+				break
+			}
+
+			v, ok := coerceFieldValue(kv, learned)
+			if !ok {
+				addWarn(WarnFieldTypeConflict,
+					fmt.Sprintf("field %q of measurement %q expect type %s, got %s, unable to coerce, dropped",
+						kv.Key, pt.pt.Name, learned, t))
+				drop(kv)
+				continue
+			}
+
+			addWarn(WarnFieldTypeConflict,
+				fmt.Sprintf("field %q of measurement %q expect type %s, got %s, coerced",
+					kv.Key, pt.pt.Name, learned, t))
+
+			x := NewKV(kv.Key, v, WithKVUnit(kv.Unit), WithKVType(kv.Type))
+			drop(kv)
+			kv = x
+		}
+
+		kvs[idx] = kv
+		idx++
+	}
+
+	for j := idx; j < len(kvs); j++ { // remove deleted elems
+		kvs[j] = nil
+	}
+	pt.pt.Fields = kvs[:idx]
+
+	if r.maxSeries > 0 {
+		id := pt.MD5()
+		if _, ok := ms.series[id]; !ok {
+			if len(ms.series) >= r.maxSeries {
+				addWarn(WarnSchemaMaxSeries,
+					fmt.Sprintf("exceed max series(%d) of measurement %q", r.maxSeries, pt.pt.Name))
+			} else {
+				ms.series[id] = struct{}{}
+			}
+		}
+	}
+
+	return pt
+}
+
+// coerceFieldValue convert value of f to type t.
+//
+//nolint:exhaustive
+func coerceFieldValue(f *Field, t KeyType) (any, bool) {
+	switch x := f.Val.(type) {
+	case *Field_I:
+		switch t {
+		case U:
+			return uint64(x.I), x.I >= 0
+		case F:
+			return float64(x.I), true
+		case B:
+			return x.I != 0, true
+		case S:
+			return strconv.FormatInt(x.I, 10), true
+		}
+
+	case *Field_U:
+		switch t {
+		case I:
+			return int64(x.U), x.U <= math.MaxInt64
+		case F:
+			return float64(x.U), true
+		case B:
+			return x.U != 0, true
+		case S:
+			return strconv.FormatUint(x.U, 10), true
+		}
+
+	case *Field_F:
+		switch t {
+		case I:
+			return int64(x.F), x.F >= math.MinInt64 && x.F < math.MaxInt64
+		case U:
+			return uint64(x.F), x.F >= 0 && x.F < math.MaxUint64
+		case B:
+			return x.F != 0, true
+		case S:
+			return strconv.FormatFloat(x.F, 'f', -1, 64), true
+		}
+
+	case *Field_B:
+		var n int64
+		if x.B {
+			n = 1
+		}
+
+		switch t {
+		case I:
+			return n, true
+		case U:
+			return uint64(n), true
+		case F:
+			return float64(n), true
+		case S:
+			return strconv.FormatBool(x.B), true
+		}
+
+	case *Field_S:
+		switch t {
+		case I:
+			v, err := strconv.ParseInt(x.S, 10, 64)
+			return v, err == nil
+		case U:
+			v, err := strconv.ParseUint(x.S, 10, 64)
+			return v, err == nil
+		case F:
+			v, err := strconv.ParseFloat(x.S, 64)
+			return v, err == nil
+		case B:
+			v, err := strconv.ParseBool(x.S)
+			return v, err == nil
+		case D:
+			return []byte(x.S), true
+		}
+
+	case *Field_D:
+		if t == S {
+			return string(x.D), true
+		}
+	}
+
+	return nil, false
+}
+
+// Schema get learned schema of measurement name.
+func (r *SchemaRegistry) Schema(name string) *MeasurementSchema {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	ms, ok := r.measurements[name]
+	if !ok {
+		return nil
+	}
+
+	return ms.export()
+}
+
+func (ms *measurementSchema) export() *MeasurementSchema {
+	x := &MeasurementSchema{
+		Fields: map[string]string{},
+	}
+
+	for k := range ms.tags {
+		x.Tags = append(x.Tags, k)
+	}
+	sort.Strings(x.Tags)
+
+	for k, t := range ms.fields {
+		x.Fields[k] = t.String()
+	}
+
+	return x
+}
+
+// Export get learned schema of all measurements in JSON.
+func (r *SchemaRegistry) Export() ([]byte, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	res := map[string]*MeasurementSchema{}
+	for name, ms := range r.measurements {
+		res[name] = ms.export()
+	}
+
+	return json.Marshal(res)
+}
+
+// Import load schema exported by Export. Imported schema merged into the
+// registry, already learned field types are not overwritten.
+func (r *SchemaRegistry) Import(data []byte) error {
+	var res map[string]*MeasurementSchema
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+
+	// validate all field types before merging
+	for name, x := range res {
+		if x == nil {
+			continue
+		}
+
+		for k, t := range x.Fields {
+			if v, ok := KeyType_value[t]; !ok || KeyType(v) == X || KeyType(v) == NIL {
+				return fmt.Errorf("invalid type %q on field %q of measurement %q", t, k, name)
+			}
+		}
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	for name, x := range res {
+		if x == nil {
+			continue
+		}
+
+		ms := r.getMeasurement(name)
+
+		for _, k := range x.Tags {
+			ms.tags[k] = struct{}{}
+		}
+
+		for k, t := range x.Fields {
+			if _, ok := ms.fields[k]; !ok {
+				ms.fields[k] = KeyType(KeyType_value[t])
+			}
+		}
+	}
+
+	return nil
+}
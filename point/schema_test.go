@@ -0,0 +1,137 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package point
+
+import (
+	"fmt"
+	T "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func warnTypes(pt *Point) (arr []string) {
+	for _, w := range pt.Warns() {
+		arr = append(arr, w.Type)
+	}
+	return arr
+}
+
+func TestSchemaRegistry(t *T.T) {
+	t.Run(`type-conflict`, func(t *T.T) {
+		r := NewSchemaRegistry()
+
+		pt := r.Check(NewPoint("abc", NewKVs(map[string]any{"f1": 1, "f2": "str"}).AddTag("t1", "v1")))
+		assert.Empty(t, pt.Warns())
+
+		// conflicted on the next point
+		pt = r.Check(NewPoint("abc", NewKVs(map[string]any{"f1": "not-int", "f2": "str"})))
+		assert.Equal(t, []string{WarnFieldTypeConflict}, warnTypes(pt))
+		assert.Equal(t, "not-int", pt.Get("f1")) // kept
+
+		// same key on other measurement is ok
+		pt = r.Check(NewPoint("def", NewKVs(map[string]any{"f1": "str"})))
+		assert.Empty(t, pt.Warns())
+
+		// nil field not conflicted
+		pt = r.Check(NewPoint("abc", KVs{NewKV("f1", nil)}, WithPrecheck(false)))
+		assert.Empty(t, pt.Warns())
+	})
+
+	t.Run(`coerce`, func(t *T.T) {
+		r := NewSchemaRegistry(WithSchemaCoerce(true))
+
+		r.Check(NewPoint("abc", NewKVs(map[string]any{"i": 1, "f": 1.0, "s": "str", "b": false})))
+
+		pt := r.Check(NewPoint("abc", NewKVs(map[string]any{
+			"i": "123",
+			"f": 2,
+			"s": 3.14,
+			"b": "true",
+		})))
+
+		assert.Len(t, pt.Warns(), 4)
+		assert.Equal(t, int64(123), pt.Get("i"))
+		assert.Equal(t, 2.0, pt.Get("f"))
+		assert.Equal(t, "3.14", pt.Get("s"))
+		assert.Equal(t, true, pt.Get("b"))
+
+		// unable to coerce: field dropped
+		pt = r.Check(NewPoint("abc", NewKVs(map[string]any{"i": "not-int", "f": 1.5})))
+		assert.Equal(t, []string{WarnFieldTypeConflict}, warnTypes(pt))
+		assert.Nil(t, pt.Get("i"))
+		assert.Equal(t, 1.5, pt.Get("f"))
+	})
+
+	t.Run(`tag-field-conflict`, func(t *T.T) {
+		r := NewSchemaRegistry()
+
+		r.Check(NewPoint("abc", NewKVs(map[string]any{"f1": 1}).AddTag("t1", "v1")))
+
+		pt := r.Check(NewPoint("abc", NewKVs(map[string]any{"t1": 1}).AddTag("f1", "v1")))
+		assert.Equal(t, []string{WarnSchemaTagFieldConflict, WarnSchemaTagFieldConflict}, warnTypes(pt))
+	})
+
+	t.Run(`max-keys`, func(t *T.T) {
+		r := NewSchemaRegistry(WithSchemaMaxTagKeys(1), WithSchemaMaxFieldKeys(2))
+
+		r.Check(NewPoint("abc", NewKVs(map[string]any{"f1": 1}).AddTag("t1", "v1")))
+
+		var kvs KVs
+		kvs = kvs.Add("f1", 1).Add("f2", 2).Add("f3", 3).AddTag("t1", "v1").AddTag("t2", "v2")
+
+		pt := r.Check(NewPoint("abc", kvs))
+		assert.Equal(t, []string{WarnSchemaMaxFieldKeys, WarnSchemaMaxTagKeys}, warnTypes(pt))
+		assert.NotNil(t, pt.Get("f2"))
+		assert.Nil(t, pt.Get("f3"))
+		assert.Equal(t, "", pt.GetTag("t2"))
+
+		s := r.Schema("abc")
+		assert.Equal(t, []string{"t1"}, s.Tags)
+		assert.Equal(t, map[string]string{"f1": "I", "f2": "I"}, s.Fields)
+	})
+
+	t.Run(`max-series`, func(t *T.T) {
+		r := NewSchemaRegistry(WithSchemaMaxSeries(3))
+
+		for i := 0; i < 5; i++ {
+			pt := r.Check(NewPoint("abc", NewKVs(map[string]any{"f1": i}).AddTag("host", fmt.Sprintf("host-%d", i))))
+			if i < 3 {
+				assert.Empty(t, pt.Warns())
+			} else {
+				assert.Equal(t, []string{WarnSchemaMaxSeries}, warnTypes(pt))
+			}
+		}
+
+		// existing series still ok
+		pt := r.Check(NewPoint("abc", NewKVs(map[string]any{"f1": 1}).AddTag("host", "host-0")))
+		assert.Empty(t, pt.Warns())
+	})
+
+	t.Run(`export-import`, func(t *T.T) {
+		r := NewSchemaRegistry()
+		r.Check(NewPoint("abc", NewKVs(map[string]any{"f1": 1, "f2": "str"}).AddTag("t1", "v1")))
+		r.Check(NewPoint("def", NewKVs(map[string]any{"f1": 3.14})))
+
+		j, err := r.Export()
+		require.NoError(t, err)
+		t.Logf("schema: %s", j)
+
+		r2 := NewSchemaRegistry()
+		require.NoError(t, r2.Import(j))
+
+		assert.Equal(t, r.Schema("abc"), r2.Schema("abc"))
+		assert.Equal(t, r.Schema("def"), r2.Schema("def"))
+		assert.Nil(t, r2.Schema("xyz"))
+
+		// imported schema used for checking
+		pt := r2.Check(NewPoint("def", NewKVs(map[string]any{"f1": "str"})))
+		assert.Equal(t, []string{WarnFieldTypeConflict}, warnTypes(pt))
+
+		assert.Error(t, r2.Import([]byte(`{"abc":{"fields":{"f1":"bad-type"}}}`)))
+		assert.Error(t, r2.Import([]byte(`not-json`)))
+	})
+}
@@ -0,0 +1,387 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package point
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/filter"
+	"github.com/VictoriaMetrics/easyproto"
+	types "github.com/gogo/protobuf/types"
+)
+
+// PointView is a read-only view over a single protobuf encoded PBPoint. Only
+// the name and time are decoded on Reset(), key-values are decoded on demand.
+//
+// Strings(and bytes) got from the view are reference to the underlying
+// bytes, so the bytes should not be modified while the view or values got
+// from it are in use.
+type PointView struct {
+	src  []byte
+	name string
+	ts   int64
+	kvs  [][]byte // raw Field messages
+
+	// reused during key-value decoding
+	fc easyproto.FieldContext
+	kv Field
+	vi Field_I
+	vu Field_U
+	vf Field_F
+	vb Field_B
+	vd Field_D
+	vs Field_S
+	va Field_A
+	a  types.Any
+}
+
+// NewPointView create view over PBPoint bytes data.
+func NewPointView(data []byte) (*PointView, error) {
+	v := &PointView{}
+	if err := v.Reset(data); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// Reset reuse the view on another PBPoint bytes data.
+func (v *PointView) Reset(data []byte) (err error) {
+	v.src = data
+	v.name = ""
+	v.ts = 0
+	v.kvs = v.kvs[:0]
+
+	src := data
+	for len(src) > 0 {
+		src, err = v.fc.NextField(src)
+		if err != nil {
+			return fmt.Errorf("read next field for PBPoint failed: %w", err)
+		}
+
+		switch v.fc.FieldNum {
+		case 1:
+			x, ok := v.fc.String()
+			if !ok {
+				return fmt.Errorf("cannot read PBPoint name")
+			}
+			v.name = x
+
+		case 2:
+			x, ok := v.fc.MessageData()
+			if !ok {
+				return fmt.Errorf("cannot read Fields for PBPoint")
+			}
+			v.kvs = append(v.kvs, x)
+
+		case 3:
+			x, ok := v.fc.Int64()
+			if !ok {
+				return fmt.Errorf("cannot read PBPoint time")
+			}
+			v.ts = x
+		}
+	}
+
+	return nil
+}
+
+// Name return point's measurement name.
+func (v *PointView) Name() string {
+	return v.name
+}
+
+// Time return point's time.
+func (v *PointView) Time() time.Time {
+	return time.Unix(0, v.ts)
+}
+
+// Bytes return the underlying PBPoint bytes, used to forward the point
+// without re-encoding.
+func (v *PointView) Bytes() []byte {
+	return v.src
+}
+
+// Point decode the view into Point.
+func (v *PointView) Point() (*Point, error) {
+	var pbpt PBPoint
+	if err := pbpt.Unmarshal(v.src); err != nil {
+		return nil, err
+	}
+
+	pt := &Point{pt: &pbpt}
+	pt.SetFlag(Ppb)
+	return pt, nil
+}
+
+// find get the first key-value with key k.
+func (v *PointView) find(k string) *Field {
+	for _, raw := range v.kvs {
+		if key, ok := v.key(raw); !ok || key != k {
+			continue
+		}
+
+		kv, err := v.decodeKV(raw)
+		if err != nil {
+			return nil
+		}
+		return kv
+	}
+
+	return nil
+}
+
+// Get get value of key k, if k not exist, return nil.
+func (v *PointView) Get(k string) any {
+	if kv := v.find(k); kv != nil {
+		return kv.Raw()
+	}
+	return nil
+}
+
+// GetTag get value of tag k.
+// If key k not tag or k not exist, return empty string.
+func (v *PointView) GetTag(k string) string {
+	for _, raw := range v.kvs {
+		if key, ok := v.key(raw); !ok || key != k {
+			continue
+		}
+
+		kv, err := v.decodeKV(raw)
+		if err != nil || !kv.IsTag {
+			continue
+		}
+
+		return kv.GetS()
+	}
+
+	return ""
+}
+
+// RangeKVs iterate all key-values of the view. The kv passed to fn is reused
+// across iterations, fn should not hold it after returned. Iteration stopped
+// if fn returned false.
+func (v *PointView) RangeKVs(fn func(kv *Field) bool) error {
+	for _, raw := range v.kvs {
+		kv, err := v.decodeKV(raw)
+		if err != nil {
+			return err
+		}
+
+		if !fn(kv) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// FilterKVs get the view as filter.KVs, used to evaluate filter conditions
+// on the view.
+func (v *PointView) FilterKVs() filter.KVs {
+	return (*viewKVs)(v)
+}
+
+type viewKVs PointView
+
+// Get implement filter.KVs.
+func (x *viewKVs) Get(k string) (any, bool) {
+	if kv := (*PointView)(x).find(k); kv != nil && kv.Val != nil {
+		return kv.Raw(), true
+	}
+
+	return nil, false
+}
+
+// key get key of raw Field message.
+func (v *PointView) key(src []byte) (string, bool) {
+	var err error
+	for len(src) > 0 {
+		src, err = v.fc.NextField(src)
+		if err != nil {
+			return "", false
+		}
+
+		if v.fc.FieldNum == 1 {
+			return v.fc.String()
+		}
+	}
+
+	return "", false
+}
+
+// decodeKV decode raw Field message into the reused v.kv.
+func (v *PointView) decodeKV(src []byte) (*Field, error) {
+	var (
+		kv  = &v.kv
+		err error
+	)
+
+	*kv = Field{}
+
+	for len(src) > 0 {
+		src, err = v.fc.NextField(src)
+		if err != nil {
+			return nil, fmt.Errorf("read next field for Field failed: %w", err)
+		}
+
+		switch v.fc.FieldNum {
+		case 1:
+			x, ok := v.fc.String()
+			if !ok {
+				return nil, fmt.Errorf("cannot read Field key")
+			}
+			kv.Key = x
+
+		case 2:
+			x, ok := v.fc.Int64()
+			if !ok {
+				return nil, fmt.Errorf("cannot unmarshal int64 for Field")
+			}
+			v.vi.I = x
+			kv.Val = &v.vi
+
+		case 3:
+			x, ok := v.fc.Uint64()
+			if !ok {
+				return nil, fmt.Errorf("cannot unmarshal uint64 for Field")
+			}
+			v.vu.U = x
+			kv.Val = &v.vu
+
+		case 4:
+			x, ok := v.fc.Double()
+			if !ok {
+				return nil, fmt.Errorf("cannot unmarshal double for Field")
+			}
+			v.vf.F = x
+			kv.Val = &v.vf
+
+		case 5:
+			x, ok := v.fc.Bool()
+			if !ok {
+				return nil, fmt.Errorf("cannot unmarshal bool for Field")
+			}
+			v.vb.B = x
+			kv.Val = &v.vb
+
+		case 6:
+			x, ok := v.fc.Bytes()
+			if !ok {
+				return nil, fmt.Errorf("cannot unmarshal bytes for Field")
+			}
+			v.vd.D = x
+			kv.Val = &v.vd
+
+		case 11:
+			x, ok := v.fc.String()
+			if !ok {
+				return nil, fmt.Errorf("cannot unmarshal string for Field")
+			}
+			v.vs.S = x
+			kv.Val = &v.vs
+
+		case 7:
+			x, ok := v.fc.MessageData()
+			if !ok {
+				return nil, fmt.Errorf("cannot unmarshal any for Field")
+			}
+
+			if err := v.decodeAny(x); err != nil {
+				return nil, err
+			}
+			v.va.A = &v.a
+			kv.Val = &v.va
+
+		case 8:
+			x, ok := v.fc.Bool()
+			if !ok {
+				return nil, fmt.Errorf("cannot unmarshal is-tag for Field")
+			}
+			kv.IsTag = x
+
+		case 9:
+			x, ok := v.fc.Int32()
+			if !ok {
+				return nil, fmt.Errorf("cannot unmarshal int32 for Field")
+			}
+			kv.Type = MetricType(x)
+
+		case 10:
+			x, ok := v.fc.String()
+			if !ok {
+				return nil, fmt.Errorf("cannot unmarshal unit for Field")
+			}
+			kv.Unit = x
+		}
+	}
+
+	return kv, nil
+}
+
+// decodeAny decode raw Any message into the reused v.a. It's safe to reuse
+// v.fc here: decodeKV do not read v.fc until its next NextField().
+func (v *PointView) decodeAny(src []byte) (err error) {
+	v.a = types.Any{}
+
+	for len(src) > 0 {
+		src, err = v.fc.NextField(src)
+		if err != nil {
+			return fmt.Errorf("read next field for Any failed: %w", err)
+		}
+
+		switch v.fc.FieldNum {
+		case 1:
+			if x, ok := v.fc.String(); ok {
+				v.a.TypeUrl = x
+			}
+		case 2:
+			if x, ok := v.fc.Bytes(); ok {
+				v.a.Value = x
+			}
+		}
+	}
+
+	return nil
+}
+
+// RangePointViews iterate PBPoints bytes src as point views. The view passed
+// to fn is reused across iterations, fn should not hold it after returned
+// (use PointView.Bytes() or PointView.Point() to keep the point). Iteration
+// stopped if fn returned false.
+func RangePointViews(src []byte, fn func(v *PointView) bool) (err error) {
+	var (
+		fc easyproto.FieldContext
+		v  PointView
+	)
+
+	for len(src) > 0 {
+		src, err = fc.NextField(src)
+		if err != nil {
+			return fmt.Errorf("read next field for PBPoints failed: %w", err)
+		}
+
+		if fc.FieldNum != 1 {
+			continue
+		}
+
+		data, ok := fc.MessageData()
+		if !ok {
+			return fmt.Errorf("cannot read PBPoint for PBPoints")
+		}
+
+		if err := v.Reset(data); err != nil {
+			return fmt.Errorf("unmarshal point failed: %w", err)
+		}
+
+		if !fn(&v) {
+			return nil
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package point
+
+import (
+	T "testing"
+
+	"github.com/GuanceCloud/cliutils/filter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPointView(t *T.T) {
+	var kvs KVs
+	kvs = kvs.Add("i", int64(42), WithKVUnit("byte"), WithKVType(GAUGE)).
+		Add("u", uint64(7)).
+		Add("f", 3.14).
+		Add("b", true).
+		Add("d", []byte("binary-data")).
+		Add("s", "text-data").
+		Add("arr", MustNewAnyArray(1, 2, 3)).
+		AddTag("host", "h1").
+		AddTag("service", "s1")
+
+	pt := NewPoint("cpu", kvs, WithTimestamp(123))
+
+	data, err := pt.PBPoint().Marshal()
+	require.NoError(t, err)
+
+	t.Run(`accessors`, func(t *T.T) {
+		v, err := NewPointView(data)
+		require.NoError(t, err)
+
+		assert.Equal(t, "cpu", v.Name())
+		assert.Equal(t, pt.Time(), v.Time())
+		assert.Equal(t, data, v.Bytes())
+
+		for _, k := range []string{"i", "u", "f", "b", "d", "s", "arr", "host", "not-exist"} {
+			assert.Equal(t, pt.Get(k), v.Get(k), "key %q", k)
+		}
+
+		assert.Equal(t, "h1", v.GetTag("host"))
+		assert.Equal(t, "", v.GetTag("s")) // not tag
+		assert.Equal(t, "", v.GetTag("not-exist"))
+
+		pt2, err := v.Point()
+		require.NoError(t, err)
+		assert.Equal(t, pt.Pretty(), pt2.Pretty())
+	})
+
+	t.Run(`range-kvs`, func(t *T.T) {
+		v, err := NewPointView(data)
+		require.NoError(t, err)
+
+		var got KVs
+		require.NoError(t, v.RangeKVs(func(kv *Field) bool {
+			got = got.AddKV(NewKV(kv.Key, kv.Raw(),
+				WithKVTagSet(kv.IsTag), WithKVUnit(kv.Unit), WithKVType(kv.Type)))
+			return true
+		}))
+		assert.Equal(t, pt.KVs().Pretty(), got.Pretty())
+
+		n := 0
+		require.NoError(t, v.RangeKVs(func(*Field) bool {
+			n++
+			return n < 2
+		}))
+		assert.Equal(t, 2, n)
+	})
+
+	t.Run(`filter`, func(t *T.T) {
+		v, err := NewPointView(data)
+		require.NoError(t, err)
+
+		for _, tc := range []struct {
+			cond string
+			pass bool
+		}{
+			{"{ host = 'h1' and i > 10 }", true},
+			{"{ host = 'h2' }", false},
+			{"{ s match ['text.*'] }", true},
+			{"{ not_exist in ['x'] }", false},
+		} {
+			conds, err := filter.GetConds(tc.cond)
+			require.NoError(t, err)
+			assert.Equal(t, tc.pass, conds.Eval(v.FilterKVs()) >= 0, "cond: %s", tc.cond)
+		}
+	})
+
+	t.Run(`no-alloc`, func(t *T.T) {
+		v, err := NewPointView(data)
+		require.NoError(t, err)
+
+		allocs := T.AllocsPerRun(100, func() {
+			require.NoError(t, v.Reset(data))
+			_ = v.Name()
+			_ = v.Time()
+			_ = v.GetTag("service")
+			_ = v.RangeKVs(func(kv *Field) bool { return kv.Key != "" })
+		})
+		assert.Zero(t, allocs)
+
+		// filter.KVs box the value into any
+		allocs = T.AllocsPerRun(100, func() {
+			_, _ = v.FilterKVs().Get("host")
+		})
+		assert.LessOrEqual(t, allocs, 1.0)
+	})
+
+	t.Run(`range-point-views`, func(t *T.T) {
+		pts := NewRander().Rand(10)
+
+		enc := GetEncoder(WithEncEncoding(Protobuf))
+		defer PutEncoder(enc)
+
+		arr, err := enc.Encode(pts)
+		require.NoError(t, err)
+		require.Len(t, arr, 1)
+
+		i := 0
+		require.NoError(t, RangePointViews(arr[0], func(v *PointView) bool {
+			assert.Equal(t, pts[i].Name(), v.Name())
+			assert.Equal(t, pts[i].Time(), v.Time())
+
+			pt, err := v.Point()
+			assert.NoError(t, err)
+			assert.Equal(t, pts[i].Pretty(), pt.Pretty())
+
+			i++
+			return true
+		}))
+		assert.Equal(t, len(pts), i)
+	})
+
+	t.Run(`invalid`, func(t *T.T) {
+		_, err := NewPointView([]byte{0x0a, 0xff})
+		assert.Error(t, err)
+
+		assert.Error(t, RangePointViews([]byte{0x0a, 0xff}, func(*PointView) bool { return true }))
+	})
+}
+
+func BenchmarkPointView(b *T.B) {
+	r := NewRander(WithFixedTags(true))
+	pts := r.Rand(1000)
+
+	enc := GetEncoder(WithEncEncoding(Protobuf))
+	defer PutEncoder(enc)
+
+	arr, err := enc.Encode(pts)
+	require.NoError(b, err)
+
+	src := arr[0]
+	tag := pts[0].Tags()[0].Key
+
+	b.Run("easybatch-get-tag", func(b *T.B) {
+		bp := NewBatchPoints()
+		defer bp.Release()
+
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			bp.Reset()
+			assert.NoError(b, bp.Unmarshal(src))
+			for _, pt := range bp.Points {
+				_ = pt.GetTag(tag)
+			}
+		}
+	})
+
+	b.Run("view-get-tag", func(b *T.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			assert.NoError(b, RangePointViews(src, func(v *PointView) bool {
+				_ = v.GetTag(tag)
+				return true
+			}))
+		}
+	})
+}